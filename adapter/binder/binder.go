@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"math/big"
 
-	"github.com/renproject/swapperd/adapter/binder/btc"
 	"github.com/renproject/swapperd/adapter/binder/erc20"
 	"github.com/renproject/swapperd/adapter/binder/eth"
+	"github.com/renproject/swapperd/adapter/binder/feebump"
+	"github.com/renproject/swapperd/adapter/binder/utxo"
 	"github.com/renproject/swapperd/adapter/wallet"
 	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
+	"github.com/renproject/swapperd/core/wallet/swapper/watcher"
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
 	"github.com/renproject/tokens"
@@ -19,12 +21,21 @@ import (
 type builder struct {
 	wallet.Wallet
 	logrus.FieldLogger
+	tracker *feebump.Tracker
 }
 
-func NewBuilder(wallet wallet.Wallet, logger logrus.FieldLogger) immediate.ContractBuilder {
+// A Builder builds the contracts of swaps, and estimates their costs.
+type Builder interface {
+	immediate.ContractBuilder
+	watcher.Builder
+	EstimateSwapCosts(blob swap.SwapBlob) (blockchain.Cost, blockchain.Cost, error)
+}
+
+func NewBuilder(wallet wallet.Wallet, logger logrus.FieldLogger) Builder {
 	return &builder{
 		wallet,
 		logger,
+		feebump.NewTracker(),
 	}
 }
 
@@ -44,26 +55,88 @@ func (builder *builder) BuildSwapContracts(req immediate.SwapRequest) (immediate
 	return nativeBinder, foreignBinder, nil
 }
 
-func (builder *builder) buildBinder(swap swap.Swap, cost blockchain.Cost, password string) (immediate.Contract, error) {
+// BuildSecretSource builds the native contract of a swap, which reveals the
+// secret once the counterparty broadcasts a transaction that redeems it.
+func (builder *builder) BuildSecretSource(req immediate.SwapRequest) (watcher.SecretSource, error) {
+	native, _, err := builder.buildComplementarySwaps(req.Blob)
+	if err != nil {
+		return nil, err
+	}
+	nativeBinder, err := builder.buildBinder(native, blockchain.Cost{}, req.Blob.Password)
+	if err != nil {
+		return nil, err
+	}
+	source, ok := nativeBinder.(watcher.SecretSource)
+	if !ok {
+		return nil, fmt.Errorf("cannot watch pending transactions of %s", native.Token.Name)
+	}
+	return source, nil
+}
+
+// EstimateSwapCosts validates the contracts of the swap, without building
+// them, and estimates the cost of initiating the native contract and of
+// redeeming the foreign contract.
+func (builder *builder) EstimateSwapCosts(blob swap.SwapBlob) (blockchain.Cost, blockchain.Cost, error) {
+	native, foreign, err := builder.buildComplementarySwaps(blob)
+	if err != nil {
+		return nil, nil, err
+	}
+	sendCost, err := builder.estimateCost(native, blob.Password, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	receiveCost, err := builder.estimateCost(foreign, blob.Password, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sendCost, receiveCost, nil
+}
+
+func (builder *builder) estimateCost(swap swap.Swap, password string, initiate bool) (blockchain.Cost, error) {
+	if chain, err := utxo.ChainOf(swap.Token.Blockchain); err == nil {
+		return utxo.EstimateCost(chain, swap, initiate), nil
+	}
 	switch swap.Token.Blockchain {
-	case tokens.BITCOIN:
-		btcAccount, err := builder.BitcoinAccount(password)
+	case tokens.ETHEREUM:
+		ethAccount, err := builder.EthereumAccount(password)
+		if err != nil {
+			return nil, err
+		}
+		return eth.EstimateCost(ethAccount, swap, initiate)
+	case tokens.ERC20:
+		ethAccount, err := builder.EthereumAccount(password)
+		if err != nil {
+			return nil, err
+		}
+		return erc20.EstimateCost(ethAccount, swap, initiate)
+	default:
+		return nil, tokens.NewErrUnsupportedToken(string(swap.Token.Name))
+	}
+}
+
+func (builder *builder) buildBinder(swap swap.Swap, cost blockchain.Cost, password string) (immediate.Contract, error) {
+	// Bitcoin and its forks are bound by the utxo binder, whatever their
+	// backend.
+	if _, err := utxo.ChainOf(swap.Token.Blockchain); err == nil {
+		account, err := builder.UTXOAccount(password, swap.Token.Blockchain)
 		if err != nil {
 			return nil, err
 		}
-		return btc.NewBTCSwapContractBinder(btcAccount, swap, cost, builder.FieldLogger)
+		return utxo.NewUTXOSwapContractBinder(account, swap, cost, builder.tracker, builder.FieldLogger)
+	}
+	switch swap.Token.Blockchain {
 	case tokens.ETHEREUM:
 		ethAccount, err := builder.EthereumAccount(password)
 		if err != nil {
 			return nil, err
 		}
-		return eth.NewETHSwapContractBinder(ethAccount, swap, cost, builder.FieldLogger)
+		return eth.NewETHSwapContractBinder(ethAccount, swap, cost, builder.tracker, builder.FieldLogger)
 	case tokens.ERC20:
 		ethAccount, err := builder.EthereumAccount(password)
 		if err != nil {
 			return nil, err
 		}
-		return erc20.NewERC20SwapContractBinder(ethAccount, swap, cost, builder.FieldLogger)
+		return erc20.NewERC20SwapContractBinder(ethAccount, swap, cost, builder.tracker, builder.FieldLogger)
 	default:
 		return nil, tokens.NewErrUnsupportedToken(string(swap.Token.Name))
 	}
@@ -88,7 +161,7 @@ func (builder *builder) buildComplementarySwaps(blob swap.SwapBlob) (swap.Swap,
 }
 
 func (builder *builder) buildNativeSwap(blob swap.SwapBlob, timelock int64, fundingAddress string) (swap.Swap, error) {
-	token, err := tokens.PatchToken(blob.SendToken)
+	token, err := blockchain.PatchToken(blob.SendToken)
 	if err != nil {
 		return swap.Swap{}, err
 	}
@@ -116,16 +189,17 @@ func (builder *builder) buildNativeSwap(blob swap.SwapBlob, timelock int64, fund
 		Value:           value,
 		Speed:           blob.Speed,
 		SecretHash:      secretHash,
-		TimeLock:        blob.TimeLock,
+		TimeLock:        timelock,
 		SpendingAddress: blob.SendTo,
 		FundingAddress:  fundingAddress,
 		BrokerAddress:   blob.BrokerSendTokenAddr,
 		BrokerFee:       brokerFee,
+		ScriptType:      blob.BitcoinScriptType,
 	}, nil
 }
 
 func (builder *builder) buildForeignSwap(blob swap.SwapBlob, timelock int64, spendingAddress string) (swap.Swap, error) {
-	token, err := tokens.PatchToken(string(blob.ReceiveToken))
+	token, err := blockchain.PatchToken(string(blob.ReceiveToken))
 	if err != nil {
 		return swap.Swap{}, err
 	}
@@ -159,28 +233,33 @@ func (builder *builder) buildForeignSwap(blob swap.SwapBlob, timelock int64, spe
 		Value:           value,
 		Speed:           blob.Speed,
 		SecretHash:      secretHash,
-		TimeLock:        blob.TimeLock,
+		TimeLock:        timelock,
 		SpendingAddress: spendingAddress,
 		FundingAddress:  blob.ReceiveFrom,
 		WithdrawAddress: withdrawAddress,
 		BrokerAddress:   blob.BrokerReceiveTokenAddr,
 		BrokerFee:       brokerFee,
+		Confirmations:   builder.Wallet.Confirmations(token.Blockchain),
+		ScriptType:      blob.BitcoinScriptType,
 	}, nil
 }
 
+// calculateTimeLocks returns the timelocks of the native and foreign
+// contracts. The responder's contract expires TimeLockMargin seconds before
+// the initiator's contract.
 func (builder *builder) calculateTimeLocks(swap swap.SwapBlob) (native, foreign int64) {
 	if swap.ShouldInitiateFirst {
 		native = swap.TimeLock
-		foreign = swap.TimeLock - 24*60*60
+		foreign = swap.ResponderTimeLock()
 		return
 	}
-	native = swap.TimeLock - 24*60*60
+	native = swap.ResponderTimeLock()
 	foreign = swap.TimeLock
 	return
 }
 
 func (builder *builder) calculateAddresses(swap swap.SwapBlob) (string, string, error) {
-	sendToken, err := tokens.PatchToken(swap.SendToken)
+	sendToken, err := blockchain.PatchToken(swap.SendToken)
 	if err != nil {
 		return "", "", err
 	}
@@ -188,7 +267,7 @@ func (builder *builder) calculateAddresses(swap swap.SwapBlob) (string, string,
 	if err != nil {
 		return "", "", err
 	}
-	receiveToken, err := tokens.PatchToken(swap.ReceiveToken)
+	receiveToken, err := blockchain.PatchToken(swap.ReceiveToken)
 	if err != nil {
 		return "", "", err
 	}
@@ -0,0 +1,39 @@
+package binder
+
+import (
+	"testing/quick"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/swapperd/testutils"
+)
+
+var _ = Describe("Binder", func() {
+	Context("when calculating the timelocks of a swap", func() {
+		It("should let the responder's contract expire the timelock margin before the initiator's contract", func() {
+			test := func(blob swap.SwapBlob, margin uint16) bool {
+				blob.TimeLockMargin = int64(margin) + 1
+				native, foreign := new(builder).calculateTimeLocks(blob)
+				if blob.ShouldInitiateFirst {
+					return native == blob.TimeLock && foreign == blob.TimeLock-blob.TimeLockMargin
+				}
+				return native == blob.TimeLock-blob.TimeLockMargin && foreign == blob.TimeLock
+			}
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should use the legacy margin for swaps that were negotiated without a margin", func() {
+			test := func(blob swap.SwapBlob) bool {
+				blob.TimeLockMargin = 0
+				native, foreign := new(builder).calculateTimeLocks(blob)
+				if blob.ShouldInitiateFirst {
+					return native == blob.TimeLock && foreign == blob.TimeLock-swap.LegacyTimeLockMargin
+				}
+				return native == blob.TimeLock-swap.LegacyTimeLockMargin && foreign == blob.TimeLock
+			}
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+	})
+})
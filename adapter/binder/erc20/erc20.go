@@ -3,14 +3,19 @@ package erc20
 import (
 	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/renproject/libeth-go"
+	"github.com/renproject/swapperd/adapter/binder/eth"
+	"github.com/renproject/swapperd/adapter/binder/feebump"
 	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
@@ -21,17 +26,26 @@ import (
 type erc20SwapContractBinder struct {
 	id             [32]byte
 	account        libeth.Account
-	speed          libeth.TxExecutionSpeed
 	swap           swap.Swap
 	logger         logrus.FieldLogger
 	swapperAddress common.Address
 	swapperBinder  *ERC20SwapContract
 	erc20          libeth.ERC20
 	cost           blockchain.Cost
+	details        swap.ContractDetails
+	tracker        *feebump.Tracker
 }
 
+// AuditLookbackBlocks is the number of recent blocks that are searched for the
+// transaction that initiated a swap.
+const AuditLookbackBlocks = 20000
+
+// logOpenTopic is the topic of the LogOpen event emitted by the swap contract
+// when a swap is initiated. The event is not part of the generated bindings.
+var logOpenTopic = crypto.Keccak256Hash([]byte("LogOpen(bytes32,address,bytes32)"))
+
 // NewERC20SwapContractBinder returns a new ERC20 Atom instance
-func NewERC20SwapContractBinder(account libeth.Account, swap swap.Swap, cost blockchain.Cost, logger logrus.FieldLogger) (immediate.Contract, error) {
+func NewERC20SwapContractBinder(account libeth.Account, swap swap.Swap, cost blockchain.Cost, tracker *feebump.Tracker, logger logrus.FieldLogger) (immediate.Contract, error) {
 	erc20, err := account.NewERC20(string(swap.Token.Name))
 	if err != nil {
 		return nil, err
@@ -68,17 +82,19 @@ func NewERC20SwapContractBinder(account libeth.Account, swap swap.Swap, cost blo
 
 	swap.Value = new(big.Int).Add(swap.Value, swap.BrokerFee)
 
-	return &erc20SwapContractBinder{
+	atom := &erc20SwapContractBinder{
 		account:        account,
 		swapperAddress: swapperAddress,
 		swapperBinder:  swapperBinder,
 		erc20:          erc20,
-		speed:          libeth.TxExecutionSpeed(swap.Speed),
 		logger:         logger,
 		swap:           swap,
 		id:             id,
 		cost:           cost,
-	}, nil
+		tracker:        tracker,
+	}
+	atom.details.ContractID = "0x" + hex.EncodeToString(id[:])
+	return atom, nil
 }
 
 // Initiate a new Atom swap by calling a function on ethereum
@@ -91,23 +107,31 @@ func (atom *erc20SwapContractBinder) Initiate() error {
 		return err
 	}
 
+	key := feebump.Key(atom.details.ContractID, "initiate")
 	if !initiatable {
+		atom.tracker.Forget(key)
 		atom.logger.Info(fmt.Sprintf("Skipping initiate on Ethereum blockchain"))
 		return nil
 	}
 	atom.logger.Info(fmt.Sprintf("Initiating on Ethereum blockchain"))
 
-	// Approve the contract to transfer tokens
-	approveTx, err := atom.erc20.Approve(ctx, atom.swapperAddress, atom.sendValue(), atom.speed)
-	if err != nil {
-		return err
+	// Approve the contract to transfer tokens, unless it was approved by a
+	// previous attempt whose initiate transaction is still pending
+	if _, ok := atom.tracker.Pending(key); !ok {
+		approveTx, err := atom.erc20.Approve(ctx, atom.swapperAddress, atom.sendValue(), libeth.TxExecutionSpeed(atom.swap.Speed))
+		if err != nil {
+			return err
+		}
+		atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], approveTx.Cost())
 	}
-	atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], approveTx.Cost())
 
 	// Initiate the Atomic Swap
-	initiateTx, err := atom.account.Transact(
+	initiateTx, err := eth.Transact(
 		ctx,
-		atom.speed,
+		atom.account,
+		atom.tracker,
+		key,
+		atom.swap.Speed,
 		nil,
 		func(tops *bind.TransactOpts) (*types.Transaction, error) {
 			var tx *types.Transaction
@@ -117,7 +141,6 @@ func (atom *erc20SwapContractBinder) Initiate() error {
 				if err != nil {
 					return tx, err
 				}
-				atom.cost[atom.swap.Token.Name] = new(big.Int).Add(atom.cost[atom.swap.Token.Name], atom.swap.BrokerFee)
 			} else {
 				tx, err = atom.swapperBinder.Initiate(tops, atom.id, common.HexToAddress(atom.swap.SpendingAddress), atom.swap.SecretHash, big.NewInt(atom.swap.TimeLock), atom.sendValue())
 				if err != nil {
@@ -140,7 +163,11 @@ func (atom *erc20SwapContractBinder) Initiate() error {
 	if err != nil {
 		return err
 	}
-	atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], initiateTx.Cost())
+	atom.addTxFee(initiateTx)
+	if atom.swap.BrokerFee.Cmp(big.NewInt(0)) > 0 {
+		atom.cost[atom.swap.Token.Name] = new(big.Int).Add(atom.cost[atom.swap.Token.Name], atom.swap.BrokerFee)
+	}
+	atom.details.InitiateTxHash = initiateTx.Hash
 	return nil
 }
 
@@ -149,9 +176,13 @@ func (atom *erc20SwapContractBinder) Refund() error {
 	atom.logger.Info("Refunding on Ethereum blockchain")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
-	tx, err := atom.account.Transact(
+	key := feebump.Key(atom.details.ContractID, "refund")
+	tx, err := eth.Transact(
 		ctx,
-		atom.speed,
+		atom.account,
+		atom.tracker,
+		key,
+		atom.swap.Speed,
 		func() bool {
 			refundable, err := atom.swapperBinder.Refundable(&bind.CallOpts{}, atom.id)
 			if err != nil {
@@ -177,13 +208,28 @@ func (atom *erc20SwapContractBinder) Refund() error {
 		},
 		1,
 	)
-	if err != nil && err != libeth.ErrPreConditionCheckFailed {
-		return err
+	if err != nil {
+		if err != libeth.ErrPreConditionCheckFailed {
+			return err
+		}
+		// The contract cannot be refunded yet, or it is not initiated yet,
+		// or it has already been spent. Only the last is final.
+		spent, err := atom.spent()
+		if err != nil {
+			return err
+		}
+		if !spent {
+			return immediate.ErrAuditPending
+		}
+		atom.tracker.Forget(key)
+		atom.logger.Info("Skipping refund on Ethereum blockchain")
+		return nil
 	}
-	atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], tx.Cost())
+	atom.addTxFee(tx)
 	if _, ok := atom.cost[atom.swap.Token.Name]; ok {
 		atom.cost[atom.swap.Token.Name] = new(big.Int).Sub(atom.cost[atom.swap.Token.Name], atom.swap.BrokerFee)
 	}
+	atom.details.RefundTxHash = tx.Hash
 	return nil
 }
 
@@ -212,6 +258,24 @@ func (atom *erc20SwapContractBinder) AuditSecret() ([32]byte, error) {
 	return secret, nil
 }
 
+// PendingSecret returns the secret revealed by a pending transaction that
+// redeems the swap, by calling the contract in the pending state of the
+// Ethereum node.
+func (atom *erc20SwapContractBinder) PendingSecret() ([32]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	opts := &bind.CallOpts{Pending: true, Context: ctx}
+	redeemable, err := atom.swapperBinder.Redeemable(opts, atom.id)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if redeemable {
+		return [32]byte{}, immediate.ErrAuditPending
+	}
+	return atom.swapperBinder.AuditSecret(opts, atom.id)
+}
+
 // Audit an Atom swap by calling a function on ethereum
 func (atom *erc20SwapContractBinder) Audit() error {
 	atom.logger.Info(fmt.Sprintf("Waiting for initiation on Ethereum blockchain"))
@@ -245,6 +309,9 @@ func (atom *erc20SwapContractBinder) Audit() error {
 	if auditReport.Value.Cmp(value) < 0 {
 		return fmt.Errorf("Receive value mismatch: expected %v, got %v", atom.swap.Value, auditReport.Value)
 	}
+	if err := atom.auditInitiateTx(); err != nil {
+		return err
+	}
 	atom.logger.Info(fmt.Sprintf("Audit successful on Ethereum blockchain"))
 	return nil
 }
@@ -253,9 +320,13 @@ func (atom *erc20SwapContractBinder) Audit() error {
 func (atom *erc20SwapContractBinder) Redeem(secret [32]byte) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
-	tx, err := atom.account.Transact(
+	key := feebump.Key(atom.details.ContractID, "redeem")
+	tx, err := eth.Transact(
 		ctx,
-		atom.speed,
+		atom.account,
+		atom.tracker,
+		key,
+		atom.swap.Speed,
 		func() bool {
 			redeemable, err := atom.swapperBinder.Redeemable(&bind.CallOpts{}, atom.id)
 			if err != nil {
@@ -286,9 +357,12 @@ func (atom *erc20SwapContractBinder) Redeem(secret [32]byte) error {
 		if err != libeth.ErrPreConditionCheckFailed {
 			return err
 		}
+		atom.tracker.Forget(key)
 		atom.logger.Info("Skipping redeem on Ethereum Blockchain")
+		return nil
 	}
-	atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], tx.Cost())
+	atom.addTxFee(tx)
+	atom.details.RedeemTxHash = tx.Hash
 	return nil
 }
 
@@ -296,9 +370,92 @@ func (atom *erc20SwapContractBinder) Cost() blockchain.Cost {
 	return atom.cost
 }
 
+func (atom *erc20SwapContractBinder) Details() swap.ContractDetails {
+	return atom.details
+}
+
+// addTxFee adds the fee of a confirmed transaction to the cost of the swap.
+func (atom *erc20SwapContractBinder) addTxFee(tx feebump.PendingTx) {
+	if tx.Fee != nil {
+		atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], tx.Fee)
+	}
+}
+
+// auditInitiateTx records the hash of the transaction that initiated the swap
+// and its number of confirmations. It returns ErrAuditPending until the
+// transaction has the required number of confirmations.
+func (atom *erc20SwapContractBinder) auditInitiateTx() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client := atom.account.EthClient()
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return atom.auditInitiateTxFailed(err)
+	}
+	start := big.NewInt(0)
+	if header.Number.Int64() > AuditLookbackBlocks {
+		start = new(big.Int).Sub(header.Number, big.NewInt(AuditLookbackBlocks))
+	}
+
+	logs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: start,
+		Addresses: []common.Address{atom.swapperAddress},
+		Topics:    [][]common.Hash{{logOpenTopic}},
+	})
+	if err != nil {
+		return atom.auditInitiateTxFailed(err)
+	}
+
+	// The initiate transaction has no confirmations until it is found in the
+	// searched blocks.
+	confirmations := int64(0)
+	for _, log := range logs {
+		// The swap id is the first (non-indexed) argument of the event.
+		if len(log.Data) >= 32 && common.BytesToHash(log.Data[:32]) == common.Hash(atom.id) {
+			atom.details.AuditTxHash = log.TxHash.String()
+			confirmations = int64(header.Number.Uint64()-log.BlockNumber) + 1
+			break
+		}
+	}
+	atom.details.AuditConfirmations = confirmations
+	if confirmations < atom.swap.Confirmations {
+		atom.logger.Info(fmt.Sprintf("Waiting for %d confirmations on Ethereum blockchain, got %d", atom.swap.Confirmations, confirmations))
+		if time.Now().Unix() > atom.swap.TimeLock {
+			return immediate.ErrSwapExpired
+		}
+		return immediate.ErrAuditPending
+	}
+	return nil
+}
+
+// auditInitiateTxFailed fails the audit when the initiate transaction is
+// required to have confirmations, otherwise failing to find it is only logged.
+func (atom *erc20SwapContractBinder) auditInitiateTxFailed(err error) error {
+	if atom.swap.Confirmations > 0 {
+		return fmt.Errorf("cannot find the initiate transaction: %v", err)
+	}
+	atom.logger.Warn(fmt.Sprintf("Failed to find the initiate transaction: %v", err))
+	return nil
+}
+
 func (atom *erc20SwapContractBinder) sendValue() *big.Int {
-	if additionalFee := atom.swap.Token.AdditionalTransactionFee(atom.swap.Value); additionalFee != nil {
+	if additionalFee := blockchain.AdditionalTransactionFee(atom.swap.Token, atom.swap.Value); additionalFee != nil {
 		return new(big.Int).Add(atom.swap.Value, additionalFee)
 	}
 	return atom.swap.Value
 }
+
+// spent returns true if the swap contract has been initiated, and then
+// redeemed or refunded.
+func (atom *erc20SwapContractBinder) spent() (bool, error) {
+	initiatable, err := atom.swapperBinder.Initiatable(&bind.CallOpts{}, atom.id)
+	if err != nil {
+		return false, err
+	}
+	redeemable, err := atom.swapperBinder.Redeemable(&bind.CallOpts{}, atom.id)
+	if err != nil {
+		return false, err
+	}
+	return !initiatable && !redeemable, nil
+}
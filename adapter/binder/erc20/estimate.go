@@ -0,0 +1,42 @@
+package erc20
+
+import (
+	"math/big"
+
+	"github.com/renproject/libeth-go"
+	"github.com/renproject/swapperd/adapter/binder/eth"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/tokens"
+)
+
+// Estimated gas used by the transactions that initiate and redeem a swap.
+// Initiating a swap also requires the swap contract to be approved to spend
+// the tokens.
+const (
+	ApproveGasEstimate  = 50000
+	InitiateGasEstimate = 200000
+	RedeemGasEstimate   = 100000
+)
+
+// EstimateCost estimates the cost of initiating, or redeeming, an ERC20 swap
+// on the Ethereum blockchain.
+func EstimateCost(account libeth.Account, swap swap.Swap, initiate bool) (blockchain.Cost, error) {
+	gasPrice, err := eth.EstimateGasPrice(account, swap.Speed)
+	if err != nil {
+		return blockchain.Cost{}, err
+	}
+	if !initiate {
+		return blockchain.Cost{
+			tokens.NameETH: new(big.Int).Mul(gasPrice, big.NewInt(RedeemGasEstimate)),
+		}, nil
+	}
+
+	cost := blockchain.Cost{
+		tokens.NameETH: new(big.Int).Mul(gasPrice, big.NewInt(ApproveGasEstimate+InitiateGasEstimate)),
+	}
+	if additionalFee := blockchain.AdditionalTransactionFee(swap.Token, swap.Value); additionalFee != nil {
+		cost[swap.Token.Name] = additionalFee
+	}
+	return cost, nil
+}
@@ -0,0 +1,59 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/renproject/libeth-go"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/tokens"
+)
+
+// Estimated gas used by the transactions that initiate and redeem a swap.
+const (
+	InitiateGasEstimate = 150000
+	RedeemGasEstimate   = 100000
+)
+
+// GasPriceMultipliers scale the gas price suggested by the Ethereum node, in
+// percent, for each execution speed.
+var GasPriceMultipliers = map[blockchain.TxExecutionSpeed]int64{
+	blockchain.Slow:     75,
+	blockchain.Standard: 100,
+	blockchain.Fast:     150,
+}
+
+// EstimateGasPrice estimates the gas price of a transaction at the given
+// execution speed.
+func EstimateGasPrice(account libeth.Account, speed blockchain.TxExecutionSpeed) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	gasPrice, err := account.EthClient().SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	multiplier, ok := GasPriceMultipliers[speed]
+	if !ok {
+		multiplier = GasPriceMultipliers[blockchain.Fast]
+	}
+	return new(big.Int).Div(new(big.Int).Mul(gasPrice, big.NewInt(multiplier)), big.NewInt(100)), nil
+}
+
+// EstimateCost estimates the cost of initiating, or redeeming, a swap on the
+// Ethereum blockchain.
+func EstimateCost(account libeth.Account, swap swap.Swap, initiate bool) (blockchain.Cost, error) {
+	gasPrice, err := EstimateGasPrice(account, swap.Speed)
+	if err != nil {
+		return blockchain.Cost{}, err
+	}
+	gas := int64(RedeemGasEstimate)
+	if initiate {
+		gas = InitiateGasEstimate
+	}
+	return blockchain.Cost{
+		tokens.NameETH: new(big.Int).Mul(gasPrice, big.NewInt(gas)),
+	}, nil
+}
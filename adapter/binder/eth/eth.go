@@ -3,6 +3,7 @@ package eth
 import (
 	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"math/big"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/renproject/libeth-go"
+	"github.com/renproject/swapperd/adapter/binder/feebump"
 	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
@@ -22,14 +24,19 @@ type ethSwapContractBinder struct {
 	id      [32]byte
 	account libeth.Account
 	swap    swap.Swap
-	speed   libeth.TxExecutionSpeed
 	logger  logrus.FieldLogger
 	binder  *EthSwapContract
 	cost    blockchain.Cost
+	details swap.ContractDetails
+	tracker *feebump.Tracker
 }
 
+// AuditLookbackBlocks is the number of recent blocks that are searched for the
+// transaction that initiated a swap.
+const AuditLookbackBlocks = 20000
+
 // NewETHSwapContractBinder returns a new Ethereum RequestAtom instance
-func NewETHSwapContractBinder(account libeth.Account, swap swap.Swap, cost blockchain.Cost, logger logrus.FieldLogger) (immediate.Contract, error) {
+func NewETHSwapContractBinder(account libeth.Account, swap swap.Swap, cost blockchain.Cost, tracker *feebump.Tracker, logger logrus.FieldLogger) (immediate.Contract, error) {
 	swapperAddr, err := account.ReadAddress("ETHSwap")
 	if err != nil {
 		return nil, err
@@ -58,15 +65,17 @@ func NewETHSwapContractBinder(account libeth.Account, swap swap.Swap, cost block
 	swap.Value = new(big.Int).Add(swap.Value, swap.BrokerFee)
 
 	logger.Info(swap.ID, fmt.Sprintf("Ethereum Atomic Swap ID: %s", base64.StdEncoding.EncodeToString(id[:])))
-	return &ethSwapContractBinder{
+	atom := &ethSwapContractBinder{
 		account: account,
 		binder:  contract,
 		logger:  logger,
 		swap:    swap,
-		speed:   libeth.TxExecutionSpeed(swap.Speed),
 		id:      id,
 		cost:    cost,
-	}, nil
+		tracker: tracker,
+	}
+	atom.details.ContractID = "0x" + hex.EncodeToString(id[:])
+	return atom, nil
 }
 
 // Initiate a new Atom swap by calling a function on ethereum
@@ -77,9 +86,13 @@ func (atom *ethSwapContractBinder) Initiate() error {
 	defer cancel()
 
 	// Initiate the Atomic Swap
-	tx, err := atom.account.Transact(
+	key := feebump.Key(atom.details.ContractID, "initiate")
+	tx, err := Transact(
 		ctx,
-		atom.speed,
+		atom.account,
+		atom.tracker,
+		key,
+		atom.swap.Speed,
 		func() bool {
 			initiatable, err := atom.binder.Initiatable(&bind.CallOpts{}, atom.id)
 			if err != nil {
@@ -96,7 +109,6 @@ func (atom *ethSwapContractBinder) Initiate() error {
 				if err != nil {
 					return tx, err
 				}
-				atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], atom.swap.BrokerFee)
 			} else {
 				tx, err = atom.binder.Initiate(tops, atom.id, common.HexToAddress(atom.swap.SpendingAddress), atom.swap.SecretHash, big.NewInt(atom.swap.TimeLock), atom.swap.Value)
 				if err != nil {
@@ -121,10 +133,14 @@ func (atom *ethSwapContractBinder) Initiate() error {
 		if err != libeth.ErrPreConditionCheckFailed {
 			return err
 		}
+		atom.tracker.Forget(key)
 		return nil
 	}
-	txFee := new(big.Int).Mul(tx.GasPrice(), big.NewInt(int64(tx.Gas())))
-	atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], txFee)
+	atom.addTxFee(tx)
+	if atom.swap.BrokerFee.Cmp(big.NewInt(0)) > 0 {
+		atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], atom.swap.BrokerFee)
+	}
+	atom.details.InitiateTxHash = tx.Hash
 	return nil
 }
 
@@ -135,9 +151,13 @@ func (atom *ethSwapContractBinder) Refund() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	tx, err := atom.account.Transact(
+	key := feebump.Key(atom.details.ContractID, "refund")
+	tx, err := Transact(
 		ctx,
-		atom.speed,
+		atom.account,
+		atom.tracker,
+		key,
+		atom.swap.Speed,
 		func() bool {
 			refundable, err := atom.binder.Refundable(&bind.CallOpts{}, atom.id)
 			if err != nil {
@@ -163,13 +183,27 @@ func (atom *ethSwapContractBinder) Refund() error {
 		},
 		0,
 	)
-	if err != nil && err != libeth.ErrPreConditionCheckFailed {
-		return err
+	if err != nil {
+		if err != libeth.ErrPreConditionCheckFailed {
+			return err
+		}
+		// The contract cannot be refunded yet, or it is not initiated yet,
+		// or it has already been spent. Only the last is final.
+		spent, err := atom.spent()
+		if err != nil {
+			return err
+		}
+		if !spent {
+			return immediate.ErrAuditPending
+		}
+		atom.tracker.Forget(key)
+		atom.logger.Info("Skipping refund on Ethereum blockchain")
+		return nil
 	}
 
-	txFee := new(big.Int).Mul(tx.GasPrice(), big.NewInt(int64(tx.Gas())))
-	atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], txFee)
+	atom.addTxFee(tx)
 	atom.cost[tokens.NameETH] = new(big.Int).Sub(atom.cost[tokens.NameETH], atom.swap.BrokerFee)
+	atom.details.RefundTxHash = tx.Hash
 	return nil
 }
 
@@ -197,6 +231,24 @@ func (atom *ethSwapContractBinder) AuditSecret() ([32]byte, error) {
 	return secret, nil
 }
 
+// PendingSecret returns the secret revealed by a pending transaction that
+// redeems the swap, by calling the contract in the pending state of the
+// Ethereum node.
+func (atom *ethSwapContractBinder) PendingSecret() ([32]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	opts := &bind.CallOpts{Pending: true, Context: ctx}
+	redeemable, err := atom.binder.Redeemable(opts, atom.id)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if redeemable {
+		return [32]byte{}, immediate.ErrAuditPending
+	}
+	return atom.binder.AuditSecret(opts, atom.id)
+}
+
 // Audit an Atom swap by calling a function on ethereum
 func (atom *ethSwapContractBinder) Audit() error {
 	atom.logger.Info(fmt.Sprintf("Waiting for initiation on ethereum blockchain"))
@@ -230,6 +282,9 @@ func (atom *ethSwapContractBinder) Audit() error {
 		atom.logger.Error(fmt.Errorf("Receive Value Mismatch Expected: %v Actual: %v", atom.swap.Value, auditReport.Value))
 		return fmt.Errorf("Receive Value Mismatch Expected: %v Actual: %v", atom.swap.Value, auditReport.Value)
 	}
+	if err := atom.auditInitiateTx(); err != nil {
+		return err
+	}
 	atom.logger.Info(fmt.Sprintf("Audit successful"))
 	return nil
 }
@@ -241,9 +296,13 @@ func (atom *ethSwapContractBinder) Redeem(secret [32]byte) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	tx, err := atom.account.Transact(
+	key := feebump.Key(atom.details.ContractID, "redeem")
+	tx, err := Transact(
 		ctx,
-		atom.speed,
+		atom.account,
+		atom.tracker,
+		key,
+		atom.swap.Speed,
 		func() bool {
 			redeemable, err := atom.binder.Redeemable(&bind.CallOpts{}, atom.id)
 			if err != nil {
@@ -273,14 +332,96 @@ func (atom *ethSwapContractBinder) Redeem(secret [32]byte) error {
 		if err != libeth.ErrPreConditionCheckFailed {
 			return err
 		}
+		atom.tracker.Forget(key)
 		atom.logger.Info("Skipping redeem on Ethereum blockchain")
 		return nil
 	}
-	txFee := new(big.Int).Mul(tx.GasPrice(), big.NewInt(int64(tx.Gas())))
-	atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], txFee)
+	atom.addTxFee(tx)
+	atom.details.RedeemTxHash = tx.Hash
 	return nil
 }
 
 func (atom *ethSwapContractBinder) Cost() blockchain.Cost {
 	return atom.cost
 }
+
+func (atom *ethSwapContractBinder) Details() swap.ContractDetails {
+	return atom.details
+}
+
+// addTxFee adds the fee of a confirmed transaction to the cost of the swap.
+func (atom *ethSwapContractBinder) addTxFee(tx feebump.PendingTx) {
+	if tx.Fee != nil {
+		atom.cost[tokens.NameETH] = new(big.Int).Add(atom.cost[tokens.NameETH], tx.Fee)
+	}
+}
+
+// auditInitiateTx records the hash of the transaction that initiated the swap
+// and its number of confirmations. It returns ErrAuditPending until the
+// transaction has the required number of confirmations.
+func (atom *ethSwapContractBinder) auditInitiateTx() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	header, err := atom.account.EthClient().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return atom.auditInitiateTxFailed(err)
+	}
+	start := uint64(0)
+	if header.Number.Uint64() > AuditLookbackBlocks {
+		start = header.Number.Uint64() - AuditLookbackBlocks
+	}
+
+	iter, err := atom.binder.FilterLogOpen(&bind.FilterOpts{Start: start, Context: ctx})
+	if err != nil {
+		return atom.auditInitiateTxFailed(err)
+	}
+	defer iter.Close()
+
+	// The initiate transaction has no confirmations until it is found in the
+	// searched blocks.
+	confirmations := int64(0)
+	for iter.Next() {
+		if iter.Event.SwapID == atom.id {
+			atom.details.AuditTxHash = iter.Event.Raw.TxHash.String()
+			confirmations = int64(header.Number.Uint64()-iter.Event.Raw.BlockNumber) + 1
+			break
+		}
+	}
+	if iter.Error() != nil {
+		return atom.auditInitiateTxFailed(iter.Error())
+	}
+	atom.details.AuditConfirmations = confirmations
+	if confirmations < atom.swap.Confirmations {
+		atom.logger.Info(fmt.Sprintf("Waiting for %d confirmations on Ethereum blockchain, got %d", atom.swap.Confirmations, confirmations))
+		if time.Now().Unix() > atom.swap.TimeLock {
+			return immediate.ErrSwapExpired
+		}
+		return immediate.ErrAuditPending
+	}
+	return nil
+}
+
+// auditInitiateTxFailed fails the audit when the initiate transaction is
+// required to have confirmations, otherwise failing to find it is only logged.
+func (atom *ethSwapContractBinder) auditInitiateTxFailed(err error) error {
+	if atom.swap.Confirmations > 0 {
+		return fmt.Errorf("cannot find the initiate transaction: %v", err)
+	}
+	atom.logger.Warn(fmt.Sprintf("Failed to find the initiate transaction: %v", err))
+	return nil
+}
+
+// spent returns true if the swap contract has been initiated, and then
+// redeemed or refunded.
+func (atom *ethSwapContractBinder) spent() (bool, error) {
+	initiatable, err := atom.binder.Initiatable(&bind.CallOpts{}, atom.id)
+	if err != nil {
+		return false, err
+	}
+	redeemable, err := atom.binder.Redeemable(&bind.CallOpts{}, atom.id)
+	if err != nil {
+		return false, err
+	}
+	return !initiatable && !redeemable, nil
+}
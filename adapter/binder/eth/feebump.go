@@ -0,0 +1,103 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/renproject/libeth-go"
+	"github.com/renproject/swapperd/adapter/binder/feebump"
+	"github.com/renproject/swapperd/foundation/blockchain"
+)
+
+// BumpWindows are the times that Ethereum transactions are given to confirm
+// before they are replaced by transactions with a higher gas price.
+var BumpWindows = feebump.Windows{
+	blockchain.Slow:     10 * time.Minute,
+	blockchain.Standard: 5 * time.Minute,
+	blockchain.Fast:     3 * time.Minute,
+}
+
+// MinGasPriceBump is the minimum increase of the gas price, in percent, for
+// Ethereum nodes to accept a transaction that replaces a pending transaction.
+const MinGasPriceBump = 10
+
+// ReplacementGasPrice returns the gas price of a transaction that replaces a
+// pending transaction, which is at least MinGasPriceBump percent higher than
+// the gas price of the pending transaction.
+func ReplacementGasPrice(gasPrice, pendingGasPrice *big.Int) *big.Int {
+	minGasPrice := new(big.Int).Mul(pendingGasPrice, big.NewInt(100+MinGasPriceBump))
+	minGasPrice = new(big.Int).Add(minGasPrice, big.NewInt(99))
+	minGasPrice = new(big.Int).Div(minGasPrice, big.NewInt(100))
+	if gasPrice.Cmp(minGasPrice) < 0 {
+		return minGasPrice
+	}
+	return gasPrice
+}
+
+// Transact executes a transaction on a swap contract. If the transaction is
+// not confirmed within the window of its speed, it is replaced by a
+// transaction with the same nonce and a higher gas price, escalating the
+// speed. It returns the transaction that was confirmed.
+func Transact(ctx context.Context, account libeth.Account, tracker *feebump.Tracker, key string, speed blockchain.TxExecutionSpeed, preCondition func() bool, f func(*bind.TransactOpts) (*types.Transaction, error), postCondition func() bool, waitBlocks int64) (feebump.PendingTx, error) {
+	return tracker.Run(
+		ctx,
+		key,
+		speed,
+		BumpWindows,
+		func(ctx context.Context, speed blockchain.TxExecutionSpeed, pending *feebump.PendingTx) error {
+			_, err := account.Transact(
+				ctx,
+				libeth.TxExecutionSpeed(speed),
+				preCondition,
+				func(tops *bind.TransactOpts) (*types.Transaction, error) {
+					if pending != nil && pending.GasPrice != nil {
+						gasPrice, err := EstimateGasPrice(account, speed)
+						if err != nil {
+							return nil, err
+						}
+						tops.Nonce = new(big.Int).SetUint64(pending.Nonce)
+						tops.GasPrice = ReplacementGasPrice(gasPrice, pending.GasPrice)
+					}
+					tx, err := f(tops)
+					if err != nil {
+						return tx, err
+					}
+					tracker.Track(key, feebump.PendingTx{
+						Hash:      tx.Hash().String(),
+						Nonce:     tx.Nonce(),
+						GasPrice:  tx.GasPrice(),
+						Fee:       new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas())),
+						Speed:     speed,
+						Broadcast: time.Now(),
+					})
+					return tx, nil
+				},
+				postCondition,
+				waitBlocks,
+			)
+			return err
+		},
+		func(pending feebump.PendingTx) bool {
+			return nonceConfirmed(account, pending)
+		},
+	)
+}
+
+// nonceConfirmed returns true once a transaction with the nonce of the
+// pending transaction, which is either the pending transaction or one of its
+// replacements, has been confirmed.
+func nonceConfirmed(account libeth.Account, pending feebump.PendingTx) bool {
+	if pending.GasPrice == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	nonce, err := account.EthClient().NonceAt(ctx, account.Address(), nil)
+	if err != nil {
+		return false
+	}
+	return nonce > pending.Nonce
+}
@@ -0,0 +1,214 @@
+// Package feebump tracks the transactions broadcast by the swap contract
+// binders, so that transactions which are not confirmed in time are replaced
+// by transactions that pay higher fees.
+package feebump
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/renproject/swapperd/foundation/blockchain"
+)
+
+// PollInterval is the interval at which a pending transaction is checked while
+// it is waited for.
+var PollInterval = 15 * time.Second
+
+// Windows are the times that transactions broadcast at each execution speed
+// are given to confirm before they are replaced.
+type Windows map[blockchain.TxExecutionSpeed]time.Duration
+
+// Window returns the window of the given speed. Unknown speeds are treated as
+// fast.
+func (windows Windows) Window(speed blockchain.TxExecutionSpeed) time.Duration {
+	if window, ok := windows[speed]; ok {
+		return window
+	}
+	return windows[blockchain.Fast]
+}
+
+// Escalate returns the speed that replaces a transaction broadcast at the
+// given speed. Fast transactions are replaced by fast transactions, which pay
+// the fees of the time they are replaced.
+func Escalate(speed blockchain.TxExecutionSpeed) blockchain.TxExecutionSpeed {
+	switch speed {
+	case blockchain.Slow:
+		return blockchain.Standard
+	default:
+		return blockchain.Fast
+	}
+}
+
+// A PendingTx is a transaction that has been broadcast but is not known to be
+// confirmed. The hash, nonce and fee are only set when they are known before
+// the transaction is confirmed.
+type PendingTx struct {
+	Hash      string
+	Nonce     uint64
+	GasPrice  *big.Int
+	Fee       *big.Int
+	Speed     blockchain.TxExecutionSpeed
+	Broadcast time.Time
+
+	// Inputs are the outputs spent by a transaction on a UTXO blockchain. A
+	// transaction that replaces it must spend them too, but they are no
+	// longer listed as unspent once it is in the mempool.
+	Inputs []Input
+}
+
+// An Input is an output that is spent by a transaction on a UTXO blockchain.
+type Input struct {
+	TxHash string
+	Vout   uint32
+	Value  int64
+}
+
+// ReplacementFee returns the fee of a transaction, of the given size in
+// bytes, that replaces the pending transaction on a UTXO blockchain. Nodes
+// only accept the replacement if it pays the fee of the pending transaction
+// and the relay fee of its own size on top (BIP 125), so that is the minimum
+// of the fee that is returned.
+func ReplacementFee(fee int64, pending *PendingTx, relayFeeRate, size int64) int64 {
+	if pending == nil || pending.Fee == nil {
+		return fee
+	}
+	if minFee := pending.Fee.Int64() + relayFeeRate*size; fee < minFee {
+		return minFee
+	}
+	return fee
+}
+
+// A Tracker holds the pending transactions of the swap contracts. It is shared
+// by all binders, so that a transaction broadcast during one attempt of a swap
+// is replaced during a later attempt.
+type Tracker struct {
+	mu  *sync.Mutex
+	txs map[string]PendingTx
+}
+
+// NewTracker returns a Tracker without any pending transactions.
+func NewTracker() *Tracker {
+	return &Tracker{
+		mu:  new(sync.Mutex),
+		txs: map[string]PendingTx{},
+	}
+}
+
+// Key identifies the transactions of an action, such as a redeem, on a swap
+// contract.
+func Key(contractID, action string) string {
+	return contractID + "/" + action
+}
+
+// Track records a transaction that has been broadcast.
+func (tracker *Tracker) Track(key string, tx PendingTx) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.txs[key] = tx
+}
+
+// Pending returns the pending transaction of the key.
+func (tracker *Tracker) Pending(key string) (PendingTx, bool) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tx, ok := tracker.txs[key]
+	return tx, ok
+}
+
+// Forget removes the pending transaction of the key, once it is confirmed or
+// no longer needed.
+func (tracker *Tracker) Forget(key string) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	delete(tracker.txs, key)
+}
+
+// Next returns the pending transaction of the key, if any, and the speed at
+// which the next transaction should be broadcast. A pending transaction is
+// given the window of its speed to confirm, until then wait is the time left
+// and nothing should be broadcast. Afterwards it is replaced at an escalated
+// speed.
+func (tracker *Tracker) Next(key string, speed blockchain.TxExecutionSpeed, windows Windows, now time.Time) (*PendingTx, blockchain.TxExecutionSpeed, time.Duration) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	pending, ok := tracker.txs[key]
+	if !ok {
+		return nil, speed, 0
+	}
+	if wait := pending.Broadcast.Add(windows.Window(pending.Speed)).Sub(now); wait > 0 {
+		return &pending, pending.Speed, wait
+	}
+	next := Escalate(pending.Speed)
+	if next < speed {
+		next = speed
+	}
+	return &pending, next, 0
+}
+
+// Run broadcasts the transaction of an action and replaces it at escalating
+// speeds, until it is confirmed or the context is done, and returns the
+// confirmed transaction. The send function broadcasts a transaction at the
+// given speed, replacing the pending transaction if it is not nil, tracks it
+// and waits for it to be confirmed until its context is done. A transaction
+// that is still pending from a previous attempt is waited for, using the
+// confirmed function, before it is replaced.
+func (tracker *Tracker) Run(ctx context.Context, key string, speed blockchain.TxExecutionSpeed, windows Windows, send func(ctx context.Context, speed blockchain.TxExecutionSpeed, pending *PendingTx) error, confirmed func(pending PendingTx) bool) (PendingTx, error) {
+	for {
+		pending, next, wait := tracker.Next(key, speed, windows, time.Now())
+		if pending != nil {
+			waitCtx, cancel := context.WithTimeout(ctx, wait)
+			err := WaitForConfirmation(waitCtx, func() bool { return confirmed(*pending) })
+			cancel()
+			if err == nil {
+				tracker.Forget(key)
+				return *pending, nil
+			}
+			if ctx.Err() != nil {
+				return PendingTx{}, fmt.Errorf("transaction %s is pending: %v", key, ctx.Err())
+			}
+			if wait > 0 {
+				continue
+			}
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, windows.Window(next))
+		err := send(sendCtx, next, pending)
+		expired := sendCtx.Err() != nil
+		cancel()
+		if err == nil {
+			tx, ok := tracker.Pending(key)
+			if !ok {
+				tx = PendingTx{Fee: big.NewInt(0), Speed: next, Broadcast: time.Now()}
+			}
+			tracker.Forget(key)
+			return tx, nil
+		}
+
+		// Only transactions that are not confirmed within their window are
+		// replaced, any other error is returned.
+		if !expired || ctx.Err() != nil {
+			return PendingTx{}, err
+		}
+	}
+}
+
+// WaitForConfirmation checks whether a transaction is confirmed every
+// PollInterval, until it is or the context is done.
+func WaitForConfirmation(ctx context.Context, confirmed func() bool) error {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		if confirmed() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
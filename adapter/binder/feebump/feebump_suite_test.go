@@ -0,0 +1,13 @@
+package feebump_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFeebump(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Feebump Suite")
+}
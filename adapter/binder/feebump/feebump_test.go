@@ -0,0 +1,157 @@
+package feebump_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/binder/feebump"
+
+	"github.com/renproject/swapperd/foundation/blockchain"
+)
+
+var _ = Describe("Fee bumping", func() {
+
+	windows := Windows{
+		blockchain.Slow:     30 * time.Millisecond,
+		blockchain.Standard: 20 * time.Millisecond,
+		blockchain.Fast:     10 * time.Millisecond,
+	}
+
+	BeforeEach(func() {
+		PollInterval = time.Millisecond
+	})
+
+	Context("when escalating speeds", func() {
+		It("should escalate slow to standard to fast", func() {
+			Expect(Escalate(blockchain.Slow)).Should(Equal(blockchain.Standard))
+			Expect(Escalate(blockchain.Standard)).Should(Equal(blockchain.Fast))
+			Expect(Escalate(blockchain.Fast)).Should(Equal(blockchain.Fast))
+		})
+	})
+
+	Context("when replacing transactions on a UTXO blockchain", func() {
+		It("should pay at least the fee of the pending transaction and the relay fee", func() {
+			pending := &PendingTx{Hash: "hash", Fee: big.NewInt(4000)}
+			Expect(ReplacementFee(5000, nil, 1, 200)).Should(Equal(int64(5000)))
+			Expect(ReplacementFee(5000, &PendingTx{Hash: "hash"}, 1, 200)).Should(Equal(int64(5000)))
+			Expect(ReplacementFee(4100, pending, 1, 200)).Should(Equal(int64(4200)))
+			Expect(ReplacementFee(4200, pending, 1, 200)).Should(Equal(int64(4200)))
+			Expect(ReplacementFee(8000, pending, 1, 200)).Should(Equal(int64(8000)))
+			Expect(ReplacementFee(4000, pending, 2, 250)).Should(Equal(int64(4500)))
+		})
+	})
+
+	Context("when getting the next transaction", func() {
+		It("should use the given speed when nothing is pending", func() {
+			tracker := NewTracker()
+			pending, speed, wait := tracker.Next("key", blockchain.Slow, windows, time.Now())
+			Expect(pending).Should(BeNil())
+			Expect(speed).Should(Equal(blockchain.Slow))
+			Expect(wait).Should(BeZero())
+		})
+
+		It("should wait for a pending transaction within its window", func() {
+			tracker := NewTracker()
+			now := time.Now()
+			tracker.Track("key", PendingTx{Hash: "hash", Speed: blockchain.Slow, Broadcast: now})
+			pending, speed, wait := tracker.Next("key", blockchain.Slow, windows, now.Add(10*time.Millisecond))
+			Expect(pending.Hash).Should(Equal("hash"))
+			Expect(speed).Should(Equal(blockchain.Slow))
+			Expect(wait).Should(Equal(20 * time.Millisecond))
+		})
+
+		It("should escalate a pending transaction after its window", func() {
+			tracker := NewTracker()
+			now := time.Now()
+			tracker.Track("key", PendingTx{Hash: "hash", Speed: blockchain.Slow, Broadcast: now})
+			pending, speed, wait := tracker.Next("key", blockchain.Slow, windows, now.Add(30*time.Millisecond))
+			Expect(pending.Hash).Should(Equal("hash"))
+			Expect(speed).Should(Equal(blockchain.Standard))
+			Expect(wait).Should(BeZero())
+		})
+
+		It("should forget confirmed transactions", func() {
+			tracker := NewTracker()
+			tracker.Track("key", PendingTx{Speed: blockchain.Slow, Broadcast: time.Now()})
+			tracker.Forget("key")
+			_, ok := tracker.Pending("key")
+			Expect(ok).Should(BeFalse())
+		})
+	})
+
+	Context("when running a transaction", func() {
+		It("should replace transactions at escalating speeds until one is confirmed", func() {
+			tracker := NewTracker()
+			speeds := []blockchain.TxExecutionSpeed{}
+			tx, err := tracker.Run(context.Background(), "key", blockchain.Slow, windows,
+				func(ctx context.Context, speed blockchain.TxExecutionSpeed, pending *PendingTx) error {
+					if len(speeds) > 0 {
+						Expect(pending).ShouldNot(BeNil())
+						Expect(pending.Speed).Should(Equal(speeds[len(speeds)-1]))
+					}
+					speeds = append(speeds, speed)
+					tracker.Track("key", PendingTx{Hash: strconv.Itoa(len(speeds)), Fee: big.NewInt(int64(speed)), Speed: speed, Broadcast: time.Now()})
+					if len(speeds) < 4 {
+						<-ctx.Done()
+						return ctx.Err()
+					}
+					return nil
+				},
+				func(PendingTx) bool { return false },
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(speeds).Should(Equal([]blockchain.TxExecutionSpeed{blockchain.Slow, blockchain.Standard, blockchain.Fast, blockchain.Fast}))
+			Expect(tx.Hash).Should(Equal("4"))
+			_, ok := tracker.Pending("key")
+			Expect(ok).Should(BeFalse())
+		})
+
+		It("should return a pending transaction once it is confirmed", func() {
+			tracker := NewTracker()
+			tracker.Track("key", PendingTx{Hash: "hash", Speed: blockchain.Slow, Broadcast: time.Now()})
+			tx, err := tracker.Run(context.Background(), "key", blockchain.Slow, windows,
+				func(ctx context.Context, speed blockchain.TxExecutionSpeed, pending *PendingTx) error {
+					Fail("pending transaction should not be replaced")
+					return nil
+				},
+				func(pending PendingTx) bool { return pending.Hash == "hash" },
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tx.Hash).Should(Equal("hash"))
+		})
+
+		It("should return errors that are not caused by the window expiring", func() {
+			tracker := NewTracker()
+			_, err := tracker.Run(context.Background(), "key", blockchain.Fast, windows,
+				func(ctx context.Context, speed blockchain.TxExecutionSpeed, pending *PendingTx) error {
+					return errors.New("precondition failed")
+				},
+				func(PendingTx) bool { return false },
+			)
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("should keep pending transactions when the context is done", func() {
+			tracker := NewTracker()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+			defer cancel()
+			_, err := tracker.Run(ctx, "key", blockchain.Slow, windows,
+				func(ctx context.Context, speed blockchain.TxExecutionSpeed, pending *PendingTx) error {
+					tracker.Track("key", PendingTx{Hash: "hash", Speed: speed, Broadcast: time.Now()})
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				func(PendingTx) bool { return false },
+			)
+			Expect(err).Should(HaveOccurred())
+			pending, ok := tracker.Pending("key")
+			Expect(ok).Should(BeTrue())
+			Expect(pending.Hash).Should(Equal("hash"))
+		})
+	})
+})
@@ -0,0 +1,182 @@
+package utxo
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/renproject/swapperd/foundation/blockchain"
+)
+
+// SigHashForkID is the flag of the signature hash types of chains that sign
+// with SIGHASH_FORKID.
+const SigHashForkID = txscript.SigHashType(0x40)
+
+// Estimated sizes, in bytes, of the parts of transactions that spend P2PKH
+// outputs.
+const (
+	TxOverheadSize  = 10
+	P2PKHInputSize  = 148
+	P2PKHOutputSize = 34
+)
+
+// An Account holds the funds of a key on a UTXO chain.
+type Account struct {
+	Chain   Chain
+	Network Network
+	Backend
+	key *ecdsa.PrivateKey
+}
+
+// NewAccount returns the Account of the key on the named network of the
+// chain, that uses the backend to read and write to the network.
+func NewAccount(chain Chain, network string, backend Backend, key *ecdsa.PrivateKey) (*Account, error) {
+	net, err := chain.Network(network)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		Chain:   chain,
+		Network: net,
+		Backend: backend,
+		key:     key,
+	}, nil
+}
+
+// Address returns the P2PKH address of the account.
+func (account *Account) Address() (btcutil.Address, error) {
+	return btcutil.NewAddressPubKeyHash(btcutil.Hash160(account.publicKey()), account.Network.Params)
+}
+
+// EncodedAddress returns the address of the account, encoded for the network.
+func (account *Account) EncodedAddress() (string, error) {
+	addr, err := account.Address()
+	if err != nil {
+		return "", err
+	}
+	return account.Network.EncodeAddress(addr)
+}
+
+// Transfer sends the amount to the address, or the whole balance if sendAll
+// is true, and returns the hash and fee of the transaction.
+func (account *Account) Transfer(ctx context.Context, to string, amount int64, speed blockchain.TxExecutionSpeed, sendAll bool) (string, int64, error) {
+	addr, err := account.Network.DecodeAddress(to)
+	if err != nil {
+		return "", 0, err
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	return account.Send(ctx, pkScript, amount, speed, sendAll)
+}
+
+// Send sends the amount to the public key script, or the whole balance if
+// sendAll is true, and returns the hash and fee of the transaction.
+func (account *Account) Send(ctx context.Context, pkScript []byte, amount int64, speed blockchain.TxExecutionSpeed, sendAll bool) (string, int64, error) {
+	from, err := account.Address()
+	if err != nil {
+		return "", 0, err
+	}
+	fromScript, err := txscript.PayToAddrScript(from)
+	if err != nil {
+		return "", 0, err
+	}
+	address, err := account.Network.EncodeAddress(from)
+	if err != nil {
+		return "", 0, err
+	}
+	utxos, err := account.UTXOs(ctx, address)
+	if err != nil {
+		return "", 0, err
+	}
+
+	tx := wire.NewMsgTx(2)
+	balance := int64(0)
+	for _, utxo := range utxos {
+		hash, err := chainhash.NewHashFromStr(utxo.TxHash)
+		if err != nil {
+			return "", 0, err
+		}
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, utxo.Vout), nil, nil))
+		balance += utxo.Value
+	}
+
+	fee := account.Chain.FeeRate(speed) * int64(TxOverheadSize+len(tx.TxIn)*P2PKHInputSize+2*P2PKHOutputSize)
+	if sendAll {
+		amount = balance - fee
+	}
+	if amount < account.Chain.Dust {
+		return "", 0, fmt.Errorf("cannot send %d, which is less than the dust limit of %d", amount, account.Chain.Dust)
+	}
+	if balance < amount+fee {
+		return "", 0, fmt.Errorf("insufficient balance: need %d, have %d", amount+fee, balance)
+	}
+	tx.AddTxOut(wire.NewTxOut(amount, pkScript))
+	if change := balance - amount - fee; change >= account.Chain.Dust {
+		tx.AddTxOut(wire.NewTxOut(change, fromScript))
+	} else {
+		fee += change
+	}
+
+	for i, utxo := range utxos {
+		sig, err := account.Sign(tx, i, fromScript, utxo.Value, false)
+		if err != nil {
+			return "", 0, err
+		}
+		builder := txscript.NewScriptBuilder()
+		builder.AddData(sig)
+		builder.AddData(account.publicKey())
+		if tx.TxIn[i].SignatureScript, err = builder.Script(); err != nil {
+			return "", 0, err
+		}
+	}
+	txHash, err := account.PublishTx(ctx, tx)
+	if err != nil {
+		return "", 0, err
+	}
+	return txHash, fee, nil
+}
+
+// Sign signs the input of the transaction that spends the sub script, and
+// returns the signature with its hash type.
+func (account *Account) Sign(tx *wire.MsgTx, idx int, subScript []byte, amount int64, witness bool) ([]byte, error) {
+	key := (*btcec.PrivateKey)(account.key)
+	switch {
+	case account.Chain.ForkID:
+		hashType := txscript.SigHashAll | SigHashForkID
+		hash, err := txscript.CalcWitnessSigHash(subScript, txscript.NewTxSigHashes(tx), hashType, tx, idx, amount)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := key.Sign(hash)
+		if err != nil {
+			return nil, err
+		}
+		return append(sig.Serialize(), byte(hashType)), nil
+	case witness:
+		return txscript.RawTxInWitnessSignature(tx, txscript.NewTxSigHashes(tx), idx, amount, subScript, txscript.SigHashAll, key)
+	default:
+		return txscript.RawTxInSignature(tx, idx, subScript, txscript.SigHashAll, key)
+	}
+}
+
+// PublishTx publishes the signed transaction, and returns its hash.
+func (account *Account) PublishTx(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := tx.Serialize(buf); err != nil {
+		return "", err
+	}
+	return account.Publish(ctx, hex.EncodeToString(buf.Bytes()))
+}
+
+func (account *Account) publicKey() []byte {
+	return (*btcec.PublicKey)(&account.key.PublicKey).SerializeCompressed()
+}
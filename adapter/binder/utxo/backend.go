@@ -0,0 +1,130 @@
+package utxo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// A Backend reads the state of a UTXO blockchain, and publishes transactions
+// to it. Addresses are encoded for the network of the backend.
+type Backend interface {
+	// Balance returns the confirmed and unconfirmed balance of the address.
+	Balance(ctx context.Context, address string) (int64, error)
+
+	// ScriptFunded returns true if the script address has been sent at least
+	// the given value, and the value it has been sent.
+	ScriptFunded(ctx context.Context, address string, value int64) (bool, int64, error)
+
+	// ScriptRedeemed returns true if the script address has been funded with
+	// at least the given value and spent, and the value it still holds.
+	ScriptRedeemed(ctx context.Context, address string, value int64) (bool, int64, error)
+
+	// ScriptSpent returns true if the script address has been spent, and the
+	// data pushed by the signature script, or the witness, of the input that
+	// spent it.
+	ScriptSpent(ctx context.Context, address string) (bool, [][]byte, error)
+
+	// UTXOs returns the unspent outputs of the address.
+	UTXOs(ctx context.Context, address string) ([]UTXO, error)
+
+	// Confirmations returns the number of confirmations of the transaction.
+	Confirmations(ctx context.Context, txHash string) (int64, error)
+
+	// Publish broadcasts the hex encoded transaction, and returns its hash.
+	Publish(ctx context.Context, tx string) (string, error)
+}
+
+// FundingConfirmations returns the number of confirmations of the funding of
+// the value by the unspent outputs, which is the number of confirmations of
+// the least confirmed output that is needed to reach the value, and the hash
+// of its transaction. The most confirmed outputs are counted first. It returns
+// no confirmations if the outputs do not reach the value.
+func FundingConfirmations(ctx context.Context, confirmations func(ctx context.Context, txHash string) (int64, error), utxos []UTXO, value int64) (int64, string, error) {
+	txConfirmations := map[string]int64{}
+	for _, utxo := range utxos {
+		if _, ok := txConfirmations[utxo.TxHash]; ok {
+			continue
+		}
+		txConfs, err := confirmations(ctx, utxo.TxHash)
+		if err != nil {
+			return 0, utxo.TxHash, err
+		}
+		txConfirmations[utxo.TxHash] = txConfs
+	}
+
+	sorted := append([]UTXO{}, utxos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return txConfirmations[sorted[i].TxHash] > txConfirmations[sorted[j].TxHash]
+	})
+	funded := int64(0)
+	for _, utxo := range sorted {
+		funded += utxo.Value
+		if funded >= value {
+			return txConfirmations[utxo.TxHash], utxo.TxHash, nil
+		}
+	}
+	return 0, "", nil
+}
+
+// pkScriptOf returns the public key script of the address on the network.
+func pkScriptOf(network Network, address string) ([]byte, error) {
+	addr, err := network.DecodeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+// spendingPushes returns the data pushed by the input of the transaction that
+// spends the P2SH or P2WSH public key script, which is the input that reveals
+// the script as its last push.
+func spendingPushes(tx *wire.MsgTx, pkScript []byte) ([][]byte, bool) {
+	for _, txIn := range tx.TxIn {
+		pushes := [][]byte(txIn.Witness)
+		witness := len(pushes) > 0
+		if !witness {
+			var err error
+			if pushes, err = txscript.PushedData(txIn.SignatureScript); err != nil {
+				continue
+			}
+		}
+		if len(pushes) == 0 {
+			continue
+		}
+		builder := txscript.NewScriptBuilder()
+		if script := pushes[len(pushes)-1]; witness {
+			scriptHash := sha256.Sum256(script)
+			builder.AddOp(txscript.OP_0).AddData(scriptHash[:])
+		} else {
+			builder.AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(script)).AddOp(txscript.OP_EQUAL)
+		}
+		scriptPkScript, err := builder.Script()
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(scriptPkScript, pkScript) {
+			return pushes, true
+		}
+	}
+	return nil, false
+}
+
+// decodeTx decodes a hex encoded transaction.
+func decodeTx(txHex string) (*wire.MsgTx, error) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,59 @@
+package utxo_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/binder/utxo"
+)
+
+var _ = Describe("Backends", func() {
+	Context("when counting the confirmations of the funding of a script address", func() {
+		txConfirmations := map[string]int64{"a": 6, "b": 0, "c": 2}
+		confirmations := func(ctx context.Context, txHash string) (int64, error) {
+			confs, ok := txConfirmations[txHash]
+			if !ok {
+				return 0, fmt.Errorf("unknown transaction %s", txHash)
+			}
+			return confs, nil
+		}
+		utxos := []UTXO{
+			{TxHash: "b", Vout: 0, Value: 5000},
+			{TxHash: "a", Vout: 0, Value: 3000},
+			{TxHash: "c", Vout: 1, Value: 4000},
+		}
+
+		It("should count the most confirmed outputs first", func() {
+			confs, txHash, err := FundingConfirmations(context.Background(), confirmations, utxos, 3000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(confs).Should(Equal(int64(6)))
+			Expect(txHash).Should(Equal("a"))
+
+			confs, txHash, err = FundingConfirmations(context.Background(), confirmations, utxos, 7000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(confs).Should(Equal(int64(2)))
+			Expect(txHash).Should(Equal("c"))
+		})
+
+		It("should not be confirmed until every output that is needed is confirmed", func() {
+			confs, txHash, err := FundingConfirmations(context.Background(), confirmations, utxos, 7001)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(confs).Should(BeZero())
+			Expect(txHash).Should(Equal("b"))
+		})
+
+		It("should not be confirmed if the outputs do not reach the value", func() {
+			confs, _, err := FundingConfirmations(context.Background(), confirmations, utxos, 12001)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(confs).Should(BeZero())
+		})
+
+		It("should return the transaction whose confirmations are unknown", func() {
+			_, txHash, err := FundingConfirmations(context.Background(), confirmations, append(utxos, UTXO{TxHash: "d", Value: 1}), 1)
+			Expect(err).Should(HaveOccurred())
+			Expect(txHash).Should(Equal("d"))
+		})
+	})
+})
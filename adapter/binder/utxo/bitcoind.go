@@ -0,0 +1,319 @@
+package utxo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// RescanWindow is how far back the node rescans the blockchain when it starts
+// watching an address, so that addresses funded before they are watched are
+// found.
+var RescanWindow = 7 * 24 * time.Hour
+
+// A BitcoindClient is a Backend that uses the JSON-RPC API of a Bitcoin Core
+// node, or a node of a fork with the same API. The node must have a wallet,
+// into which the addresses are imported as watch-only addresses.
+type BitcoindClient struct {
+	url      string
+	username string
+	password string
+	network  Network
+
+	mu       *sync.Mutex
+	imported map[string]bool
+}
+
+type bitcoindRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      string        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type bitcoindResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type bitcoindUnspent struct {
+	TxHash string  `json:"txid"`
+	Vout   uint32  `json:"vout"`
+	Amount float64 `json:"amount"`
+}
+
+type bitcoindTransaction struct {
+	TxHash        string `json:"txid"`
+	Confirmations int64  `json:"confirmations"`
+	BlockHash     string `json:"blockhash"`
+	Hex           string `json:"hex"`
+}
+
+// NewBitcoindClient returns a BitcoindClient of the node at the url, that is
+// on the network.
+func NewBitcoindClient(url, username, password string, network Network) *BitcoindClient {
+	return &BitcoindClient{
+		url:      url,
+		username: username,
+		password: password,
+		network:  network,
+		mu:       new(sync.Mutex),
+		imported: map[string]bool{},
+	}
+}
+
+func (client *BitcoindClient) Balance(ctx context.Context, address string) (int64, error) {
+	utxos, err := client.UTXOs(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	balance := int64(0)
+	for _, utxo := range utxos {
+		balance += utxo.Value
+	}
+	return balance, nil
+}
+
+func (client *BitcoindClient) ScriptFunded(ctx context.Context, address string, value int64) (bool, int64, error) {
+	funded, err := client.received(ctx, address)
+	if err != nil {
+		return false, 0, err
+	}
+	return funded >= value && funded > 0, funded, nil
+}
+
+func (client *BitcoindClient) ScriptRedeemed(ctx context.Context, address string, value int64) (bool, int64, error) {
+	funded, err := client.received(ctx, address)
+	if err != nil {
+		return false, 0, err
+	}
+	unspent, err := client.Balance(ctx, address)
+	if err != nil {
+		return false, 0, err
+	}
+	return funded > 0 && funded >= value && unspent == 0, unspent, nil
+}
+
+// ScriptSpent finds the transaction that spent an output that funded the
+// script address. The outputs are those of the transactions received by the
+// address, and the spend of each is looked up by its outpoint.
+func (client *BitcoindClient) ScriptSpent(ctx context.Context, address string) (bool, [][]byte, error) {
+	pkScript, err := pkScriptOf(client.network, address)
+	if err != nil {
+		return false, nil, err
+	}
+	if err := client.watch(ctx, address); err != nil {
+		return false, nil, err
+	}
+	received := []struct {
+		TxHashes []string `json:"txids"`
+	}{}
+	if err := client.call(ctx, "listreceivedbyaddress", &received, 0, true, true, address); err != nil {
+		return false, nil, err
+	}
+	for _, receipt := range received {
+		for _, txHash := range receipt.TxHashes {
+			walletTx := bitcoindTransaction{}
+			if err := client.call(ctx, "gettransaction", &walletTx, txHash, true); err != nil {
+				return false, nil, err
+			}
+			tx, err := decodeTx(walletTx.Hex)
+			if err != nil {
+				return false, nil, err
+			}
+			for i, txOut := range tx.TxOut {
+				if !bytes.Equal(txOut.PkScript, pkScript) {
+					continue
+				}
+				spendingTx, err := client.spendingTx(ctx, wire.OutPoint{Hash: tx.TxHash(), Index: uint32(i)}, walletTx.BlockHash)
+				if err != nil {
+					return false, nil, err
+				}
+				if spendingTx == nil {
+					continue
+				}
+				if pushes, ok := spendingPushes(spendingTx, pkScript); ok {
+					return true, pushes, nil
+				}
+			}
+		}
+	}
+	return false, nil, nil
+}
+
+func (client *BitcoindClient) UTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	if err := client.watch(ctx, address); err != nil {
+		return nil, err
+	}
+	unspent := []bitcoindUnspent{}
+	if err := client.call(ctx, "listunspent", &unspent, 0, 9999999, []string{address}); err != nil {
+		return nil, err
+	}
+	utxos := make([]UTXO, 0, len(unspent))
+	for _, output := range unspent {
+		value, err := btcutil.NewAmount(output.Amount)
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, UTXO{TxHash: output.TxHash, Vout: output.Vout, Value: int64(value)})
+	}
+	return utxos, nil
+}
+
+func (client *BitcoindClient) Confirmations(ctx context.Context, txHash string) (int64, error) {
+	tx := bitcoindTransaction{}
+	if err := client.call(ctx, "gettransaction", &tx, txHash, true); err != nil {
+		// Transactions that are not in the wallet can only be found if the
+		// node indexes transactions.
+		if err := client.call(ctx, "getrawtransaction", &tx, txHash, true); err != nil {
+			return 0, err
+		}
+	}
+	if tx.Confirmations < 0 {
+		return 0, nil
+	}
+	return tx.Confirmations, nil
+}
+
+func (client *BitcoindClient) Publish(ctx context.Context, tx string) (string, error) {
+	txHash := ""
+	err := client.call(ctx, "sendrawtransaction", &txHash, tx)
+	return txHash, err
+}
+
+// received returns the total value that has been sent to the address.
+func (client *BitcoindClient) received(ctx context.Context, address string) (int64, error) {
+	if err := client.watch(ctx, address); err != nil {
+		return 0, err
+	}
+	received := float64(0)
+	if err := client.call(ctx, "getreceivedbyaddress", &received, address, 0); err != nil {
+		return 0, err
+	}
+	value, err := btcutil.NewAmount(received)
+	return int64(value), err
+}
+
+func (client *BitcoindClient) transaction(ctx context.Context, txHash string) (*wire.MsgTx, error) {
+	walletTx := bitcoindTransaction{}
+	if err := client.call(ctx, "gettransaction", &walletTx, txHash, true); err != nil {
+		return nil, err
+	}
+	return decodeTx(walletTx.Hex)
+}
+
+// spendingTx returns the transaction that spends the outpoint, or nil if it is
+// unspent. The spend is in the wallet of the node, because it spends an output
+// of a watched address, and it is in the block of the outpoint or a later one.
+func (client *BitcoindClient) spendingTx(ctx context.Context, outpoint wire.OutPoint, blockHash string) (*wire.MsgTx, error) {
+	var txOut *struct {
+		Value float64 `json:"value"`
+	}
+	if err := client.call(ctx, "gettxout", &txOut, outpoint.Hash.String(), outpoint.Index, true); err != nil {
+		return nil, err
+	}
+	if txOut != nil {
+		return nil, nil
+	}
+	// Every transaction of the wallet is listed since an unconfirmed outpoint.
+	since := struct {
+		Transactions []bitcoindTransaction `json:"transactions"`
+	}{}
+	if err := client.call(ctx, "listsinceblock", &since, blockHash, 1, true); err != nil {
+		return nil, err
+	}
+	checked := map[string]bool{}
+	for _, walletTx := range since.Transactions {
+		if checked[walletTx.TxHash] {
+			continue
+		}
+		checked[walletTx.TxHash] = true
+
+		tx, err := client.transaction(ctx, walletTx.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		for _, txIn := range tx.TxIn {
+			if txIn.PreviousOutPoint == outpoint {
+				return tx, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// watch imports the address into the wallet of the node, and rescans the
+// blocks of the RescanWindow for its transactions.
+func (client *BitcoindClient) watch(ctx context.Context, address string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.imported[address] {
+		return nil
+	}
+	request := map[string]interface{}{
+		"scriptPubKey": map[string]string{"address": address},
+		"timestamp":    time.Now().Add(-RescanWindow).Unix(),
+		"watchonly":    true,
+		"label":        "swapperd",
+	}
+	results := []struct {
+		Success bool `json:"success"`
+		Error   *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	if err := client.call(ctx, "importmulti", &results, []interface{}{request}, map[string]bool{"rescan": true}); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if !result.Success && result.Error != nil {
+			return fmt.Errorf("cannot watch %s: %s", address, result.Error.Message)
+		}
+	}
+	client.imported[address] = true
+	return nil
+}
+
+func (client *BitcoindClient) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	data, err := json.Marshal(bitcoindRequest{
+		JSONRPC: "1.0",
+		ID:      "swapperd",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", client.url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client.username != "" || client.password != "" {
+		req.SetBasicAuth(client.username, client.password)
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	response := bitcoindResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("unexpected response to %s with status code %d: %v", method, resp.StatusCode, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s failed with code %d: %s", method, response.Error.Code, response.Error.Message)
+	}
+	return json.Unmarshal(response.Result, result)
+}
@@ -0,0 +1,211 @@
+package utxo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/renproject/swapperd/foundation/swap"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/binder/utxo"
+)
+
+// standInNode is a Bitcoin Core node whose wallet holds the transactions it
+// is given. Transactions are in the block of their block hash, or in the
+// mempool if they do not have one.
+type standInNode struct {
+	server      *httptest.Server
+	mu          *sync.Mutex
+	txs         []*wire.MsgTx
+	blockHashes map[string]string
+	methods     []string
+	sinceBlocks []string
+}
+
+func newStandInNode() *standInNode {
+	node := &standInNode{
+		mu:          new(sync.Mutex),
+		blockHashes: map[string]string{},
+	}
+	node.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		response := map[string]interface{}{"id": "swapperd", "error": nil}
+		if result, err := node.handle(request.Method, request.Params); err != nil {
+			response["error"] = map[string]interface{}{"code": -1, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	return node
+}
+
+func (node *standInNode) add(tx *wire.MsgTx, blockHash string) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.txs = append(node.txs, tx)
+	node.blockHashes[tx.TxHash().String()] = blockHash
+}
+
+func (node *standInNode) handle(method string, params []json.RawMessage) (interface{}, error) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.methods = append(node.methods, method)
+
+	param := func(i int) string {
+		value := ""
+		if i < len(params) {
+			json.Unmarshal(params[i], &value)
+		}
+		return value
+	}
+	switch method {
+	case "importmulti":
+		return []map[string]bool{{"success": true}}, nil
+	case "listreceivedbyaddress":
+		// Only the first transaction funds the address.
+		return []map[string]interface{}{{"txids": []string{node.txs[0].TxHash().String()}}}, nil
+	case "gettransaction":
+		for _, tx := range node.txs {
+			if tx.TxHash().String() != param(0) {
+				continue
+			}
+			buf := new(bytes.Buffer)
+			if err := tx.Serialize(buf); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"txid": param(0), "blockhash": node.blockHashes[param(0)], "hex": hex.EncodeToString(buf.Bytes())}, nil
+		}
+		return nil, fmt.Errorf("invalid or non-wallet transaction id")
+	case "gettxout":
+		index := uint32(0)
+		json.Unmarshal(params[1], &index)
+		for _, tx := range node.txs {
+			for _, txIn := range tx.TxIn {
+				if txIn.PreviousOutPoint.Hash.String() == param(0) && txIn.PreviousOutPoint.Index == index {
+					return nil, nil
+				}
+			}
+		}
+		return map[string]float64{"value": 0.001}, nil
+	case "listsinceblock":
+		node.sinceBlocks = append(node.sinceBlocks, param(0))
+		transactions := []map[string]string{}
+		for _, tx := range node.txs {
+			// A transaction is listed once for each of its categories.
+			transactions = append(transactions, map[string]string{"txid": tx.TxHash().String(), "category": "receive"})
+			transactions = append(transactions, map[string]string{"txid": tx.TxHash().String(), "category": "send"})
+		}
+		return map[string]interface{}{"transactions": transactions}, nil
+	default:
+		return nil, fmt.Errorf("method %s not found", method)
+	}
+}
+
+func (node *standInNode) Methods() []string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.methods
+}
+
+var _ = Describe("Bitcoind backend", func() {
+	network, err := Bitcoin.Network("testnet")
+	if err != nil {
+		panic(err)
+	}
+	script := []byte{txscript.OP_DROP, txscript.OP_TRUE}
+	addr, err := ScriptAddress(script, swap.ScriptTypeP2WSH, network.Params)
+	if err != nil {
+		panic(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		panic(err)
+	}
+	secret := bytes.Repeat([]byte{0x01}, 32)
+
+	// fundingTx pays the script address, after an output to another script.
+	fundingTx := func() *wire.MsgTx {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil))
+		tx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+		tx.AddTxOut(wire.NewTxOut(100000, pkScript))
+		return tx
+	}
+
+	// spendingTx spends the output of the funding transaction.
+	spendingTx := func(funding *wire.MsgTx, index uint32, data []byte) *wire.MsgTx {
+		hash := funding.TxHash()
+		tx := wire.NewMsgTx(2)
+		txIn := wire.NewTxIn(wire.NewOutPoint(&hash, index), nil, nil)
+		txIn.Witness = wire.TxWitness{data, script}
+		tx.AddTxIn(txIn)
+		tx.AddTxOut(wire.NewTxOut(99000, []byte{txscript.OP_TRUE}))
+		return tx
+	}
+
+	Context("when the script address has been spent", func() {
+		It("should find the spend of the funding outpoint", func() {
+			node := newStandInNode()
+			defer node.server.Close()
+			funding := fundingTx()
+			node.add(funding, "0000000000000000000000000000000000000000000000000000000000000001")
+			// Another output of the funding transaction is spent first.
+			node.add(spendingTx(funding, 0, bytes.Repeat([]byte{0x02}, 32)), "")
+			node.add(spendingTx(funding, 1, secret), "")
+
+			client := NewBitcoindClient(node.server.URL, "", "", network)
+			spent, pushes, err := client.ScriptSpent(context.Background(), addr.EncodeAddress())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(spent).Should(BeTrue())
+			Expect(pushes).Should(Equal([][]byte{secret, script}))
+			Expect(node.Methods()).ShouldNot(ContainElement("listtransactions"))
+			Expect(node.sinceBlocks).Should(Equal([]string{"0000000000000000000000000000000000000000000000000000000000000001"}))
+		})
+
+		It("should find the spend of an unconfirmed funding outpoint", func() {
+			node := newStandInNode()
+			defer node.server.Close()
+			funding := fundingTx()
+			node.add(funding, "")
+			node.add(spendingTx(funding, 1, secret), "")
+
+			client := NewBitcoindClient(node.server.URL, "", "", network)
+			spent, pushes, err := client.ScriptSpent(context.Background(), addr.EncodeAddress())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(spent).Should(BeTrue())
+			Expect(pushes).Should(Equal([][]byte{secret, script}))
+		})
+	})
+
+	Context("when the script address has not been spent", func() {
+		It("should not look for a spend", func() {
+			node := newStandInNode()
+			defer node.server.Close()
+			node.add(fundingTx(), "0000000000000000000000000000000000000000000000000000000000000001")
+
+			client := NewBitcoindClient(node.server.URL, "", "", network)
+			spent, _, err := client.ScriptSpent(context.Background(), addr.EncodeAddress())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(spent).Should(BeFalse())
+			Expect(node.Methods()).ShouldNot(ContainElement("listsinceblock"))
+		})
+	})
+})
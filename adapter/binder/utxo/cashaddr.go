@@ -0,0 +1,121 @@
+package utxo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+const cashAddrCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// The types of cashaddr addresses.
+const (
+	cashAddrP2PKH = byte(0)
+	cashAddrP2SH  = byte(1)
+)
+
+// EncodeCashAddress encodes a P2PKH or P2SH address in the cashaddr format,
+// with the given prefix.
+func EncodeCashAddress(addr btcutil.Address, prefix string) (string, error) {
+	var addrType byte
+	switch addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+		addrType = cashAddrP2PKH
+	case *btcutil.AddressScriptHash:
+		addrType = cashAddrP2SH
+	default:
+		return "", fmt.Errorf("cannot encode %s as a cashaddr address", addr.EncodeAddress())
+	}
+	// The version byte holds the type, and a size of 160 bits.
+	payload, err := bech32.ConvertBits(append([]byte{addrType << 3}, addr.ScriptAddress()...), 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	checksum := cashAddrPolymod(append(append(cashAddrPrefix(prefix), payload...), make([]byte, 8)...))
+	for i := 0; i < 8; i++ {
+		payload = append(payload, byte((checksum>>uint(5*(7-i)))&0x1f))
+	}
+
+	encoded := make([]byte, len(payload))
+	for i, b := range payload {
+		encoded[i] = cashAddrCharset[b]
+	}
+	return prefix + ":" + string(encoded), nil
+}
+
+// DecodeCashAddress decodes a cashaddr address, with or without its prefix.
+func DecodeCashAddress(address, prefix string, params *chaincfg.Params) (btcutil.Address, error) {
+	address = strings.ToLower(address)
+	if i := strings.LastIndex(address, ":"); i >= 0 {
+		if address[:i] != prefix {
+			return nil, fmt.Errorf("invalid cashaddr prefix: %s", address[:i])
+		}
+		address = address[i+1:]
+	}
+	if len(address) <= 8 {
+		return nil, fmt.Errorf("invalid cashaddr address: %s", address)
+	}
+
+	data := make([]byte, len(address))
+	for i := range address {
+		b := strings.IndexByte(cashAddrCharset, address[i])
+		if b < 0 {
+			return nil, fmt.Errorf("invalid cashaddr character: %c", address[i])
+		}
+		data[i] = byte(b)
+	}
+	if cashAddrPolymod(append(cashAddrPrefix(prefix), data...)) != 0 {
+		return nil, fmt.Errorf("invalid cashaddr checksum: %s", address)
+	}
+
+	payload, err := bech32.ConvertBits(data[:len(data)-8], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != 21 || payload[0]&0x07 != 0 {
+		return nil, fmt.Errorf("unsupported cashaddr address: %s", address)
+	}
+	switch payload[0] >> 3 {
+	case cashAddrP2PKH:
+		return btcutil.NewAddressPubKeyHash(payload[1:], params)
+	case cashAddrP2SH:
+		return btcutil.NewAddressScriptHashFromHash(payload[1:], params)
+	default:
+		return nil, fmt.Errorf("unsupported cashaddr type: %d", payload[0]>>3)
+	}
+}
+
+func cashAddrPrefix(prefix string) []byte {
+	data := make([]byte, len(prefix)+1)
+	for i := range prefix {
+		data[i] = prefix[i] & 0x1f
+	}
+	return data
+}
+
+func cashAddrPolymod(data []byte) uint64 {
+	c := uint64(1)
+	for _, d := range data {
+		c0 := byte(c >> 35)
+		c = ((c & 0x07ffffffff) << 5) ^ uint64(d)
+		if c0&0x01 != 0 {
+			c ^= 0x98f2bc8e61
+		}
+		if c0&0x02 != 0 {
+			c ^= 0x79b76d99e2
+		}
+		if c0&0x04 != 0 {
+			c ^= 0xf33e5fb3c4
+		}
+		if c0&0x08 != 0 {
+			c ^= 0xae2eabe2a8
+		}
+		if c0&0x10 != 0 {
+			c ^= 0x1e4f43e470
+		}
+	}
+	return c ^ 1
+}
@@ -0,0 +1,57 @@
+package utxo_test
+
+import (
+	"testing/quick"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/binder/utxo"
+)
+
+var _ = Describe("Cashaddr addresses", func() {
+
+	Context("when encoding legacy addresses", func() {
+		It("should encode p2pkh addresses", func() {
+			addr, err := btcutil.DecodeAddress("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", &chaincfg.MainNetParams)
+			Expect(err).ShouldNot(HaveOccurred())
+			encoded, err := EncodeCashAddress(addr, "bitcoincash")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(encoded).Should(Equal("bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"))
+		})
+	})
+
+	Context("when decoding addresses", func() {
+		It("should decode the addresses it encodes", func() {
+			test := func(hash [20]byte, p2sh bool) bool {
+				var addr btcutil.Address
+				var err error
+				if p2sh {
+					addr, err = btcutil.NewAddressScriptHashFromHash(hash[:], &BitcoinCashMainNetParams)
+				} else {
+					addr, err = btcutil.NewAddressPubKeyHash(hash[:], &BitcoinCashMainNetParams)
+				}
+				Expect(err).ShouldNot(HaveOccurred())
+				encoded, err := EncodeCashAddress(addr, "bitcoincash")
+				Expect(err).ShouldNot(HaveOccurred())
+				decoded, err := DecodeCashAddress(encoded, "bitcoincash", &BitcoinCashMainNetParams)
+				Expect(err).ShouldNot(HaveOccurred())
+				return decoded.EncodeAddress() == addr.EncodeAddress()
+			}
+			Expect(quick.Check(test, nil)).ShouldNot(HaveOccurred())
+		})
+
+		It("should decode addresses without their prefix", func() {
+			addr, err := BitcoinCash.Networks["mainnet"].DecodeAddress("qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(addr.EncodeAddress()).Should(Equal("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu"))
+		})
+
+		It("should reject addresses with an invalid checksum", func() {
+			_, err := DecodeCashAddress("bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6b", "bitcoincash", &BitcoinCashMainNetParams)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
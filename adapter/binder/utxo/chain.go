@@ -0,0 +1,262 @@
+package utxo
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/tokens"
+)
+
+// A Chain parameterises the swap contracts, addresses and transactions of a
+// Bitcoin-like UTXO blockchain.
+type Chain struct {
+	Token tokens.Token
+
+	// CoinType is the BIP44 coin type of the keys on mainnet. Keys on every
+	// other network use the testnet coin type.
+	CoinType uint32
+
+	// Dust is the smallest output, in the smallest unit of the token, that is
+	// relayed by the network.
+	Dust int64
+
+	// ForkID chains sign every input with SIGHASH_FORKID, and the BIP143
+	// signature hash, to protect against replays on the chain they forked
+	// from.
+	ForkID bool
+
+	// Segwit chains support P2WSH swap contracts.
+	Segwit bool
+
+	// Replaceable chains relay transactions that replace unconfirmed
+	// transactions with a higher fee (BIP 125).
+	Replaceable bool
+
+	// FeeRates are the fee rates, in the smallest unit of the token per byte,
+	// of transactions at each execution speed.
+	FeeRates map[blockchain.TxExecutionSpeed]int64
+
+	// Networks are the networks of the chain by name.
+	Networks map[string]Network
+}
+
+// A Network of a Chain.
+type Network struct {
+	Params *chaincfg.Params
+
+	// CashAddrPrefix is the human readable prefix of cashaddr addresses, on
+	// networks that encode addresses in the cashaddr format. These networks
+	// accept legacy addresses too.
+	CashAddrPrefix string
+
+	// URL is the Esplora API that is used, unless another one is configured.
+	URL string
+}
+
+// LitecoinMainNetParams are the parameters of the Litecoin main network.
+var LitecoinMainNetParams = func() chaincfg.Params {
+	params := chaincfg.MainNetParams
+	params.Name = "litecoin-mainnet"
+	params.Net = wire.BitcoinNet(0xdbb6c0fb)
+	params.PubKeyHashAddrID = 0x30
+	params.ScriptHashAddrID = 0x32
+	params.PrivateKeyID = 0xb0
+	params.Bech32HRPSegwit = "ltc"
+	params.HDCoinType = 2
+	return params
+}()
+
+// LitecoinTestNetParams are the parameters of the Litecoin test network.
+var LitecoinTestNetParams = func() chaincfg.Params {
+	params := chaincfg.TestNet3Params
+	params.Name = "litecoin-testnet4"
+	params.Net = wire.BitcoinNet(0xf1c8d2fd)
+	params.PubKeyHashAddrID = 0x6f
+	params.ScriptHashAddrID = 0x3a
+	params.PrivateKeyID = 0xef
+	params.Bech32HRPSegwit = "tltc"
+	return params
+}()
+
+// BitcoinCashMainNetParams are the parameters of the Bitcoin Cash main
+// network. Its legacy addresses are the same as those of Bitcoin.
+var BitcoinCashMainNetParams = func() chaincfg.Params {
+	params := chaincfg.MainNetParams
+	params.Name = "bitcoincash-mainnet"
+	params.Net = wire.BitcoinNet(0xe8f3e1e3)
+	params.HDCoinType = 145
+	return params
+}()
+
+// BitcoinCashTestNetParams are the parameters of the Bitcoin Cash test
+// network.
+var BitcoinCashTestNetParams = func() chaincfg.Params {
+	params := chaincfg.TestNet3Params
+	params.Name = "bitcoincash-testnet3"
+	params.Net = wire.BitcoinNet(0xf4f3e5f4)
+	return params
+}()
+
+// Bitcoin is the Bitcoin Chain.
+var Bitcoin = Chain{
+	Token:       tokens.BTC,
+	CoinType:    0,
+	Dust:        546,
+	Segwit:      true,
+	Replaceable: true,
+	FeeRates: map[blockchain.TxExecutionSpeed]int64{
+		blockchain.Slow:     10,
+		blockchain.Standard: 20,
+		blockchain.Fast:     40,
+	},
+	Networks: map[string]Network{
+		"mainnet": {
+			Params: &chaincfg.MainNetParams,
+			URL:    "https://blockstream.info/api",
+		},
+		"testnet": {
+			Params: &chaincfg.TestNet3Params,
+			URL:    "https://blockstream.info/testnet/api",
+		},
+	},
+}
+
+// Litecoin is the Litecoin Chain.
+var Litecoin = Chain{
+	Token:       blockchain.LTC,
+	CoinType:    2,
+	Dust:        5460,
+	Segwit:      true,
+	Replaceable: true,
+	FeeRates: map[blockchain.TxExecutionSpeed]int64{
+		blockchain.Slow:     10,
+		blockchain.Standard: 20,
+		blockchain.Fast:     40,
+	},
+	Networks: map[string]Network{
+		"mainnet": {
+			Params: &LitecoinMainNetParams,
+			URL:    "https://litecoinspace.org/api",
+		},
+		"testnet": {
+			Params: &LitecoinTestNetParams,
+			URL:    "https://litecoinspace.org/testnet/api",
+		},
+	},
+}
+
+// BitcoinCash is the Bitcoin Cash Chain. There is no public Esplora API for
+// Bitcoin Cash, so the URL of one must be configured.
+var BitcoinCash = Chain{
+	Token:    blockchain.BCH,
+	CoinType: 145,
+	Dust:     546,
+	ForkID:   true,
+	FeeRates: map[blockchain.TxExecutionSpeed]int64{
+		blockchain.Slow:     1,
+		blockchain.Standard: 2,
+		blockchain.Fast:     5,
+	},
+	Networks: map[string]Network{
+		"mainnet": {
+			Params:         &BitcoinCashMainNetParams,
+			CashAddrPrefix: "bitcoincash",
+		},
+		"testnet": {
+			Params:         &BitcoinCashTestNetParams,
+			CashAddrPrefix: "bchtest",
+		},
+	},
+}
+
+// Chains are the supported UTXO chains by blockchain.
+var Chains = map[tokens.BlockchainName]Chain{
+	tokens.BITCOIN:         Bitcoin,
+	blockchain.LITECOIN:    Litecoin,
+	blockchain.BITCOINCASH: BitcoinCash,
+}
+
+func init() {
+	// Litecoin addresses cannot be decoded unless their address ids are
+	// registered.
+	for _, params := range []*chaincfg.Params{&LitecoinMainNetParams, &LitecoinTestNetParams} {
+		if err := chaincfg.Register(params); err != nil && err != chaincfg.ErrDuplicateNet {
+			panic(err)
+		}
+	}
+}
+
+// ChainOf returns the Chain of the blockchain.
+func ChainOf(blockchainName tokens.BlockchainName) (Chain, error) {
+	chain, ok := Chains[blockchainName]
+	if !ok {
+		return Chain{}, tokens.NewErrUnsupportedBlockchain(blockchainName)
+	}
+	return chain, nil
+}
+
+// Network returns the network of the chain with the given name.
+func (chain Chain) Network(name string) (Network, error) {
+	if name == "testnet3" || name == "testnet4" {
+		name = "testnet"
+	}
+	network, ok := chain.Networks[name]
+	if !ok {
+		return Network{}, fmt.Errorf("unsupported %s network: %s", chain.Token.Blockchain, name)
+	}
+	return network, nil
+}
+
+// NewClient returns a Client of the Esplora API at the url, or of the default
+// API of the named network if the url is empty.
+func (chain Chain) NewClient(network, url string) (*Client, error) {
+	net, err := chain.Network(network)
+	if err != nil {
+		return nil, err
+	}
+	if url == "" {
+		url = net.URL
+	}
+	if url == "" {
+		return nil, fmt.Errorf("no esplora api configured for %s %s", chain.Token.Blockchain, network)
+	}
+	return NewClient(url), nil
+}
+
+// FeeRate returns the fee rate of transactions at the speed.
+func (chain Chain) FeeRate(speed blockchain.TxExecutionSpeed) int64 {
+	feeRate, ok := chain.FeeRates[speed]
+	if !ok {
+		return chain.FeeRates[blockchain.Fast]
+	}
+	return feeRate
+}
+
+// DecodeAddress decodes an address of the network.
+func (network Network) DecodeAddress(address string) (btcutil.Address, error) {
+	if network.CashAddrPrefix != "" {
+		if addr, err := DecodeCashAddress(address, network.CashAddrPrefix, network.Params); err == nil {
+			return addr, nil
+		}
+	}
+	addr, err := btcutil.DecodeAddress(address, network.Params)
+	if err != nil {
+		return nil, err
+	}
+	if !addr.IsForNet(network.Params) {
+		return nil, fmt.Errorf("address %s is not intended for use on %s", address, network.Params.Name)
+	}
+	return addr, nil
+}
+
+// EncodeAddress encodes an address of the network, in the cashaddr format if
+// the network supports it.
+func (network Network) EncodeAddress(addr btcutil.Address) (string, error) {
+	if network.CashAddrPrefix != "" {
+		return EncodeCashAddress(addr, network.CashAddrPrefix)
+	}
+	return addr.EncodeAddress(), nil
+}
@@ -0,0 +1,340 @@
+package utxo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ElectrumProtocolVersion is the version of the Electrum protocol that is
+// negotiated with servers.
+const ElectrumProtocolVersion = "1.4"
+
+// An ElectrumClient is a Backend that uses an Electrum server, such as
+// ElectrumX or Fulcrum. Its url is "tcp://host:port", or "ssl://host:port" for
+// servers that use TLS. It holds a single connection to the server, so one
+// client is shared by every account of a network.
+type ElectrumClient struct {
+	url     string
+	network Network
+
+	mu     *sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID uint64
+	closed bool
+}
+
+type electrumRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type electrumResponse struct {
+	ID     *uint64         `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type electrumBalance struct {
+	Confirmed   int64 `json:"confirmed"`
+	Unconfirmed int64 `json:"unconfirmed"`
+}
+
+type electrumHistory struct {
+	TxHash string `json:"tx_hash"`
+	Height int64  `json:"height"`
+}
+
+type electrumUnspent struct {
+	TxHash string `json:"tx_hash"`
+	TxPos  uint32 `json:"tx_pos"`
+	Value  int64  `json:"value"`
+}
+
+// NewElectrumClient returns an ElectrumClient of the server at the url, that
+// is on the network. It connects to the server when it is first used.
+func NewElectrumClient(url string, network Network) *ElectrumClient {
+	return &ElectrumClient{
+		url:     url,
+		network: network,
+		mu:      new(sync.Mutex),
+	}
+}
+
+// Close closes the connection to the server. The client cannot be used once it
+// is closed.
+func (client *ElectrumClient) Close() error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.closed = true
+	client.disconnect()
+	return nil
+}
+
+func (client *ElectrumClient) Balance(ctx context.Context, address string) (int64, error) {
+	scriptHash, err := client.scriptHash(address)
+	if err != nil {
+		return 0, err
+	}
+	balance := electrumBalance{}
+	if err := client.call(ctx, "blockchain.scripthash.get_balance", &balance, scriptHash); err != nil {
+		return 0, err
+	}
+	return balance.Confirmed + balance.Unconfirmed, nil
+}
+
+func (client *ElectrumClient) ScriptFunded(ctx context.Context, address string, value int64) (bool, int64, error) {
+	funded, err := client.received(ctx, address)
+	if err != nil {
+		return false, 0, err
+	}
+	return funded >= value && funded > 0, funded, nil
+}
+
+func (client *ElectrumClient) ScriptRedeemed(ctx context.Context, address string, value int64) (bool, int64, error) {
+	funded, err := client.received(ctx, address)
+	if err != nil {
+		return false, 0, err
+	}
+	unspent, err := client.Balance(ctx, address)
+	if err != nil {
+		return false, 0, err
+	}
+	return funded > 0 && funded >= value && unspent == 0, unspent, nil
+}
+
+// ScriptSpent finds the transaction that spent the script address amongst
+// the history of the address. The spending input is the one that reveals the
+// script of the address.
+func (client *ElectrumClient) ScriptSpent(ctx context.Context, address string) (bool, [][]byte, error) {
+	pkScript, txs, err := client.history(ctx, address)
+	if err != nil {
+		return false, nil, err
+	}
+	for _, tx := range txs {
+		if pushes, ok := spendingPushes(tx, pkScript); ok {
+			return true, pushes, nil
+		}
+	}
+	return false, nil, nil
+}
+
+func (client *ElectrumClient) UTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	scriptHash, err := client.scriptHash(address)
+	if err != nil {
+		return nil, err
+	}
+	unspent := []electrumUnspent{}
+	if err := client.call(ctx, "blockchain.scripthash.listunspent", &unspent, scriptHash); err != nil {
+		return nil, err
+	}
+	utxos := make([]UTXO, 0, len(unspent))
+	for _, output := range unspent {
+		utxos = append(utxos, UTXO{TxHash: output.TxHash, Vout: output.TxPos, Value: output.Value})
+	}
+	return utxos, nil
+}
+
+// Confirmations returns the number of confirmations of the transaction, from
+// the verbose transaction of the node behind the server.
+func (client *ElectrumClient) Confirmations(ctx context.Context, txHash string) (int64, error) {
+	tx := struct {
+		Confirmations int64 `json:"confirmations"`
+	}{}
+	if err := client.call(ctx, "blockchain.transaction.get", &tx, txHash, true); err != nil {
+		return 0, err
+	}
+	return tx.Confirmations, nil
+}
+
+func (client *ElectrumClient) Publish(ctx context.Context, tx string) (string, error) {
+	txHash := ""
+	err := client.call(ctx, "blockchain.transaction.broadcast", &txHash, tx)
+	return txHash, err
+}
+
+// received returns the total value that has been sent to the address.
+func (client *ElectrumClient) received(ctx context.Context, address string) (int64, error) {
+	pkScript, txs, err := client.history(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	received := int64(0)
+	for _, tx := range txs {
+		for _, txOut := range tx.TxOut {
+			if bytes.Equal(txOut.PkScript, pkScript) {
+				received += txOut.Value
+			}
+		}
+	}
+	return received, nil
+}
+
+// history returns the public key script of the address, and the transactions
+// that pay to or spend from it.
+func (client *ElectrumClient) history(ctx context.Context, address string) ([]byte, []*wire.MsgTx, error) {
+	pkScript, err := pkScriptOf(client.network, address)
+	if err != nil {
+		return nil, nil, err
+	}
+	history := []electrumHistory{}
+	if err := client.call(ctx, "blockchain.scripthash.get_history", &history, electrumScriptHash(pkScript)); err != nil {
+		return nil, nil, err
+	}
+	txs := make([]*wire.MsgTx, 0, len(history))
+	for _, entry := range history {
+		txHex := ""
+		if err := client.call(ctx, "blockchain.transaction.get", &txHex, entry.TxHash); err != nil {
+			return nil, nil, err
+		}
+		tx, err := decodeTx(txHex)
+		if err != nil {
+			return nil, nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return pkScript, txs, nil
+}
+
+func (client *ElectrumClient) scriptHash(address string) (string, error) {
+	pkScript, err := pkScriptOf(client.network, address)
+	if err != nil {
+		return "", err
+	}
+	return electrumScriptHash(pkScript), nil
+}
+
+// electrumScriptHash returns the script hash that Electrum servers index the
+// public key script by, which is its reversed SHA256 hash.
+func electrumScriptHash(pkScript []byte) string {
+	hash := sha256.Sum256(pkScript)
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+	return hex.EncodeToString(hash[:])
+}
+
+// call sends a request to the server and waits for its response. Requests
+// are sent one at a time over a connection, which is reopened after it fails.
+// Errors returned by the server do not close the connection.
+func (client *ElectrumClient) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.closed {
+		return ErrElectrumClientClosed
+	}
+	if client.conn == nil {
+		if err := client.connect(ctx); err != nil {
+			return err
+		}
+	}
+	return client.roundTrip(ctx, method, result, params)
+}
+
+// connect opens a connection to the server and negotiates the protocol
+// version.
+func (client *ElectrumClient) connect(ctx context.Context) error {
+	serverURL, err := url.Parse(client.url)
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{Timeout: time.Minute}
+	var conn net.Conn
+	switch serverURL.Scheme {
+	case "tcp":
+		conn, err = dialer.DialContext(ctx, "tcp", serverURL.Host)
+	case "ssl", "tls":
+		tlsDialer := &tls.Dialer{
+			NetDialer: dialer,
+			Config:    &tls.Config{ServerName: serverURL.Hostname()},
+		}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", serverURL.Host)
+	default:
+		return fmt.Errorf("unsupported electrum url: %s", client.url)
+	}
+	if err != nil {
+		return err
+	}
+	client.conn = conn
+	client.reader = bufio.NewReader(conn)
+
+	versions := []string{}
+	if err := client.roundTrip(ctx, "server.version", &versions, []interface{}{"swapperd", ElectrumProtocolVersion}); err != nil {
+		client.disconnect()
+		return err
+	}
+	return nil
+}
+
+func (client *ElectrumClient) disconnect() {
+	if client.conn != nil {
+		client.conn.Close()
+		client.conn = nil
+	}
+}
+
+func (client *ElectrumClient) roundTrip(ctx context.Context, method string, result interface{}, params []interface{}) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	if err := client.conn.SetDeadline(deadline); err != nil {
+		client.disconnect()
+		return err
+	}
+
+	client.nextID++
+	id := client.nextID
+	data, err := json.Marshal(electrumRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := client.conn.Write(append(data, '\n')); err != nil {
+		client.disconnect()
+		return err
+	}
+
+	// Notifications have no id, and are skipped.
+	for {
+		line, err := client.reader.ReadBytes('\n')
+		if err != nil {
+			client.disconnect()
+			return err
+		}
+		response := electrumResponse{}
+		if err := json.Unmarshal(line, &response); err != nil {
+			client.disconnect()
+			return fmt.Errorf("unexpected response to %s: %v", method, err)
+		}
+		if response.ID == nil || *response.ID != id {
+			continue
+		}
+		if response.Error != nil {
+			return fmt.Errorf("%s failed with code %d: %s", method, response.Error.Code, response.Error.Message)
+		}
+		return json.Unmarshal(response.Result, result)
+	}
+}
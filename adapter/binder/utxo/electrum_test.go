@@ -0,0 +1,381 @@
+package utxo_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/binder/utxo"
+)
+
+// standInServer is an Electrum server that serves the transactions it holds.
+type standInServer struct {
+	listener net.Listener
+	mu       *sync.Mutex
+	txs      map[string]*wire.MsgTx
+	conns    int
+}
+
+func newStandInServer(txs ...*wire.MsgTx) *standInServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ShouldNot(HaveOccurred())
+	server := &standInServer{
+		listener: listener,
+		mu:       new(sync.Mutex),
+		txs:      map[string]*wire.MsgTx{},
+	}
+	for _, tx := range txs {
+		server.txs[tx.TxHash().String()] = tx
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns++
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *standInServer) url() string {
+	return "tcp://" + server.listener.Addr().String()
+}
+
+func (server *standInServer) connections() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.conns
+}
+
+func (server *standInServer) serve(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		request := struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			return
+		}
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		if result, err := server.handle(request.Method, request.Params); err != nil {
+			response["error"] = map[string]interface{}{"code": 2, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+
+		// Notifications are interleaved with the responses.
+		notification := `{"jsonrpc":"2.0","method":"blockchain.headers.subscribe","params":[{"height":1,"hex":""}]}`
+		data, err := json.Marshal(response)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte(notification + "\n" + string(data) + "\n")); err != nil {
+			return
+		}
+	}
+}
+
+func (server *standInServer) handle(method string, params []json.RawMessage) (interface{}, error) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	param := func(i int) string {
+		value := ""
+		if i < len(params) {
+			json.Unmarshal(params[i], &value)
+		}
+		return value
+	}
+	switch method {
+	case "server.version":
+		return []string{"stand-in", "1.4"}, nil
+	case "blockchain.scripthash.get_balance":
+		balance := int64(0)
+		for _, output := range server.unspent(param(0)) {
+			balance += output["value"].(int64)
+		}
+		return map[string]int64{"confirmed": balance, "unconfirmed": 0}, nil
+	case "blockchain.scripthash.listunspent":
+		return server.unspent(param(0)), nil
+	case "blockchain.scripthash.get_history":
+		history := []map[string]interface{}{}
+		for txHash, tx := range server.txs {
+			if server.involves(tx, param(0)) {
+				history = append(history, map[string]interface{}{"tx_hash": txHash, "height": 1})
+			}
+		}
+		return history, nil
+	case "blockchain.transaction.get":
+		tx, ok := server.txs[param(0)]
+		if !ok {
+			return nil, fmt.Errorf("unknown transaction %s", param(0))
+		}
+		if len(params) > 1 && string(params[1]) == "true" {
+			return map[string]interface{}{"txid": param(0), "confirmations": 3}, nil
+		}
+		buf := new(bytes.Buffer)
+		if err := tx.Serialize(buf); err != nil {
+			return nil, err
+		}
+		return hex.EncodeToString(buf.Bytes()), nil
+	case "blockchain.transaction.broadcast":
+		data, err := hex.DecodeString(param(0))
+		if err != nil {
+			return nil, err
+		}
+		tx := wire.NewMsgTx(wire.TxVersion)
+		if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		server.txs[tx.TxHash().String()] = tx
+		return tx.TxHash().String(), nil
+	default:
+		return nil, fmt.Errorf("unknown method %s", method)
+	}
+}
+
+func (server *standInServer) unspent(scriptHash string) []map[string]interface{} {
+	unspent := []map[string]interface{}{}
+	for txHash, tx := range server.txs {
+		for i, txOut := range tx.TxOut {
+			if scriptHashOf(txOut.PkScript) == scriptHash && !server.spent(tx.TxHash(), uint32(i)) {
+				unspent = append(unspent, map[string]interface{}{"tx_hash": txHash, "tx_pos": i, "value": txOut.Value, "height": 1})
+			}
+		}
+	}
+	return unspent
+}
+
+func (server *standInServer) spent(txHash chainhash.Hash, index uint32) bool {
+	for _, tx := range server.txs {
+		for _, txIn := range tx.TxIn {
+			if txIn.PreviousOutPoint.Hash == txHash && txIn.PreviousOutPoint.Index == index {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (server *standInServer) involves(tx *wire.MsgTx, scriptHash string) bool {
+	for _, txOut := range tx.TxOut {
+		if scriptHashOf(txOut.PkScript) == scriptHash {
+			return true
+		}
+	}
+	for _, txIn := range tx.TxIn {
+		prevTx, ok := server.txs[txIn.PreviousOutPoint.Hash.String()]
+		if ok && scriptHashOf(prevTx.TxOut[txIn.PreviousOutPoint.Index].PkScript) == scriptHash {
+			return true
+		}
+	}
+	return false
+}
+
+func scriptHashOf(pkScript []byte) string {
+	hash := sha256.Sum256(pkScript)
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+	return hex.EncodeToString(hash[:])
+}
+
+var _ = Describe("Electrum backend", func() {
+
+	network, err := Bitcoin.Network("testnet")
+	if err != nil {
+		panic(err)
+	}
+	script := []byte{txscript.OP_DROP, txscript.OP_TRUE}
+	secret := []byte("secret")
+
+	buildTxs := func() (string, *wire.MsgTx, *wire.MsgTx) {
+		addr, err := btcutil.NewAddressScriptHash(script, network.Params)
+		Expect(err).ShouldNot(HaveOccurred())
+		pkScript, err := txscript.PayToAddrScript(addr)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		fundTx := wire.NewMsgTx(wire.TxVersion)
+		fundTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+		fundTx.AddTxOut(wire.NewTxOut(10000, pkScript))
+
+		fundHash := fundTx.TxHash()
+		sigScript, err := txscript.NewScriptBuilder().AddData(secret).AddData(script).Script()
+		Expect(err).ShouldNot(HaveOccurred())
+		spendTx := wire.NewMsgTx(wire.TxVersion)
+		spendTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundHash, 0), sigScript, nil))
+		spendTx.AddTxOut(wire.NewTxOut(9000, []byte{txscript.OP_TRUE}))
+
+		return addr.EncodeAddress(), fundTx, spendTx
+	}
+
+	serialize := func(tx *wire.MsgTx) string {
+		buf := new(bytes.Buffer)
+		Expect(tx.Serialize(buf)).Should(Succeed())
+		return hex.EncodeToString(buf.Bytes())
+	}
+
+	Context("when a script address is funded", func() {
+		It("should return the balance and unspent outputs of the address", func() {
+			address, fundTx, _ := buildTxs()
+			server := newStandInServer(fundTx)
+			defer server.listener.Close()
+			client := NewElectrumClient(server.url(), network)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			balance, err := client.Balance(ctx, address)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(balance).Should(Equal(int64(10000)))
+
+			utxos, err := client.UTXOs(ctx, address)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(utxos).Should(Equal([]UTXO{{TxHash: fundTx.TxHash().String(), Vout: 0, Value: 10000}}))
+		})
+
+		It("should be funded with at most the value it was sent", func() {
+			address, fundTx, _ := buildTxs()
+			server := newStandInServer(fundTx)
+			defer server.listener.Close()
+			client := NewElectrumClient(server.url(), network)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			funded, value, err := client.ScriptFunded(ctx, address, 10000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(funded).Should(BeTrue())
+			Expect(value).Should(Equal(int64(10000)))
+
+			funded, _, err = client.ScriptFunded(ctx, address, 10001)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(funded).Should(BeFalse())
+
+			spent, _, err := client.ScriptSpent(ctx, address)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(spent).Should(BeFalse())
+		})
+	})
+
+	Context("when a script address is spent", func() {
+		It("should return the data pushed by the spending input", func() {
+			address, fundTx, spendTx := buildTxs()
+			server := newStandInServer(fundTx)
+			defer server.listener.Close()
+			client := NewElectrumClient(server.url(), network)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			txHash, err := client.Publish(ctx, serialize(spendTx))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(txHash).Should(Equal(spendTx.TxHash().String()))
+
+			redeemed, unspent, err := client.ScriptRedeemed(ctx, address, 10000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(redeemed).Should(BeTrue())
+			Expect(unspent).Should(BeZero())
+
+			spent, pushes, err := client.ScriptSpent(ctx, address)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(spent).Should(BeTrue())
+			Expect(pushes).Should(Equal([][]byte{secret, script}))
+		})
+	})
+
+	Context("when looking up transactions", func() {
+		It("should return the confirmations of known transactions, and keep the connection after errors", func() {
+			_, fundTx, _ := buildTxs()
+			server := newStandInServer(fundTx)
+			defer server.listener.Close()
+			client := NewElectrumClient(server.url(), network)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			_, err := client.Confirmations(ctx, chainhash.Hash{2}.String())
+			Expect(err).Should(HaveOccurred())
+
+			confirmations, err := client.Confirmations(ctx, fundTx.TxHash().String())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(confirmations).Should(Equal(int64(3)))
+		})
+
+		It("should share one connection between calls until it is closed", func() {
+			_, fundTx, _ := buildTxs()
+			server := newStandInServer(fundTx)
+			defer server.listener.Close()
+			client := NewElectrumClient(server.url(), network)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			errs := make(chan error, 10)
+			for i := 0; i < 10; i++ {
+				go func() {
+					_, err := client.Confirmations(ctx, fundTx.TxHash().String())
+					errs <- err
+				}()
+			}
+			for i := 0; i < 10; i++ {
+				Expect(<-errs).ShouldNot(HaveOccurred())
+			}
+			Expect(server.connections()).Should(Equal(1))
+
+			Expect(client.Close()).Should(Succeed())
+			_, err := client.Confirmations(ctx, fundTx.TxHash().String())
+			Expect(err).Should(Equal(ErrElectrumClientClosed))
+			Expect(server.connections()).Should(Equal(1))
+		})
+
+		It("should stop the tls handshake when the context is done", func() {
+			// The listener accepts connections, but never answers the
+			// handshake.
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ShouldNot(HaveOccurred())
+			defer listener.Close()
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					defer conn.Close()
+				}
+			}()
+
+			client := NewElectrumClient("ssl://"+listener.Addr().String(), network)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			_, err = client.Confirmations(ctx, chainhash.Hash{2}.String())
+			Expect(err).Should(HaveOccurred())
+			Expect(time.Since(start)).Should(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should reject unsupported urls", func() {
+			client := NewElectrumClient("http://127.0.0.1:50001", network)
+			_, err := client.Confirmations(context.Background(), chainhash.Hash{2}.String())
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
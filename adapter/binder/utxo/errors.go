@@ -0,0 +1,41 @@
+package utxo
+
+import (
+	"fmt"
+)
+
+var ErrMalformedRedeemTx = fmt.Errorf("redeem transaction returned by the blockchain is malformed")
+var ErrNotFunded = fmt.Errorf("swap contract has no unspent outputs")
+var ErrElectrumClientClosed = fmt.Errorf("electrum client is closed")
+
+func NewErrDecodeAddress(addr string, err error) error {
+	return fmt.Errorf("failed to decode address (%s): %v", addr, err)
+}
+
+func NewErrSignTransaction(err error) error {
+	return fmt.Errorf("failed to sign transaction: %v", err)
+}
+
+func NewErrPublishTransaction(err error) error {
+	return fmt.Errorf("failed to publish signed transaction: %v", err)
+}
+
+func NewErrBuildScript(err error) error {
+	return fmt.Errorf("failed to build script: %v", err)
+}
+
+func NewErrInitiate(err error) error {
+	return fmt.Errorf("failed to initiate: %v", err)
+}
+
+func NewErrRedeem(err error) error {
+	return fmt.Errorf("failed to redeem: %v", err)
+}
+
+func NewErrRefund(err error) error {
+	return fmt.Errorf("failed to refund: %v", err)
+}
+
+func NewErrAuditSecret(err error) error {
+	return fmt.Errorf("failed to audit secret: %v", err)
+}
@@ -0,0 +1,198 @@
+package utxo
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// A Client reads the state of a UTXO blockchain from an Esplora API, and
+// publishes transactions to it.
+type Client struct {
+	url string
+}
+
+// A UTXO is an unspent output of an address.
+type UTXO struct {
+	TxHash string `json:"txid"`
+	Vout   uint32 `json:"vout"`
+	Value  int64  `json:"value"`
+}
+
+type esploraStats struct {
+	FundedTxoSum int64 `json:"funded_txo_sum"`
+	SpentTxoSum  int64 `json:"spent_txo_sum"`
+}
+
+type esploraAddress struct {
+	ChainStats   esploraStats `json:"chain_stats"`
+	MempoolStats esploraStats `json:"mempool_stats"`
+}
+
+type esploraTx struct {
+	TxHash string `json:"txid"`
+	Vin    []struct {
+		Prevout struct {
+			Address string `json:"scriptpubkey_address"`
+		} `json:"prevout"`
+		SigScript string   `json:"scriptsig"`
+		Witness   []string `json:"witness"`
+	} `json:"vin"`
+}
+
+type esploraTxStatus struct {
+	Confirmed   bool  `json:"confirmed"`
+	BlockHeight int64 `json:"block_height"`
+}
+
+// NewClient returns a Client of the Esplora API at the url.
+func NewClient(url string) *Client {
+	return &Client{strings.TrimSuffix(url, "/")}
+}
+
+// Balance returns the confirmed and unconfirmed balance of the address.
+func (client *Client) Balance(ctx context.Context, address string) (int64, error) {
+	stats, err := client.address(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	funded := stats.ChainStats.FundedTxoSum + stats.MempoolStats.FundedTxoSum
+	spent := stats.ChainStats.SpentTxoSum + stats.MempoolStats.SpentTxoSum
+	return funded - spent, nil
+}
+
+// ScriptFunded returns true if the script address has been sent at least the
+// given value, and the value it has been sent.
+func (client *Client) ScriptFunded(ctx context.Context, address string, value int64) (bool, int64, error) {
+	stats, err := client.address(ctx, address)
+	if err != nil {
+		return false, 0, err
+	}
+	funded := stats.ChainStats.FundedTxoSum + stats.MempoolStats.FundedTxoSum
+	return funded >= value && funded > 0, funded, nil
+}
+
+// ScriptRedeemed returns true if the script address has been funded with at
+// least the given value and spent, and the value it still holds.
+func (client *Client) ScriptRedeemed(ctx context.Context, address string, value int64) (bool, int64, error) {
+	stats, err := client.address(ctx, address)
+	if err != nil {
+		return false, 0, err
+	}
+	funded := stats.ChainStats.FundedTxoSum + stats.MempoolStats.FundedTxoSum
+	spent := stats.ChainStats.SpentTxoSum + stats.MempoolStats.SpentTxoSum
+	return funded > 0 && funded >= value && funded == spent, funded - spent, nil
+}
+
+// ScriptSpent returns true if the script address has been spent, and the data
+// pushed by the signature script, or the witness, of the input that spent it.
+func (client *Client) ScriptSpent(ctx context.Context, address string) (bool, [][]byte, error) {
+	txs := []esploraTx{}
+	if err := client.get(ctx, fmt.Sprintf("/address/%s/txs", address), &txs); err != nil {
+		return false, nil, err
+	}
+	for _, tx := range txs {
+		for _, vin := range tx.Vin {
+			if vin.Prevout.Address != address {
+				continue
+			}
+			if len(vin.Witness) == 0 {
+				sigScript, err := hex.DecodeString(vin.SigScript)
+				if err != nil {
+					return false, nil, fmt.Errorf("malformed signature script of %s: %v", tx.TxHash, err)
+				}
+				pushes, err := txscript.PushedData(sigScript)
+				if err != nil {
+					return false, nil, fmt.Errorf("malformed signature script of %s: %v", tx.TxHash, err)
+				}
+				return true, pushes, nil
+			}
+			witness := make([][]byte, len(vin.Witness))
+			for i, item := range vin.Witness {
+				data, err := hex.DecodeString(item)
+				if err != nil {
+					return false, nil, fmt.Errorf("malformed witness of %s: %v", tx.TxHash, err)
+				}
+				witness[i] = data
+			}
+			return true, witness, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// UTXOs returns the unspent outputs of the address.
+func (client *Client) UTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	utxos := []UTXO{}
+	if err := client.get(ctx, fmt.Sprintf("/address/%s/utxo", address), &utxos); err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// Confirmations returns the number of confirmations of the transaction.
+func (client *Client) Confirmations(ctx context.Context, txHash string) (int64, error) {
+	status := esploraTxStatus{}
+	if err := client.get(ctx, fmt.Sprintf("/tx/%s/status", txHash), &status); err != nil {
+		return 0, err
+	}
+	if !status.Confirmed {
+		return 0, nil
+	}
+	height := int64(0)
+	if err := client.get(ctx, "/blocks/tip/height", &height); err != nil {
+		return 0, err
+	}
+	return height - status.BlockHeight + 1, nil
+}
+
+// Publish broadcasts the hex encoded transaction, and returns its hash.
+func (client *Client) Publish(ctx context.Context, tx string) (string, error) {
+	req, err := http.NewRequest("POST", client.url+"/tx", strings.NewReader(tx))
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, data)
+	}
+	return string(bytes.TrimSpace(data)), nil
+}
+
+func (client *Client) address(ctx context.Context, address string) (esploraAddress, error) {
+	stats := esploraAddress{}
+	err := client.get(ctx, fmt.Sprintf("/address/%s", address), &stats)
+	return stats, err
+}
+
+func (client *Client) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequest("GET", client.url+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, data)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,35 @@
+package utxo
+
+import (
+	"math/big"
+
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/foundation/swap"
+)
+
+// Estimated sizes, in bytes, of the transactions that initiate and redeem a
+// swap. Witness data is discounted, so P2WSH redeems are smaller.
+const (
+	InitiateTxSize      = 250
+	RedeemTxSize        = 350
+	WitnessRedeemTxSize = 180
+)
+
+// EstimateCost estimates the cost of initiating, or redeeming, a swap on the
+// chain.
+func EstimateCost(chain Chain, swap swap.Swap, initiate bool) blockchain.Cost {
+	size := redeemTxSize(swap.ScriptType)
+	if initiate {
+		size = InitiateTxSize
+	}
+	return blockchain.Cost{
+		chain.Token.Name: big.NewInt(size * chain.FeeRate(swap.Speed)),
+	}
+}
+
+func redeemTxSize(scriptType swap.ScriptType) int64 {
+	if witnessScript(scriptType) {
+		return WitnessRedeemTxSize
+	}
+	return RedeemTxSize
+}
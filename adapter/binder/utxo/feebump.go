@@ -0,0 +1,70 @@
+package utxo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/renproject/swapperd/adapter/binder/feebump"
+	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
+	"github.com/renproject/swapperd/foundation/blockchain"
+)
+
+// BumpWindows are the times that transactions spending a swap contract are
+// given to confirm before they are replaced by transactions with a higher
+// fee.
+var BumpWindows = feebump.Windows{
+	blockchain.Slow:     2 * time.Hour,
+	blockchain.Standard: time.Hour,
+	blockchain.Fast:     30 * time.Minute,
+}
+
+// MinRelayFeeRate is the minimum fee rate, in the smallest unit of the token
+// per byte, that nodes relay transactions at.
+const MinRelayFeeRate = 1
+
+// ReplaceableSequence is the sequence number of the inputs of transactions
+// that can be replaced by transactions with a higher fee (BIP 125).
+const ReplaceableSequence = wire.MaxTxInSequenceNum - 2
+
+// sendSpendTransaction spends the swap contract to the outputs, unless a
+// transaction that spends it is pending, and returns the transaction once it
+// is confirmed. Until then it returns ErrSpendPending, so that the swap is
+// attempted again later. A pending transaction that is not confirmed within
+// the window of its speed is replaced by a transaction with a higher fee, at
+// an escalated speed, on chains that relay replacements.
+func (atom *utxoSwapContractBinder) sendSpendTransaction(ctx context.Context, key string, outputs []*wire.TxOut, lockTime, sequence uint32, unlock func(sig, pubKey []byte) ([][]byte, error)) (feebump.PendingTx, error) {
+	speed := atom.swap.Speed
+	if speed == blockchain.Nil {
+		speed = blockchain.Fast
+	}
+	pending, next, wait := atom.tracker.Next(key, speed, BumpWindows, time.Now())
+	if pending != nil {
+		if atom.txConfirmed(*pending) {
+			atom.tracker.Forget(key)
+			return *pending, nil
+		}
+		if wait > 0 || !atom.account.Chain.Replaceable {
+			return feebump.PendingTx{}, immediate.ErrSpendPending
+		}
+		atom.Info(fmt.Sprintf("Replacing %s on %s blockchain: not confirmed in time", pending.Hash, atom.account.Chain.Token.Blockchain))
+	}
+	tx, err := atom.spend(ctx, next, pending, outputs, lockTime, sequence, unlock)
+	if err != nil {
+		return feebump.PendingTx{}, err
+	}
+	atom.tracker.Track(key, tx)
+	return feebump.PendingTx{}, immediate.ErrSpendPending
+}
+
+// txConfirmed returns true once the transaction has been mined.
+func (atom *utxoSwapContractBinder) txConfirmed(tx feebump.PendingTx) bool {
+	if tx.Hash == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	confirmations, err := atom.account.Confirmations(ctx, tx.Hash)
+	return err == nil && confirmations > 0
+}
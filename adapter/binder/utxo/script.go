@@ -0,0 +1,151 @@
+package utxo
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/renproject/swapperd/foundation/swap"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// AtomicSwapRefundScriptSize is the size of the Bitcoin Atomic Swap's
+// RefundScript
+const AtomicSwapRefundScriptSize = 1 + 73 + 1 + 33 + 1
+
+// AtomicSwapRedeemScriptSize is the size of the Bitcoin Atomic Swap's
+// RedeemScript
+const AtomicSwapRedeemScriptSize = 1 + 73 + 1 + 33 + 1 + 32 + 1
+
+// AtomicSwapRefundWitnessSize is the size of the witness of a P2WSH Bitcoin
+// Atomic Swap's refund, without the initiate script
+const AtomicSwapRefundWitnessSize = 1 + 1 + 73 + 1 + 33 + 1
+
+// AtomicSwapRedeemWitnessSize is the size of the witness of a P2WSH Bitcoin
+// Atomic Swap's redeem, without the initiate script
+const AtomicSwapRedeemWitnessSize = 1 + 1 + 73 + 1 + 33 + 1 + 32 + 1 + 1
+
+// NewInitiateScript creates a Bitcoin Atomic Swap initiate script.
+//
+//	OP_IF
+//		OP_SIZE
+//		32
+//		OP_EQUALVERIFY
+//		OP_SHA256
+//		<secret_hash>
+//		OP_EQUALVERIFY
+//		OP_DUP
+//		OP_HASH160
+//		<foreign_address>
+//	OP_ELSE
+//		<lock_time>
+//		OP_CHECKLOCKTIMEVERIFY
+//		OP_DROP
+//		OP_DUP
+//		OP_HASH160
+//		<personal_address>
+//	OP_ENDIF
+//	OP_EQUALVERIFY
+//	OP_CHECKSIG
+func NewInitiateScript(pkhMe, pkhThem *[ripemd160.Size]byte, locktime int64, secretHash []byte) ([]byte, error) {
+	b := txscript.NewScriptBuilder()
+
+	b.AddOp(txscript.OP_IF)
+	{
+		b.AddOp(txscript.OP_SIZE)
+		b.AddData([]byte{32})
+		b.AddOp(txscript.OP_EQUALVERIFY)
+		b.AddOp(txscript.OP_SHA256)
+		b.AddData(secretHash)
+		b.AddOp(txscript.OP_EQUALVERIFY)
+		b.AddOp(txscript.OP_DUP)
+		b.AddOp(txscript.OP_HASH160)
+		b.AddData(pkhThem[:])
+	}
+	b.AddOp(txscript.OP_ELSE)
+	{
+		b.AddInt64(locktime)
+		b.AddOp(txscript.OP_CHECKLOCKTIMEVERIFY)
+		b.AddOp(txscript.OP_DROP)
+		b.AddOp(txscript.OP_DUP)
+		b.AddOp(txscript.OP_HASH160)
+		b.AddData(pkhMe[:])
+	}
+	b.AddOp(txscript.OP_ENDIF)
+	b.AddOp(txscript.OP_EQUALVERIFY)
+	b.AddOp(txscript.OP_CHECKSIG)
+
+	return b.Script()
+}
+
+// NewRedeemScript creates a Redeem Script for the Bitcoin Atomic Swap.
+//
+//	<Signature>
+//	<PublicKey>
+//	<Secret>
+//	1 (True)
+//	<InitiateScript>
+func NewRedeemScript(initiateScript, sig, pubkey []byte, secret [32]byte) ([]byte, error) {
+	b := txscript.NewScriptBuilder()
+	b.AddData(sig)
+	b.AddData(pubkey)
+	b.AddData(secret[:])
+	b.AddInt64(1)
+	b.AddData(initiateScript)
+	return b.Script()
+}
+
+// NewRefundScript creates a Bitcoin Refund Atomic Swap.
+//
+//	<Signature>
+//	<PublicKey>
+//	0 (False)
+//	<InitiateScript>
+func NewRefundScript(initiateScript, sig, pubkey []byte) ([]byte, error) {
+	b := txscript.NewScriptBuilder()
+	b.AddData(sig)
+	b.AddData(pubkey)
+	b.AddInt64(0)
+	b.AddData(initiateScript)
+	return b.Script()
+}
+
+// NewRedeemWitness creates the witness that redeems a P2WSH Bitcoin Atomic
+// Swap.
+//
+//	<Signature>
+//	<PublicKey>
+//	<Secret>
+//	1 (True)
+//	<InitiateScript>
+func NewRedeemWitness(initiateScript, sig, pubkey []byte, secret [32]byte) wire.TxWitness {
+	return wire.TxWitness{sig, pubkey, secret[:], []byte{1}, initiateScript}
+}
+
+// NewRefundWitness creates the witness that refunds a P2WSH Bitcoin Atomic
+// Swap.
+//
+//	<Signature>
+//	<PublicKey>
+//	<> (False)
+//	<InitiateScript>
+func NewRefundWitness(initiateScript, sig, pubkey []byte) wire.TxWitness {
+	return wire.TxWitness{sig, pubkey, []byte{}, initiateScript}
+}
+
+// ScriptAddress returns the address that the initiate script is funded at,
+// which is a bech32 address for P2WSH scripts.
+func ScriptAddress(initiateScript []byte, scriptType swap.ScriptType, Net *chaincfg.Params) (btcutil.Address, error) {
+	switch scriptType {
+	case swap.ScriptTypeP2WSH:
+		scriptHash := sha256.Sum256(initiateScript)
+		return btcutil.NewAddressWitnessScriptHash(scriptHash[:], Net)
+	case "", swap.ScriptTypeP2SH:
+		return btcutil.NewAddressScriptHash(initiateScript, Net)
+	default:
+		return nil, fmt.Errorf("unsupported script type: %s", scriptType)
+	}
+}
@@ -0,0 +1,151 @@
+package utxo_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/renproject/swapperd/foundation/swap"
+	"golang.org/x/crypto/ripemd160"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/binder/utxo"
+)
+
+var _ = Describe("Atomic swap scripts", func() {
+	secret := [32]byte{}
+	copy(secret[:], bytes.Repeat([]byte{0x01}, 32))
+	secretHash := sha256.Sum256(secret[:])
+	lockTime := int64(1500000000)
+
+	pkh := func(b byte) *[ripemd160.Size]byte {
+		hash := [ripemd160.Size]byte{}
+		copy(hash[:], bytes.Repeat([]byte{b}, ripemd160.Size))
+		return &hash
+	}
+
+	initiateScript := func() []byte {
+		script, err := NewInitiateScript(pkh(0x11), pkh(0x22), lockTime, secretHash[:])
+		Expect(err).ShouldNot(HaveOccurred())
+		return script
+	}
+
+	Context("when building the initiate script", func() {
+		It("should build the known script", func() {
+			Expect(hex.EncodeToString(initiateScript())).Should(Equal(
+				"6382012088a82072cd6e8422c407fb6d098690f1130b7ded7ec2f7f5e1d30bd9d521f015363793" +
+					"8876a9142222222222222222222222222222222222222222" +
+					"6704002f6859b17576a9141111111111111111111111111111111111111111" +
+					"6888ac",
+			))
+		})
+	})
+
+	Context("when deriving the address of a script", func() {
+		It("should derive the P2WSH address of BIP 173", func() {
+			script, err := hex.DecodeString("210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798ac")
+			Expect(err).ShouldNot(HaveOccurred())
+			addr, err := ScriptAddress(script, swap.ScriptTypeP2WSH, &chaincfg.MainNetParams)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(addr.EncodeAddress()).Should(Equal("bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3"))
+			addr, err = ScriptAddress(script, swap.ScriptTypeP2WSH, &chaincfg.TestNet3Params)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(addr.EncodeAddress()).Should(Equal("tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"))
+		})
+
+		It("should derive the known addresses of the initiate script", func() {
+			addr, err := ScriptAddress(initiateScript(), swap.ScriptTypeP2WSH, &chaincfg.TestNet3Params)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(addr.EncodeAddress()).Should(Equal("tb1qvgh9y8gultdemzm7ctlkygsunuqsve3mdlzzhxkc8cxqv5pezftsvefs0y"))
+			addr, err = ScriptAddress(initiateScript(), swap.ScriptTypeP2SH, &chaincfg.TestNet3Params)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(addr.EncodeAddress()).Should(Equal("2MyNYu5XfTa4gvTftNggqzyxepfNPXZ9hsH"))
+			addr, err = ScriptAddress(initiateScript(), "", &chaincfg.MainNetParams)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(addr.EncodeAddress()).Should(Equal("37pLqLbdr7ZLig3LhZ4yP2yPcKADjZxXsD"))
+		})
+
+		It("should not derive the address of an unsupported script type", func() {
+			_, err := ScriptAddress(initiateScript(), "p2tr", &chaincfg.MainNetParams)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("when building the witnesses", func() {
+		sig, pubKey := make([]byte, 73), make([]byte, 33)
+
+		It("should build the redeem witness", func() {
+			witness := NewRedeemWitness(initiateScript(), sig, pubKey, secret)
+			Expect(witness).Should(Equal(wire.TxWitness{sig, pubKey, secret[:], []byte{1}, initiateScript()}))
+			Expect(witness.SerializeSize()).Should(Equal(242))
+			Expect(witness.SerializeSize()).Should(Equal(AtomicSwapRedeemWitnessSize + 1 + len(initiateScript())))
+		})
+
+		It("should build the refund witness", func() {
+			witness := NewRefundWitness(initiateScript(), sig, pubKey)
+			Expect(witness).Should(Equal(wire.TxWitness{sig, pubKey, []byte{}, initiateScript()}))
+			Expect(witness.SerializeSize()).Should(Equal(208))
+			Expect(witness.SerializeSize()).Should(Equal(AtomicSwapRefundWitnessSize + 1 + len(initiateScript())))
+		})
+	})
+
+	Context("when spending a P2WSH contract", func() {
+		key, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{0x02}, 32))
+		pubKey := key.PubKey().SerializeCompressed()
+		value := int64(100000)
+
+		// spend signs a transaction that spends the contract, and executes the
+		// public key script of the contract against its witness.
+		spend := func(txLockTime uint32, witness func(script, sig []byte) wire.TxWitness) error {
+			hash160 := pkh(0)
+			copy(hash160[:], btcutil.Hash160(pubKey))
+			script, err := NewInitiateScript(hash160, hash160, lockTime, secretHash[:])
+			Expect(err).ShouldNot(HaveOccurred())
+			addr, err := ScriptAddress(script, swap.ScriptTypeP2WSH, &chaincfg.TestNet3Params)
+			Expect(err).ShouldNot(HaveOccurred())
+			pkScript, err := txscript.PayToAddrScript(addr)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			tx := wire.NewMsgTx(2)
+			tx.LockTime = txLockTime
+			txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil)
+			txIn.Sequence = 0xfffffffe
+			tx.AddTxIn(txIn)
+			tx.AddTxOut(wire.NewTxOut(value-1000, pkScript))
+
+			sigHashes := txscript.NewTxSigHashes(tx)
+			sig, err := txscript.RawTxInWitnessSignature(tx, sigHashes, 0, value, script, txscript.SigHashAll, key)
+			Expect(err).ShouldNot(HaveOccurred())
+			tx.TxIn[0].Witness = witness(script, sig)
+
+			engine, err := txscript.NewEngine(pkScript, tx, 0, txscript.StandardVerifyFlags, nil, sigHashes, value)
+			Expect(err).ShouldNot(HaveOccurred())
+			return engine.Execute()
+		}
+
+		It("should redeem the contract with the secret", func() {
+			Expect(spend(0, func(script, sig []byte) wire.TxWitness {
+				return NewRedeemWitness(script, sig, pubKey, secret)
+			})).Should(Succeed())
+			Expect(spend(0, func(script, sig []byte) wire.TxWitness {
+				return NewRedeemWitness(script, sig, pubKey, [32]byte{})
+			})).ShouldNot(Succeed())
+		})
+
+		It("should refund the contract once it has expired", func() {
+			Expect(spend(uint32(lockTime), func(script, sig []byte) wire.TxWitness {
+				return NewRefundWitness(script, sig, pubKey)
+			})).Should(Succeed())
+			Expect(spend(uint32(lockTime-1), func(script, sig []byte) wire.TxWitness {
+				return NewRefundWitness(script, sig, pubKey)
+			})).ShouldNot(Succeed())
+		})
+	})
+})
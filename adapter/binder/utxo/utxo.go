@@ -0,0 +1,489 @@
+package utxo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/renproject/swapperd/adapter/binder/feebump"
+	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/sirupsen/logrus"
+)
+
+type utxoSwapContractBinder struct {
+	scriptAddr string
+	script     []byte
+	pkScript   []byte
+	swap       swap.Swap
+	cost       blockchain.Cost
+	details    swap.ContractDetails
+	account    *Account
+	tracker    *feebump.Tracker
+	logrus.FieldLogger
+}
+
+// NewUTXOSwapContractBinder returns a new swap contract binder of the chain
+// of the account. The spends of the swap contract are tracked by the tracker,
+// so that they are replaced if they are not confirmed in time.
+func NewUTXOSwapContractBinder(account *Account, swap swap.Swap, cost blockchain.Cost, tracker *feebump.Tracker, logger logrus.FieldLogger) (immediate.Contract, error) {
+	script, scriptAddr, err := buildInitiateScript(account, swap)
+	if err != nil {
+		return nil, err
+	}
+	encodedScriptAddr, err := account.Network.EncodeAddress(scriptAddr)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(scriptAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	token := account.Chain.Token.Name
+	logger = logger.WithFields(logrus.Fields{
+		"SwapID":     swap.ID,
+		"ContractID": encodedScriptAddr,
+		"Token":      token,
+	})
+
+	if _, ok := cost[token]; !ok {
+		cost[token] = big.NewInt(0)
+	}
+	if swap.BrokerFee.Int64() != 0 && swap.BrokerFee.Int64() < account.Chain.Dust {
+		swap.BrokerFee = big.NewInt(account.Chain.Dust)
+	}
+	swap.Value = new(big.Int).Add(swap.Value, swap.BrokerFee)
+
+	logger.Info(swap.ID, fmt.Sprintf("%s atomic swap = %s", token, encodedScriptAddr))
+	atom := &utxoSwapContractBinder{
+		scriptAddr:  encodedScriptAddr,
+		script:      script,
+		pkScript:    pkScript,
+		swap:        swap,
+		cost:        cost,
+		account:     account,
+		tracker:     tracker,
+		FieldLogger: logger,
+	}
+	atom.details.ContractID = encodedScriptAddr
+	return atom, nil
+}
+
+// Initiate the atomic swap by funding a HTLC.
+func (atom *utxoSwapContractBinder) Initiate() error {
+	atom.Info(fmt.Sprintf("Initiating on %s blockchain", atom.account.Chain.Token.Blockchain))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	funded, value, err := atom.account.ScriptFunded(ctx, atom.scriptAddr, atom.swap.Value.Int64())
+	if err != nil {
+		return NewErrInitiate(err)
+	}
+	if funded {
+		return nil
+	}
+	txHash, txFee, err := atom.account.Send(ctx, atom.pkScript, atom.swap.Value.Int64()-value, atom.swap.Speed, false)
+	if err != nil {
+		return NewErrInitiate(err)
+	}
+
+	token := atom.account.Chain.Token.Name
+	atom.cost[token] = new(big.Int).Add(big.NewInt(txFee), atom.cost[token])
+	atom.cost[token] = new(big.Int).Add(atom.swap.BrokerFee, atom.cost[token])
+	atom.details.InitiateTxHash = txHash
+	atom.Info(fmt.Sprintf("Initiated on %s blockchain: %s", atom.account.Chain.Token.Blockchain, txHash))
+	return nil
+}
+
+func (atom *utxoSwapContractBinder) Audit() error {
+	if funded, amount, err := atom.account.ScriptFunded(context.Background(), atom.scriptAddr, atom.swap.Value.Int64()); funded && err == nil {
+		value := new(big.Int).Sub(atom.swap.Value, atom.swap.BrokerFee)
+		if amount < value.Int64() {
+			return fmt.Errorf("Audit Failed")
+		}
+		return atom.auditFundingTx(value.Int64())
+	}
+
+	if time.Now().Unix() > atom.swap.TimeLock {
+		return immediate.ErrSwapExpired
+	}
+	return immediate.ErrAuditPending
+}
+
+// Redeem the Atomic Swap by revealing the secret and withdrawing funds from the
+// HTLC.
+func (atom *utxoSwapContractBinder) Redeem(secret [32]byte) error {
+	atom.Info(fmt.Sprintf("Redeeming on %s blockchain", atom.account.Chain.Token.Blockchain))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	outputs := []*wire.TxOut{}
+	if atom.swap.BrokerFee.Int64() >= atom.account.Chain.Dust {
+		feeScript, err := atom.payToAddrScript(atom.swap.BrokerAddress)
+		if err != nil {
+			return NewErrRedeem(err)
+		}
+		outputs = append(outputs, wire.NewTxOut(atom.swap.BrokerFee.Int64(), feeScript))
+	}
+	withdrawScript, err := atom.payToAddrScript(atom.swap.WithdrawAddress)
+	if err != nil {
+		return NewErrRedeem(err)
+	}
+	outputs = append(outputs, wire.NewTxOut(0, withdrawScript))
+
+	sequence := uint32(wire.MaxTxInSequenceNum)
+	if atom.account.Chain.Replaceable {
+		sequence = ReplaceableSequence
+	}
+	key := feebump.Key(atom.details.ContractID, "redeem")
+	tx, err := atom.sendSpendTransaction(ctx, key, outputs, 0, sequence, func(sig, pubKey []byte) ([][]byte, error) {
+		return [][]byte{sig, pubKey, secret[:], {1}}, nil
+	})
+	if err == immediate.ErrSpendPending {
+		return err
+	}
+	if err != nil {
+		// The contract may have been redeemed by a previous attempt
+		if err := atom.verifySpent(ctx, err); err != nil {
+			return NewErrRedeem(err)
+		}
+		atom.tracker.Forget(key)
+		return nil
+	}
+	token := atom.account.Chain.Token.Name
+	atom.cost[token] = new(big.Int).Add(tx.Fee, atom.cost[token])
+	atom.details.RedeemTxHash = tx.Hash
+	atom.Info(fmt.Sprintf("Redeemed on %s blockchain: %s", atom.account.Chain.Token.Blockchain, tx.Hash))
+	return nil
+}
+
+func (atom *utxoSwapContractBinder) AuditSecret() ([32]byte, error) {
+	atom.Info(fmt.Sprintf("Auditing secret on %s blockchain", atom.account.Chain.Token.Blockchain))
+	spent, pushes, err := atom.account.ScriptSpent(context.Background(), atom.scriptAddr)
+	if !spent || err != nil {
+		if time.Now().Unix() > atom.swap.TimeLock {
+			return [32]byte{}, immediate.ErrSwapExpired
+		}
+		return [32]byte{}, immediate.ErrAuditPending
+	}
+	secret, err := atom.extractSecret(pushes)
+	if err != nil {
+		// A spend that does not reveal the secret after the timelock is a
+		// refund, which is finished by refunding again.
+		if time.Now().Unix() > atom.swap.TimeLock {
+			return [32]byte{}, immediate.ErrSwapExpired
+		}
+		return [32]byte{}, err
+	}
+	atom.Info(fmt.Sprintf("Audit succeeded on %s blockchain secret = %s", atom.account.Chain.Token.Blockchain, base64.StdEncoding.EncodeToString(secret[:])))
+	return secret, nil
+}
+
+// PendingSecret returns the secret revealed by the transaction that spends the
+// script address, which is found as soon as it is in the mempool.
+func (atom *utxoSwapContractBinder) PendingSecret() ([32]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	spent, pushes, err := atom.account.ScriptSpent(ctx, atom.scriptAddr)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if !spent {
+		return [32]byte{}, immediate.ErrAuditPending
+	}
+	return atom.extractSecret(pushes)
+}
+
+// Refund the Atomic Swap after expiry and withdraw funds from the HTLC.
+func (atom *utxoSwapContractBinder) Refund() error {
+	atom.Info(fmt.Sprintf("Refunding on %s blockchain", atom.account.Chain.Token.Blockchain))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	address, err := atom.account.Address()
+	if err != nil {
+		return NewErrRefund(err)
+	}
+	refundScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return NewErrRefund(err)
+	}
+
+	key := feebump.Key(atom.details.ContractID, "refund")
+	tx, err := atom.sendSpendTransaction(ctx, key, []*wire.TxOut{wire.NewTxOut(0, refundScript)}, uint32(atom.swap.TimeLock), 0, func(sig, pubKey []byte) ([][]byte, error) {
+		return [][]byte{sig, pubKey, {}}, nil
+	})
+	if err == immediate.ErrSpendPending {
+		return err
+	}
+	if err != nil {
+		if err := atom.verifySpent(ctx, err); err != nil {
+			return NewErrRefund(err)
+		}
+		atom.tracker.Forget(key)
+		return nil
+	}
+	token := atom.account.Chain.Token.Name
+	atom.cost[token] = new(big.Int).Add(tx.Fee, atom.cost[token])
+	atom.cost[token] = new(big.Int).Sub(atom.cost[token], atom.swap.BrokerFee)
+	atom.details.RefundTxHash = tx.Hash
+	atom.Info(fmt.Sprintf("Refunded on %s blockchain: %s", atom.account.Chain.Token.Blockchain, tx.Hash))
+	return nil
+}
+
+func (atom *utxoSwapContractBinder) Cost() blockchain.Cost {
+	return atom.cost
+}
+
+func (atom *utxoSwapContractBinder) Details() swap.ContractDetails {
+	return atom.details
+}
+
+// verifySpent returns nil if the error of a spend is that the swap contract
+// has no unspent outputs, because they have already been spent. Contracts that
+// have never been funded cannot be spent.
+func (atom *utxoSwapContractBinder) verifySpent(ctx context.Context, err error) error {
+	if err != ErrNotFunded {
+		return err
+	}
+	spent, _, err := atom.account.ScriptRedeemed(ctx, atom.scriptAddr, 0)
+	if err != nil {
+		return err
+	}
+	if !spent {
+		return ErrNotFunded
+	}
+	return nil
+}
+
+// spend spends every unspent output of the swap contract to the outputs, at
+// the fee rate of the speed, and returns the transaction. The value of the
+// last output is the value of the contract, minus the other outputs and the
+// fee. A transaction that replaces the pending transaction spends the same
+// outputs, and pays at least its ReplacementFee. The unlock function returns
+// the data, that is pushed before the initiate script, to unlock the contract
+// with the signature.
+func (atom *utxoSwapContractBinder) spend(ctx context.Context, speed blockchain.TxExecutionSpeed, pending *feebump.PendingTx, outputs []*wire.TxOut, lockTime, sequence uint32, unlock func(sig, pubKey []byte) ([][]byte, error)) (feebump.PendingTx, error) {
+	inputs := []feebump.Input{}
+	if pending != nil && len(pending.Inputs) > 0 {
+		inputs = pending.Inputs
+	} else {
+		utxos, err := atom.account.UTXOs(ctx, atom.scriptAddr)
+		if err != nil {
+			return feebump.PendingTx{}, err
+		}
+		for _, utxo := range utxos {
+			inputs = append(inputs, feebump.Input{TxHash: utxo.TxHash, Vout: utxo.Vout, Value: utxo.Value})
+		}
+	}
+	if len(inputs) == 0 {
+		return feebump.PendingTx{}, ErrNotFunded
+	}
+
+	tx := wire.NewMsgTx(2)
+	tx.LockTime = lockTime
+	value := int64(0)
+	for _, input := range inputs {
+		hash, err := chainhash.NewHashFromStr(input.TxHash)
+		if err != nil {
+			return feebump.PendingTx{}, err
+		}
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, input.Vout), nil, nil)
+		txIn.Sequence = sequence
+		tx.AddTxIn(txIn)
+		value += input.Value
+	}
+	for _, output := range outputs[:len(outputs)-1] {
+		value -= output.Value
+	}
+	for _, output := range outputs {
+		tx.AddTxOut(wire.NewTxOut(output.Value, output.PkScript))
+	}
+
+	// Estimate the fee using an unlocking script of the largest signature.
+	witness := witnessScript(atom.swap.ScriptType)
+	dummy, err := unlock(make([]byte, 73), make([]byte, 33))
+	if err != nil {
+		return feebump.PendingTx{}, err
+	}
+	size, err := spendSize(tx, dummy, atom.script, witness)
+	if err != nil {
+		return feebump.PendingTx{}, NewErrSignTransaction(err)
+	}
+	fee := feebump.ReplacementFee(atom.account.Chain.FeeRate(speed)*size, pending, MinRelayFeeRate, size)
+	if value-fee < atom.account.Chain.Dust {
+		return feebump.PendingTx{}, fmt.Errorf("cannot pay a fee of %d from %d", fee, value)
+	}
+	tx.TxOut[len(tx.TxOut)-1].Value = value - fee
+
+	pubKey := atom.account.publicKey()
+	for i, input := range inputs {
+		sig, err := atom.account.Sign(tx, i, atom.script, input.Value, witness)
+		if err != nil {
+			return feebump.PendingTx{}, NewErrSignTransaction(err)
+		}
+		data, err := unlock(sig, pubKey)
+		if err != nil {
+			return feebump.PendingTx{}, err
+		}
+		if witness {
+			tx.TxIn[i].Witness = append(wire.TxWitness(data), atom.script)
+			continue
+		}
+		builder := txscript.NewScriptBuilder()
+		for _, push := range data {
+			builder.AddData(push)
+		}
+		builder.AddData(atom.script)
+		if tx.TxIn[i].SignatureScript, err = builder.Script(); err != nil {
+			return feebump.PendingTx{}, NewErrSignTransaction(err)
+		}
+	}
+
+	broadcast := time.Now()
+	txHash, err := atom.account.PublishTx(ctx, tx)
+	if err != nil {
+		return feebump.PendingTx{}, NewErrPublishTransaction(err)
+	}
+	return feebump.PendingTx{
+		Hash:      txHash,
+		Fee:       big.NewInt(fee),
+		Speed:     speed,
+		Broadcast: broadcast,
+		Inputs:    inputs,
+	}, nil
+}
+
+// spendSize returns the virtual size, in bytes, of the transaction once each
+// of its inputs is unlocked by the data, followed by the initiate script.
+func spendSize(tx *wire.MsgTx, data [][]byte, script []byte, witness bool) (int64, error) {
+	if witness {
+		witnessSize := append(wire.TxWitness(data), script).SerializeSize()
+		weight := tx.SerializeSizeStripped()*4 + 2 + len(tx.TxIn)*witnessSize
+		return int64((weight + 3) / 4), nil
+	}
+	builder := txscript.NewScriptBuilder()
+	for _, push := range data {
+		builder.AddData(push)
+	}
+	builder.AddData(script)
+	sigScript, err := builder.Script()
+	if err != nil {
+		return 0, err
+	}
+	// The signature scripts replace the empty scripts of the inputs, that are
+	// already serialized as a single byte.
+	sigScriptSize := wire.VarIntSerializeSize(uint64(len(sigScript))) + len(sigScript) - 1
+	return int64(tx.SerializeSize() + len(tx.TxIn)*sigScriptSize), nil
+}
+
+// extractSecret extracts the secret from the data pushed by the signature
+// script, or the witness, of the transaction that redeemed the script address.
+func (atom *utxoSwapContractBinder) extractSecret(pushes [][]byte) ([32]byte, error) {
+	for _, push := range pushes {
+		if sha256.Sum256(push) == atom.swap.SecretHash {
+			var secret [32]byte
+			copy(secret[:], push)
+			return secret, nil
+		}
+	}
+	return [32]byte{}, NewErrAuditSecret(ErrMalformedRedeemTx)
+}
+
+// auditFundingTx records the hash of the least confirmed transaction that
+// funded the value of the script address, and its number of confirmations. It
+// returns ErrAuditPending until every transaction that is needed to fund the
+// value has the required number of confirmations. A contract that has been
+// funded and then spent passes the audit.
+func (atom *utxoSwapContractBinder) auditFundingTx(value int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	utxos, err := atom.account.UTXOs(ctx, atom.scriptAddr)
+	if err != nil || len(utxos) == 0 {
+		// The contract may have been funded and then spent, by a redeem or a
+		// refund that was broadcast by a previous attempt.
+		if spent, _, spentErr := atom.account.ScriptRedeemed(ctx, atom.scriptAddr, value); spentErr == nil && spent {
+			return nil
+		}
+		if atom.swap.Confirmations > 0 {
+			return fmt.Errorf("cannot find the funding transaction of %s: %v", atom.scriptAddr, err)
+		}
+		atom.Warn(fmt.Sprintf("Failed to find the funding transaction of %s: %v", atom.scriptAddr, err))
+		return nil
+	}
+
+	confirmations, txHash, err := FundingConfirmations(ctx, atom.account.Confirmations, utxos, value)
+	if err != nil {
+		if atom.swap.Confirmations > 0 {
+			return fmt.Errorf("cannot get the confirmations of %s: %v", txHash, err)
+		}
+		atom.Warn(fmt.Sprintf("Failed to get the confirmations of %s: %v", txHash, err))
+		return nil
+	}
+	atom.details.AuditTxHash = txHash
+	atom.details.AuditConfirmations = confirmations
+	if confirmations < atom.swap.Confirmations {
+		atom.Info(fmt.Sprintf("Waiting for %d confirmations on %s blockchain, got %d", atom.swap.Confirmations, atom.account.Chain.Token.Blockchain, confirmations))
+		if time.Now().Unix() > atom.swap.TimeLock {
+			return immediate.ErrSwapExpired
+		}
+		return immediate.ErrAuditPending
+	}
+	return nil
+}
+
+func (atom *utxoSwapContractBinder) payToAddrScript(address string) ([]byte, error) {
+	addr, err := atom.account.Network.DecodeAddress(address)
+	if err != nil {
+		return nil, NewErrDecodeAddress(address, err)
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+func buildInitiateScript(account *Account, swap swap.Swap) ([]byte, btcutil.Address, error) {
+	if witnessScript(swap.ScriptType) && !account.Chain.Segwit {
+		return nil, nil, fmt.Errorf("%s does not support %s scripts", account.Chain.Token.Blockchain, swap.ScriptType)
+	}
+	fundingAddr, err := pubKeyHash(account.Network, swap.FundingAddress)
+	if err != nil {
+		return nil, nil, NewErrDecodeAddress(swap.FundingAddress, err)
+	}
+	spendingAddr, err := pubKeyHash(account.Network, swap.SpendingAddress)
+	if err != nil {
+		return nil, nil, NewErrDecodeAddress(swap.SpendingAddress, err)
+	}
+	initiateScript, err := NewInitiateScript(fundingAddr.Hash160(), spendingAddr.Hash160(), swap.TimeLock, swap.SecretHash[:])
+	if err != nil {
+		return nil, nil, NewErrBuildScript(err)
+	}
+	scriptAddr, err := ScriptAddress(initiateScript, swap.ScriptType, account.Network.Params)
+	if err != nil {
+		return nil, nil, NewErrBuildScript(err)
+	}
+	return initiateScript, scriptAddr, nil
+}
+
+func witnessScript(scriptType swap.ScriptType) bool {
+	return scriptType == swap.ScriptTypeP2WSH
+}
+
+func pubKeyHash(network Network, address string) (*btcutil.AddressPubKeyHash, error) {
+	addr, err := network.DecodeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	pkh, ok := addr.(*btcutil.AddressPubKeyHash)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a p2pkh address", address)
+	}
+	return pkh, nil
+}
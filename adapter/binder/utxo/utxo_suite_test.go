@@ -0,0 +1,13 @@
+package utxo_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestUtxo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Utxo Suite")
+}
@@ -0,0 +1,412 @@
+package utxo_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/renproject/swapperd/adapter/binder/feebump"
+	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ripemd160"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/binder/utxo"
+)
+
+// mockBackend is a Backend of a single swap contract. Its outputs are spent
+// once a transaction is published, by the first transaction that is
+// published, and transactions are confirmed once the given number of them
+// have been published.
+type mockBackend struct {
+	mu        *sync.Mutex
+	utxos     []UTXO
+	published []*wire.MsgTx
+	confirmAt int
+}
+
+func newMockBackend(confirmAt int, utxos ...UTXO) *mockBackend {
+	return &mockBackend{mu: new(sync.Mutex), utxos: utxos, confirmAt: confirmAt}
+}
+
+func (backend *mockBackend) funded() int64 {
+	funded := int64(0)
+	for _, utxo := range backend.utxos {
+		funded += utxo.Value
+	}
+	return funded
+}
+
+func (backend *mockBackend) Balance(ctx context.Context, address string) (int64, error) {
+	return 0, nil
+}
+
+func (backend *mockBackend) ScriptFunded(ctx context.Context, address string, value int64) (bool, int64, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	funded := backend.funded()
+	return funded > 0 && funded >= value, funded, nil
+}
+
+func (backend *mockBackend) ScriptRedeemed(ctx context.Context, address string, value int64) (bool, int64, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	funded := backend.funded()
+	if len(backend.published) == 0 {
+		return false, funded, nil
+	}
+	return funded > 0 && funded >= value, 0, nil
+}
+
+func (backend *mockBackend) ScriptSpent(ctx context.Context, address string) (bool, [][]byte, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if len(backend.published) == 0 {
+		return false, nil, nil
+	}
+	txIn := backend.published[0].TxIn[0]
+	if len(txIn.Witness) > 0 {
+		return true, txIn.Witness, nil
+	}
+	pushes, err := txscript.PushedData(txIn.SignatureScript)
+	return true, pushes, err
+}
+
+func (backend *mockBackend) UTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if len(backend.published) > 0 {
+		return []UTXO{}, nil
+	}
+	return backend.utxos, nil
+}
+
+func (backend *mockBackend) Confirmations(ctx context.Context, txHash string) (int64, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if len(backend.published) < backend.confirmAt {
+		return 0, nil
+	}
+	if backend.published[backend.confirmAt-1].TxHash().String() != txHash {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (backend *mockBackend) Publish(ctx context.Context, txHex string) (string, error) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		return "", err
+	}
+	tx := wire.NewMsgTx(2)
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return "", err
+	}
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	backend.published = append(backend.published, tx)
+	return tx.TxHash().String(), nil
+}
+
+func (backend *mockBackend) Published() []*wire.MsgTx {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	return backend.published
+}
+
+var _ = Describe("UTXO swap contract binder", func() {
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{0x03}, 32))
+	pubKey := key.PubKey().SerializeCompressed()
+	secret := [32]byte{}
+	copy(secret[:], bytes.Repeat([]byte{0x04}, 32))
+	secretHash := sha256.Sum256(secret[:])
+	timeLock := time.Now().Unix() - 60
+	fundingTx := "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+	var windows feebump.Windows
+	var tracker *feebump.Tracker
+	var confirmations int64
+
+	BeforeEach(func() {
+		windows = BumpWindows
+		tracker = feebump.NewTracker()
+		confirmations = 0
+	})
+
+	AfterEach(func() {
+		BumpWindows = windows
+	})
+
+	// newBinder returns the binder of a swap between the account and itself,
+	// and the initiate script of the swap.
+	newBinder := func(chain Chain, scriptType swap.ScriptType, backend Backend) (immediate.Contract, []byte) {
+		account, err := NewAccount(chain, "testnet", backend, key.ToECDSA())
+		Expect(err).ShouldNot(HaveOccurred())
+		address, err := account.EncodedAddress()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		pkh := [ripemd160.Size]byte{}
+		addr, err := account.Address()
+		Expect(err).ShouldNot(HaveOccurred())
+		copy(pkh[:], addr.ScriptAddress())
+		script, err := NewInitiateScript(&pkh, &pkh, timeLock, secretHash[:])
+		Expect(err).ShouldNot(HaveOccurred())
+
+		binder, err := NewUTXOSwapContractBinder(account, swap.Swap{
+			ID:              "swap",
+			Token:           chain.Token,
+			Value:           big.NewInt(90000),
+			BrokerFee:       big.NewInt(0),
+			SecretHash:      secretHash,
+			TimeLock:        timeLock,
+			SpendingAddress: address,
+			FundingAddress:  address,
+			WithdrawAddress: address,
+			Speed:           blockchain.Fast,
+			ScriptType:      scriptType,
+			Confirmations:   confirmations,
+		}, blockchain.Cost{}, tracker, logrus.StandardLogger())
+		Expect(err).ShouldNot(HaveOccurred())
+		return binder, script
+	}
+
+	// pkScriptOf returns the public key script of the swap contract.
+	pkScriptOf := func(chain Chain, scriptType swap.ScriptType, script []byte) []byte {
+		network, err := chain.Network("testnet")
+		Expect(err).ShouldNot(HaveOccurred())
+		addr, err := ScriptAddress(script, scriptType, network.Params)
+		Expect(err).ShouldNot(HaveOccurred())
+		pkScript, err := txscript.PayToAddrScript(addr)
+		Expect(err).ShouldNot(HaveOccurred())
+		return pkScript
+	}
+
+	// execute runs the public key script of the contract against every input
+	// of the transaction.
+	execute := func(tx *wire.MsgTx, pkScript []byte, value int64) {
+		sigHashes := txscript.NewTxSigHashes(tx)
+		for i := range tx.TxIn {
+			engine, err := txscript.NewEngine(pkScript, tx, i, txscript.StandardVerifyFlags, nil, sigHashes, value)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(engine.Execute()).Should(Succeed())
+		}
+	}
+
+	Context("when redeeming a P2WSH contract", func() {
+		It("should sign the witness and succeed once the transaction is confirmed", func() {
+			backend := newMockBackend(1, UTXO{TxHash: fundingTx, Vout: 0, Value: 50000}, UTXO{TxHash: fundingTx, Vout: 1, Value: 40000})
+			binder, script := newBinder(Bitcoin, swap.ScriptTypeP2WSH, backend)
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+			Expect(binder.Redeem(secret)).Should(Succeed())
+
+			Expect(backend.Published()).Should(HaveLen(1))
+			tx := backend.Published()[0]
+			Expect(tx.TxIn).Should(HaveLen(2))
+			Expect(tx.TxIn[0].Witness).Should(HaveLen(5))
+			Expect(tx.TxIn[0].Witness[2]).Should(Equal(secret[:]))
+			Expect(tx.TxIn[0].Sequence).Should(Equal(uint32(ReplaceableSequence)))
+			Expect(tx.TxOut).Should(HaveLen(1))
+			fee := 90000 - tx.TxOut[0].Value
+			Expect(binder.Cost()[Bitcoin.Token.Name].Int64()).Should(Equal(fee))
+			Expect(fee).Should(BeNumerically(">=", Bitcoin.FeeRate(blockchain.Fast)*int64((tx.SerializeSizeStripped()*3+tx.SerializeSize())/4)))
+
+			// Each input is signed for its own value, so the witness is
+			// checked against the value of the first input only.
+			sigHashes := txscript.NewTxSigHashes(tx)
+			engine, err := txscript.NewEngine(pkScriptOf(Bitcoin, swap.ScriptTypeP2WSH, script), tx, 0, txscript.StandardVerifyFlags, nil, sigHashes, 50000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(engine.Execute()).Should(Succeed())
+			engine, err = txscript.NewEngine(pkScriptOf(Bitcoin, swap.ScriptTypeP2WSH, script), tx, 1, txscript.StandardVerifyFlags, nil, sigHashes, 40000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(engine.Execute()).Should(Succeed())
+		})
+
+		It("should replace the transaction with a higher fee if it is not confirmed in time", func() {
+			BumpWindows = feebump.Windows{
+				blockchain.Slow:     50 * time.Millisecond,
+				blockchain.Standard: 50 * time.Millisecond,
+				blockchain.Fast:     50 * time.Millisecond,
+			}
+
+			backend := newMockBackend(2, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, script := newBinder(Bitcoin, swap.ScriptTypeP2WSH, backend)
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+			Expect(backend.Published()).Should(HaveLen(1))
+
+			// The transaction is replaced by a later attempt, once its window
+			// has passed.
+			time.Sleep(100 * time.Millisecond)
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+			Expect(binder.Redeem(secret)).Should(Succeed())
+
+			Expect(backend.Published()).Should(HaveLen(2))
+			pending, replacement := backend.Published()[0], backend.Published()[1]
+			Expect(replacement.TxIn[0].PreviousOutPoint).Should(Equal(pending.TxIn[0].PreviousOutPoint))
+			size := int64((replacement.SerializeSizeStripped()*3 + replacement.SerializeSize()) / 4)
+			Expect(pending.TxOut[0].Value - replacement.TxOut[0].Value).Should(BeNumerically(">=", MinRelayFeeRate*size))
+			Expect(binder.Cost()[Bitcoin.Token.Name].Int64()).Should(Equal(90000 - replacement.TxOut[0].Value))
+			execute(replacement, pkScriptOf(Bitcoin, swap.ScriptTypeP2WSH, script), 90000)
+		})
+	})
+
+	Context("when refunding a P2SH contract", func() {
+		It("should sign the signature script after the timelock", func() {
+			backend := newMockBackend(1, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, script := newBinder(Litecoin, swap.ScriptTypeP2SH, backend)
+			Expect(binder.Refund()).Should(Equal(immediate.ErrSpendPending))
+
+			Expect(backend.Published()).Should(HaveLen(1))
+			tx := backend.Published()[0]
+			Expect(tx.LockTime).Should(Equal(uint32(timeLock)))
+			Expect(tx.TxIn[0].Sequence).Should(Equal(uint32(0)))
+			execute(tx, pkScriptOf(Litecoin, swap.ScriptTypeP2SH, script), 90000)
+		})
+	})
+
+	Context("when redeeming a contract on a fork id chain", func() {
+		It("should sign with the fork id signature hash", func() {
+			backend := newMockBackend(1, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, script := newBinder(BitcoinCash, swap.ScriptTypeP2SH, backend)
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+
+			Expect(backend.Published()).Should(HaveLen(1))
+			tx := backend.Published()[0]
+			Expect(tx.TxIn[0].Sequence).Should(Equal(uint32(wire.MaxTxInSequenceNum)))
+			pushes, err := txscript.PushedData(tx.TxIn[0].SignatureScript)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pushes).Should(HaveLen(5))
+			Expect(pushes[1]).Should(Equal(pubKey))
+			Expect(pushes[2]).Should(Equal(secret[:]))
+			Expect(pushes[4]).Should(Equal(script))
+
+			sig := pushes[0]
+			hashType := txscript.SigHashAll | SigHashForkID
+			Expect(sig[len(sig)-1]).Should(Equal(byte(hashType)))
+			hash, err := txscript.CalcWitnessSigHash(script, txscript.NewTxSigHashes(tx), hashType, tx, 0, 90000)
+			Expect(err).ShouldNot(HaveOccurred())
+			signature, err := btcec.ParseDERSignature(sig[:len(sig)-1], btcec.S256())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(signature.Verify(hash, key.PubKey())).Should(BeTrue())
+		})
+
+		It("should not replace the transaction", func() {
+			BumpWindows = feebump.Windows{
+				blockchain.Slow:     50 * time.Millisecond,
+				blockchain.Standard: 50 * time.Millisecond,
+				blockchain.Fast:     50 * time.Millisecond,
+			}
+
+			backend := newMockBackend(2, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, _ := newBinder(BitcoinCash, swap.ScriptTypeP2SH, backend)
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+			time.Sleep(100 * time.Millisecond)
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+			Expect(backend.Published()).Should(HaveLen(1))
+		})
+	})
+
+	Context("when estimating the fee of a spend", func() {
+		// fee returns the fee paid by the spend of a single output of the
+		// contract.
+		fee := func(scriptType swap.ScriptType, spend func(binder immediate.Contract) error) (int64, *wire.MsgTx) {
+			backend := newMockBackend(1, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, _ := newBinder(Bitcoin, scriptType, backend)
+			Expect(spend(binder)).Should(Equal(immediate.ErrSpendPending))
+			Expect(backend.Published()).Should(HaveLen(1))
+			tx := backend.Published()[0]
+			return 90000 - tx.TxOut[0].Value, tx
+		}
+		redeem := func(binder immediate.Contract) error { return binder.Redeem(secret) }
+		refund := func(binder immediate.Contract) error { return binder.Refund() }
+
+		It("should pay for the discounted size of a P2WSH spend", func() {
+			feeRate := Bitcoin.FeeRate(blockchain.Fast)
+			redeemFee, tx := fee(swap.ScriptTypeP2WSH, redeem)
+			Expect(redeemFee).Should(Equal(146 * feeRate))
+			weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+			Expect(redeemFee / feeRate).Should(BeNumerically(">=", (weight+3)/4))
+			Expect(redeemFee / feeRate).Should(BeNumerically("<=", (weight+3)/4+2))
+
+			refundFee, _ := fee(swap.ScriptTypeP2WSH, refund)
+			Expect(refundFee).Should(Equal(138 * feeRate))
+		})
+
+		It("should pay for the signature script of a P2SH spend", func() {
+			feeRate := Bitcoin.FeeRate(blockchain.Fast)
+			redeemFee, tx := fee(swap.ScriptTypeP2SH, redeem)
+			Expect(redeemFee).Should(Equal(326 * feeRate))
+			Expect(redeemFee / feeRate).Should(BeNumerically(">=", tx.SerializeSize()))
+			Expect(redeemFee / feeRate).Should(BeNumerically("<=", tx.SerializeSize()+2))
+
+			refundFee, _ := fee(swap.ScriptTypeP2SH, refund)
+			Expect(refundFee).Should(Equal(293 * feeRate))
+		})
+
+		It("should not estimate a redeem to cost less than it pays", func() {
+			Expect(int64(WitnessRedeemTxSize)).Should(BeNumerically(">=", 146))
+			Expect(int64(RedeemTxSize)).Should(BeNumerically(">=", 326))
+			Expect(EstimateCost(Bitcoin, swap.Swap{ScriptType: swap.ScriptTypeP2WSH, Speed: blockchain.Fast}, false)).Should(Equal(blockchain.Cost{
+				Bitcoin.Token.Name: big.NewInt(WitnessRedeemTxSize * Bitcoin.FeeRate(blockchain.Fast)),
+			}))
+		})
+	})
+
+	Context("when the contract has no unspent outputs", func() {
+		It("should succeed if the contract has been spent", func() {
+			backend := newMockBackend(1, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, _ := newBinder(Bitcoin, swap.ScriptTypeP2WSH, backend)
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+
+			// A later attempt, after the transaction is no longer tracked,
+			// finds the contract spent.
+			tracker = feebump.NewTracker()
+			binder, _ = newBinder(Bitcoin, swap.ScriptTypeP2WSH, backend)
+			Expect(binder.Redeem(secret)).Should(Succeed())
+			Expect(backend.Published()).Should(HaveLen(1))
+		})
+
+		It("should pass the audit if the contract has been funded and then spent", func() {
+			confirmations = 6
+			backend := newMockBackend(1, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, _ := newBinder(Bitcoin, swap.ScriptTypeP2WSH, backend)
+			Expect(binder.Audit()).ShouldNot(Succeed())
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+
+			binder, _ = newBinder(Bitcoin, swap.ScriptTypeP2WSH, backend)
+			Expect(binder.Audit()).Should(Succeed())
+		})
+
+		It("should audit the secret of a redeem", func() {
+			backend := newMockBackend(1, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, _ := newBinder(Bitcoin, swap.ScriptTypeP2WSH, backend)
+			Expect(binder.Redeem(secret)).Should(Equal(immediate.ErrSpendPending))
+			Expect(binder.AuditSecret()).Should(Equal(secret))
+		})
+
+		It("should return that the swap has expired if the contract has been refunded", func() {
+			backend := newMockBackend(1, UTXO{TxHash: fundingTx, Vout: 0, Value: 90000})
+			binder, _ := newBinder(Bitcoin, swap.ScriptTypeP2WSH, backend)
+			Expect(binder.Refund()).Should(Equal(immediate.ErrSpendPending))
+			_, err := binder.AuditSecret()
+			Expect(err).Should(Equal(immediate.ErrSwapExpired))
+			Expect(binder.Refund()).Should(Succeed())
+		})
+
+		It("should fail if the contract has never been funded", func() {
+			binder, _ := newBinder(Bitcoin, swap.ScriptTypeP2WSH, newMockBackend(1))
+			Expect(binder.Redeem(secret)).ShouldNot(Succeed())
+			Expect(binder.Refund()).ShouldNot(Succeed())
+		})
+	})
+})
@@ -2,40 +2,76 @@ package callback
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/big"
 	"net/http"
+	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/renproject/swapperd/adapter/wallet"
 	"github.com/renproject/swapperd/core/wallet/swapper/delayed"
 	"github.com/renproject/swapperd/foundation/swap"
+	"golang.org/x/crypto/sha3"
 )
 
+// SignatureHeader holds the base64 encoded signature of the body of callback
+// requests and responses, over its sha3 hash. Requests are signed by the
+// swapperd id, and responses by the broker public key of the swap.
+const SignatureHeader = "Swapperd-Signature"
+
+// PublicKeyHeader holds the base64 encoded public key of the swapperd id that
+// signed a callback request.
+const PublicKeyHeader = "Swapperd-Public-Key"
+
+// A Signer loads the ECDSA key that signs the callback requests of the swaps
+// of a password.
+type Signer interface {
+	ECDSASigner(password string) (wallet.ECDSASigner, error)
+}
+
 type cb struct {
+	signer Signer
 }
 
-func New() delayed.DelayCallback {
-	return &cb{}
+func New(signer Signer) delayed.DelayCallback {
+	return &cb{signer}
 }
 
-func (cb *cb) DelayCallback(partialSwap swap.SwapBlob) (swap.SwapBlob, error) {
+func (cb *cb) DelayCallback(partialSwap swap.SwapBlob, password string) (swap.SwapBlob, error) {
 	data, err := json.MarshalIndent(partialSwap, "", "  ")
 	if err != nil {
 		return partialSwap, err
 	}
-	buf := bytes.NewBuffer(data)
 
-	resp, err := http.Post(fmt.Sprintf(partialSwap.DelayCallbackURL), "application/json", buf)
+	req, err := http.NewRequest("POST", partialSwap.DelayCallbackURL, bytes.NewBuffer(data))
+	if err != nil {
+		return partialSwap, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := cb.signRequest(req, password, data); err != nil {
+		return partialSwap, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return partialSwap, err
 	}
+	defer resp.Body.Close()
 
 	respBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return partialSwap, err
 	}
 
+	if partialSwap.BrokerPublicKey != "" {
+		if err := verifyResponse(partialSwap.BrokerPublicKey, resp.Header.Get(SignatureHeader), respBytes); err != nil {
+			return partialSwap, err
+		}
+	}
+
 	switch resp.StatusCode {
 	case http.StatusOK:
 		filledSwap := swap.SwapBlob{}
@@ -52,7 +88,73 @@ func (cb *cb) DelayCallback(partialSwap swap.SwapBlob) (swap.SwapBlob, error) {
 	}
 }
 
+// signRequest signs the body of a callback request with the swapperd id of
+// the password, so that the broker can authenticate it.
+func (cb *cb) signRequest(req *http.Request, password string, body []byte) error {
+	signer, err := cb.signer.ECDSASigner(password)
+	if err != nil {
+		return fmt.Errorf("unable to load ecdsa signer: %v", err)
+	}
+	hash := sha3.Sum256(body)
+	sig, err := signer.Sign(hash[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign callback request: %v", err)
+	}
+	pubKey := signer.PublicKey()
+	req.Header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(sig))
+	req.Header.Set(PublicKeyHeader, base64.StdEncoding.EncodeToString(crypto.FromECDSAPub(&pubKey)))
+	return nil
+}
+
+// verifyResponse checks that the body of a callback response was signed by the
+// broker public key.
+func verifyResponse(brokerPublicKey, signature string, body []byte) error {
+	if signature == "" {
+		return fmt.Errorf("callback response is not signed by the broker")
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid callback response signature: %v", err)
+	}
+	if err := wallet.VerifySignature(brokerPublicKey, body, sig); err != nil {
+		return fmt.Errorf("invalid callback response signature: %v", err)
+	}
+	return nil
+}
+
+// DelayFill verifies a filled swap pushed by the broker. The message has to be
+// signed by the broker public key of the partial swap, and the filled swap is
+// held to the same price and amount checks as the swaps returned by the
+// callback.
+func (cb *cb) DelayFill(partialSwap swap.SwapBlob, message, signature []byte) (swap.SwapBlob, error) {
+	if partialSwap.BrokerPublicKey == "" {
+		return partialSwap, fmt.Errorf("swap does not have a broker public key")
+	}
+	if err := wallet.VerifySignature(partialSwap.BrokerPublicKey, message, signature); err != nil {
+		return partialSwap, err
+	}
+
+	filledSwap := swap.SwapBlob{}
+	if err := json.Unmarshal(message, &filledSwap); err != nil {
+		return partialSwap, err
+	}
+	if filledSwap.ID != partialSwap.ID {
+		return partialSwap, fmt.Errorf("filled swap id %s does not match %s", filledSwap.ID, partialSwap.ID)
+	}
+	return verifyDelaySwap(partialSwap, filledSwap)
+}
+
+// verifyDelaySwap checks that the broker filled the swap at a price that is
+// at least as good as the price of the partial swap, without changing the
+// details that were checked when the partial swap was posted.
 func verifyDelaySwap(partialSwap, filledSwap swap.SwapBlob) (swap.SwapBlob, error) {
+	if filledSwap.SendToken != partialSwap.SendToken || filledSwap.ReceiveToken != partialSwap.ReceiveToken {
+		return partialSwap, fmt.Errorf("invalid filled swap token pair %s/%s, expected %s/%s", filledSwap.SendToken, filledSwap.ReceiveToken, partialSwap.SendToken, partialSwap.ReceiveToken)
+	}
+	if filledSwap.WithdrawAddress != partialSwap.WithdrawAddress {
+		return partialSwap, fmt.Errorf("invalid filled swap withdraw address %s, expected %s", filledSwap.WithdrawAddress, partialSwap.WithdrawAddress)
+	}
+
 	initialMinReceiveValue, ok := new(big.Int).SetString(partialSwap.MinimumReceiveAmount, 10)
 	if !ok {
 		initialMinReceiveValue = big.NewInt(0)
@@ -86,6 +188,37 @@ func verifyDelaySwap(partialSwap, filledSwap swap.SwapBlob) (swap.SwapBlob, erro
 		return partialSwap, fmt.Errorf("invalid filled swap unfavorable price")
 	}
 
-	filledSwap.Delay = false
-	return filledSwap, nil
+	return fillDelaySwap(partialSwap, filledSwap)
+}
+
+// fillDelaySwap copies the details negotiated by the broker onto the partial
+// swap: the amounts, the addresses of the counterparty and, when the
+// counterparty initiates, the secret hash and timelock of its contract. Every
+// other detail of the partial swap is kept.
+func fillDelaySwap(partialSwap, filledSwap swap.SwapBlob) (swap.SwapBlob, error) {
+	blob := partialSwap
+	blob.Delay = false
+	blob.SendAmount = filledSwap.SendAmount
+	blob.ReceiveAmount = filledSwap.ReceiveAmount
+	blob.SendTo = filledSwap.SendTo
+	blob.ReceiveFrom = filledSwap.ReceiveFrom
+	if partialSwap.ShouldInitiateFirst {
+		return blob, nil
+	}
+
+	blob.SecretHash = filledSwap.SecretHash
+	blob.TimeLock = filledSwap.TimeLock
+	blob.TimeLockMargin = filledSwap.TimeLockMargin
+	blob.BitcoinScriptType = filledSwap.BitcoinScriptType
+
+	// The timelock of the counterparty is held to the margin that the partial
+	// swap was posted with.
+	margin := partialSwap.TimeLockMargin
+	if gap := blob.TimeLock - blob.ResponderTimeLock(); gap < margin {
+		return partialSwap, fmt.Errorf("invalid filled swap timelock margin of %d seconds, the minimum is %d seconds", gap, margin)
+	}
+	if time.Now().Unix()+margin > blob.ResponderTimeLock() {
+		return partialSwap, fmt.Errorf("invalid filled swap timelock, not enough time to do the atomic swap")
+	}
+	return blob, nil
 }
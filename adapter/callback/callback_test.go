@@ -1,10 +1,12 @@
 package callback_test
 
 import (
+	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"time"
@@ -14,9 +16,13 @@ import (
 	. "github.com/renproject/swapperd/adapter/callback"
 	"github.com/renproject/tokens"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gorilla/mux"
+	"github.com/renproject/swapperd/adapter/wallet"
 	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/swapperd/testutils"
 	"github.com/rs/cors"
+	"golang.org/x/crypto/sha3"
 )
 
 var _ = Describe("Server Adapter", func() {
@@ -158,7 +164,7 @@ var _ = Describe("Server Adapter", func() {
 
 				if !swap.ShouldInitiateFirst {
 					swap.SecretHash = randomString()
-					swap.TimeLock = time.Now().Unix()
+					swap.TimeLock = time.Now().Add(48 * time.Hour).Unix()
 				}
 				swap.SendTo = fmt.Sprintf("Address:%s", swap.SendToken)
 				swap.ReceiveFrom = fmt.Sprintf("Address:%s", swap.ReceiveToken)
@@ -174,8 +180,8 @@ var _ = Describe("Server Adapter", func() {
 		for _, pendingSwap := range partialSwaps {
 			It(fmt.Sprintf("verification should succeed, and delay should be set to false"), func() {
 				pendingSwap.DelayCallbackURL = "http://127.0.0.1:17777/swaps"
-				swapFiller := New()
-				filledSwap, err := swapFiller.DelayCallback(pendingSwap)
+				swapFiller := New(testutils.NewMockSigner())
+				filledSwap, err := swapFiller.DelayCallback(pendingSwap, "password")
 				Expect(err).Should(BeNil())
 				Expect(filledSwap.Delay).Should(BeFalse())
 			})
@@ -205,7 +211,7 @@ var _ = Describe("Server Adapter", func() {
 
 				if !swap.ShouldInitiateFirst {
 					swap.SecretHash = randomString()
-					swap.TimeLock = time.Now().Unix()
+					swap.TimeLock = time.Now().Add(48 * time.Hour).Unix()
 				}
 				swap.SendTo = fmt.Sprintf("Address:%s", swap.SendToken)
 				swap.ReceiveFrom = fmt.Sprintf("Address:%s", swap.ReceiveToken)
@@ -220,8 +226,8 @@ var _ = Describe("Server Adapter", func() {
 		for _, pendingSwap := range partialSwaps {
 			It(fmt.Sprintf("verification should succeed, and delay should be set to false %v", pendingSwap), func() {
 				pendingSwap.DelayCallbackURL = "http://127.0.0.1:17778/swaps"
-				swapFiller := New()
-				filledSwap, err := swapFiller.DelayCallback(pendingSwap)
+				swapFiller := New(testutils.NewMockSigner())
+				filledSwap, err := swapFiller.DelayCallback(pendingSwap, "password")
 				Expect(err).Should(BeNil())
 				Expect(filledSwap.Delay).Should(BeFalse())
 			})
@@ -250,7 +256,7 @@ var _ = Describe("Server Adapter", func() {
 
 				if !swap.ShouldInitiateFirst {
 					swap.SecretHash = randomString()
-					swap.TimeLock = time.Now().Unix()
+					swap.TimeLock = time.Now().Add(48 * time.Hour).Unix()
 				}
 				swap.SendTo = fmt.Sprintf("Address:%s", swap.SendToken)
 				swap.ReceiveFrom = fmt.Sprintf("Address:%s", swap.ReceiveToken)
@@ -265,11 +271,226 @@ var _ = Describe("Server Adapter", func() {
 		for _, pendingSwap := range partialSwaps {
 			It(fmt.Sprintf("verification should fail %v", pendingSwap), func() {
 				pendingSwap.DelayCallbackURL = "http://127.0.0.1:17779/swaps"
-				swapFiller := New()
-				_, err := swapFiller.DelayCallback(pendingSwap)
+				swapFiller := New(testutils.NewMockSigner())
+				_, err := swapFiller.DelayCallback(pendingSwap, "password")
 				Expect(err).ShouldNot(BeNil())
 			})
 		}
 		close(doneCh)
 	})
+
+	Context("when the broker pushes a filled swap", func() {
+		brokerKey, err := crypto.GenerateKey()
+		if err != nil {
+			panic(err)
+		}
+		brokerPublicKey := base64.StdEncoding.EncodeToString(crypto.FromECDSAPub(&brokerKey.PublicKey))
+
+		signFill := func(filledSwap swap.SwapBlob, key *ecdsa.PrivateKey) ([]byte, []byte) {
+			message, err := json.Marshal(filledSwap)
+			Expect(err).Should(BeNil())
+			hash := sha3.Sum256(message)
+			signature, err := crypto.Sign(hash[:], key)
+			Expect(err).Should(BeNil())
+			return message, signature
+		}
+
+		// fill returns an honest fill of the partial swap.
+		fill := func(partialSwap swap.SwapBlob) swap.SwapBlob {
+			filledSwap := partialSwap
+			if !filledSwap.ShouldInitiateFirst {
+				filledSwap.SecretHash = randomString()
+				filledSwap.TimeLock = time.Now().Add(48 * time.Hour).Unix()
+			}
+			filledSwap.SendTo = fmt.Sprintf("Address:%s", filledSwap.SendToken)
+			filledSwap.ReceiveFrom = fmt.Sprintf("Address:%s", filledSwap.ReceiveToken)
+			return filledSwap
+		}
+
+		partialSwap := partialSwaps[0]
+		partialSwap.ShouldInitiateFirst = false
+		partialSwap.BrokerPublicKey = brokerPublicKey
+		partialSwap.WithdrawAddress = "Address:Withdraw"
+		partialSwap.BrokerFee = 20
+		partialSwap.BrokerReceiveTokenAddr = "Address:Broker"
+		partialSwap.ResponseURL = "http://127.0.0.1:17781/swaps"
+
+		It("should only take the negotiated details from the fill", func() {
+			filledSwap := fill(partialSwap)
+			filledSwap.BrokerFee = 0
+			filledSwap.BrokerReceiveTokenAddr = "Address:Other"
+			filledSwap.ResponseURL = "http://127.0.0.1:17782/swaps"
+			message, signature := signFill(filledSwap, brokerKey)
+
+			blob, err := New(testutils.NewMockSigner()).DelayFill(partialSwap, message, signature)
+			Expect(err).Should(BeNil())
+			Expect(blob.SendTo).Should(Equal(filledSwap.SendTo))
+			Expect(blob.ReceiveFrom).Should(Equal(filledSwap.ReceiveFrom))
+			Expect(blob.SecretHash).Should(Equal(filledSwap.SecretHash))
+			Expect(blob.TimeLock).Should(Equal(filledSwap.TimeLock))
+			Expect(blob.WithdrawAddress).Should(Equal(partialSwap.WithdrawAddress))
+			Expect(blob.BrokerFee).Should(Equal(partialSwap.BrokerFee))
+			Expect(blob.BrokerReceiveTokenAddr).Should(Equal(partialSwap.BrokerReceiveTokenAddr))
+			Expect(blob.ResponseURL).Should(Equal(partialSwap.ResponseURL))
+		})
+
+		It("verification should fail for a fill that changes the withdraw address", func() {
+			filledSwap := fill(partialSwap)
+			filledSwap.WithdrawAddress = "Address:Broker"
+			message, signature := signFill(filledSwap, brokerKey)
+
+			_, err := New(testutils.NewMockSigner()).DelayFill(partialSwap, message, signature)
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		It("verification should fail for a fill that changes the token pair", func() {
+			filledSwap := fill(partialSwap)
+			filledSwap.ReceiveToken = filledSwap.SendToken
+			message, signature := signFill(filledSwap, brokerKey)
+
+			_, err := New(testutils.NewMockSigner()).DelayFill(partialSwap, message, signature)
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		It("verification should fail for a fill with a timelock that expires too soon", func() {
+			filledSwap := fill(partialSwap)
+			filledSwap.TimeLock = time.Now().Unix()
+			message, signature := signFill(filledSwap, brokerKey)
+
+			_, err := New(testutils.NewMockSigner()).DelayFill(partialSwap, message, signature)
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		for _, pendingSwap := range partialSwaps {
+			It(fmt.Sprintf("verification should succeed for a fill signed by the broker %v", pendingSwap), func() {
+				pendingSwap.BrokerPublicKey = brokerPublicKey
+				filledSwap := fill(pendingSwap)
+				message, signature := signFill(filledSwap, brokerKey)
+
+				filledSwap, err := New(testutils.NewMockSigner()).DelayFill(pendingSwap, message, signature)
+				Expect(err).Should(BeNil())
+				Expect(filledSwap.Delay).Should(BeFalse())
+			})
+
+			It(fmt.Sprintf("verification should fail for a malicious fill signed by the broker %v", pendingSwap), func() {
+				pendingSwap.BrokerPublicKey = brokerPublicKey
+				filledSwap := pendingSwap
+				filledSwap.SendAmount = pendingSwap.SendAmount + "0"
+				message, signature := signFill(filledSwap, brokerKey)
+
+				_, err := New(testutils.NewMockSigner()).DelayFill(pendingSwap, message, signature)
+				Expect(err).ShouldNot(BeNil())
+			})
+
+			It(fmt.Sprintf("verification should fail for a fill that is not signed by the broker %v", pendingSwap), func() {
+				otherKey, err := crypto.GenerateKey()
+				Expect(err).Should(BeNil())
+				pendingSwap.BrokerPublicKey = brokerPublicKey
+				message, signature := signFill(pendingSwap, otherKey)
+
+				_, err = New(testutils.NewMockSigner()).DelayFill(pendingSwap, message, signature)
+				Expect(err).ShouldNot(BeNil())
+			})
+		}
+	})
+
+	Context("when the callback requests and responses are signed", func() {
+		signer := testutils.NewMockSigner()
+		broker := testutils.NewMockSigner()
+		brokerPublicKey := base64.StdEncoding.EncodeToString(crypto.FromECDSAPub(&broker.Key.PublicKey))
+		requestPublicKey := base64.StdEncoding.EncodeToString(crypto.FromECDSAPub(&signer.Key.PublicKey))
+
+		doneCh := make(chan struct{})
+		go startTestServer(func() http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("cannot read swap request: %v", err))
+					return
+				}
+				if r.Header.Get(PublicKeyHeader) != requestPublicKey {
+					writeError(w, http.StatusUnauthorized, "unexpected public key")
+					return
+				}
+				sig, err := base64.StdEncoding.DecodeString(r.Header.Get(SignatureHeader))
+				if err != nil {
+					writeError(w, http.StatusUnauthorized, fmt.Sprintf("invalid signature: %v", err))
+					return
+				}
+				if err := wallet.VerifySignature(requestPublicKey, body, sig); err != nil {
+					writeError(w, http.StatusUnauthorized, err.Error())
+					return
+				}
+
+				swap := swap.SwapBlob{}
+				if err := json.Unmarshal(body, &swap); err != nil {
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("cannot decode swap request: %v", err))
+					return
+				}
+				if !swap.ShouldInitiateFirst {
+					swap.SecretHash = randomString()
+					swap.TimeLock = time.Now().Add(48 * time.Hour).Unix()
+				}
+				swap.SendTo = fmt.Sprintf("Address:%s", swap.SendToken)
+				swap.ReceiveFrom = fmt.Sprintf("Address:%s", swap.ReceiveToken)
+				respBytes, err := json.Marshal(swap)
+				if err != nil {
+					writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot encode swap response: %v", err))
+					return
+				}
+
+				// Only swaps with delay info at index zero are signed by the
+				// broker.
+				testDelayInfo := TestDelayInfo{}
+				if err := json.Unmarshal(swap.DelayInfo, &testDelayInfo); err != nil {
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse delay info: %v", err))
+					return
+				}
+				if testDelayInfo.Index == 0 {
+					hash := sha3.Sum256(respBytes)
+					respSig, err := crypto.Sign(hash[:], broker.Key)
+					if err != nil {
+						writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot sign swap response: %v", err))
+						return
+					}
+					w.Header().Set(SignatureHeader, base64.StdEncoding.EncodeToString(respSig))
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write(respBytes)
+			}
+		}, doneCh, 17780)
+
+		for _, pendingSwap := range partialSwaps {
+			testDelayInfo := TestDelayInfo{}
+			if err := json.Unmarshal(pendingSwap.DelayInfo, &testDelayInfo); err != nil {
+				panic(err)
+			}
+
+			if testDelayInfo.Index == 0 {
+				It(fmt.Sprintf("verification should succeed for a response signed by the broker %v", pendingSwap), func() {
+					pendingSwap.DelayCallbackURL = "http://127.0.0.1:17780/swaps"
+					pendingSwap.BrokerPublicKey = brokerPublicKey
+					filledSwap, err := New(signer).DelayCallback(pendingSwap, "password")
+					Expect(err).Should(BeNil())
+					Expect(filledSwap.Delay).Should(BeFalse())
+				})
+				continue
+			}
+
+			It(fmt.Sprintf("verification should fail for a response that is not signed by the broker %v", pendingSwap), func() {
+				pendingSwap.DelayCallbackURL = "http://127.0.0.1:17780/swaps"
+				pendingSwap.BrokerPublicKey = brokerPublicKey
+				_, err := New(signer).DelayCallback(pendingSwap, "password")
+				Expect(err).ShouldNot(BeNil())
+			})
+
+			It(fmt.Sprintf("verification should succeed for swaps without a broker public key %v", pendingSwap), func() {
+				pendingSwap.DelayCallbackURL = "http://127.0.0.1:17780/swaps"
+				filledSwap, err := New(signer).DelayCallback(pendingSwap, "password")
+				Expect(err).Should(BeNil())
+				Expect(filledSwap.Delay).Should(BeFalse())
+			})
+		}
+		close(doneCh)
+	})
 })
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/renproject/swapperd/adapter/wallet"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	TableAddressBook      = [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08}
+	TableAddressBookStart = [40]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	TableAddressBookLimit = [40]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+func (db *dbStorage) PutAddressBookEntry(entry wallet.AddressBookEntry) error {
+	entryData, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	id, err := base64.StdEncoding.DecodeString(entry.ID)
+	if err != nil {
+		return err
+	}
+	return db.db.Put(append(TableAddressBook[:], id...), entryData, nil)
+}
+
+func (db *dbStorage) DeleteAddressBookEntry(entryID string) error {
+	id, err := base64.StdEncoding.DecodeString(entryID)
+	if err != nil {
+		return err
+	}
+	return db.db.Delete(append(TableAddressBook[:], id...), nil)
+}
+
+func (db *dbStorage) AddressBook() ([]wallet.AddressBookEntry, error) {
+	iterator := db.db.NewIterator(&util.Range{Start: TableAddressBookStart[:], Limit: TableAddressBookLimit[:]}, nil)
+	defer iterator.Release()
+	entries := []wallet.AddressBookEntry{}
+	for iterator.Next() {
+		value := iterator.Value()
+		entry := wallet.AddressBookEntry{}
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, iterator.Error()
+}
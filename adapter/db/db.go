@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 
+	"github.com/renproject/swapperd/adapter/wallet"
+	"github.com/renproject/swapperd/core/wallet/schedule"
 	"github.com/renproject/swapperd/core/wallet/transfer"
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
@@ -29,6 +31,8 @@ type Storage interface {
 	PutSwap(blob swap.SwapBlob) error
 	DeletePendingSwap(swapID swap.SwapID) error
 	PendingSwaps() ([]swap.SwapBlob, error)
+	PutSecret(swapID swap.SwapID, encryptedSecret string) error
+	Secret(swapID swap.SwapID) (string, error)
 
 	PutTransfer(transfer transfer.TransferReceipt) error
 	Transfers() ([]transfer.TransferReceipt, error)
@@ -39,7 +43,20 @@ type Storage interface {
 	UpdateReceipt(receiptUpdate swap.ReceiptUpdate) error
 	Receipts() ([]swap.SwapReceipt, error)
 	Receipt(swapID swap.SwapID) (swap.SwapReceipt, error)
+	SwapEvents(swapID swap.SwapID) ([]swap.SwapEvent, error)
 	LoadCosts(swapID swap.SwapID) (blockchain.Cost, blockchain.Cost)
+
+	PutSchedule(schedule schedule.Schedule) error
+	DeleteSchedule(scheduleID schedule.ScheduleID) error
+	Schedules() ([]schedule.Schedule, error)
+
+	PutOffer(offer swap.Offer) error
+	DeleteOffer(offerID swap.SwapID) error
+	Offers() ([]swap.Offer, error)
+
+	PutAddressBookEntry(entry wallet.AddressBookEntry) error
+	DeleteAddressBookEntry(entryID string) error
+	AddressBook() ([]wallet.AddressBookEntry, error)
 }
 
 type dbStorage struct {
@@ -1,6 +1,8 @@
 package db_test
 
 import (
+	"encoding/base64"
+	"errors"
 	"reflect"
 	"testing/quick"
 
@@ -8,8 +10,11 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/renproject/swapperd/adapter/db"
 
+	"github.com/renproject/swapperd/adapter/wallet"
+	"github.com/renproject/swapperd/core/wallet/schedule"
 	"github.com/renproject/swapperd/foundation/swap"
 	"github.com/renproject/swapperd/testutils"
+	"github.com/renproject/tokens"
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
@@ -26,11 +31,188 @@ var _ bool = Describe("DB", func() {
 				Expect(db.PutSwap(swap)).ShouldNot(HaveOccurred())
 				stored, err := db.PendingSwap(swap.ID)
 				swap.Password = ""
+				swap.Secret = [32]byte{}
 				Expect(err).ShouldNot(HaveOccurred())
 				return reflect.DeepEqual(swap, stored)
 			}
 
 			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
 		})
+
+		It("should record an event for every status transition", func() {
+			ldb, err := leveldb.OpenFile("./db-test", nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			db := New(ldb)
+			defer ldb.Close()
+
+			test := func(blob swap.SwapBlob, errString string) bool {
+				blob.DelayInfo = []byte("null")
+				receipt := swap.NewSwapReceipt(blob)
+				Expect(db.PutReceipt(receipt)).ShouldNot(HaveOccurred())
+
+				update := swap.NewReceiptUpdate(blob.ID, func(receipt *swap.SwapReceipt) {
+					receipt.Status = swap.Initiated
+				})
+				Expect(db.UpdateReceipt(update)).ShouldNot(HaveOccurred())
+
+				update = swap.NewReceiptUpdate(blob.ID, func(receipt *swap.SwapReceipt) {
+					receipt.Status = swap.AuditPending
+				})
+				update.Error = errors.New(errString)
+				Expect(db.UpdateReceipt(update)).ShouldNot(HaveOccurred())
+				Expect(db.UpdateReceipt(update)).ShouldNot(HaveOccurred())
+
+				events, err := db.SwapEvents(blob.ID)
+				Expect(err).ShouldNot(HaveOccurred())
+				return len(events) == 2 &&
+					events[0].OldStatus == swap.Inactive && events[0].NewStatus == swap.Initiated && events[0].Error == "" &&
+					events[1].OldStatus == swap.Initiated && events[1].NewStatus == swap.AuditPending && events[1].Error == errString
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should keep the events of a swap in order, apart from those of other swaps", func() {
+			ldb, err := leveldb.OpenFile("./db-test", nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			db := New(ldb)
+			defer ldb.Close()
+
+			test := func(blob, other swap.SwapBlob) bool {
+				blob.DelayInfo = []byte("null")
+				other.DelayInfo = []byte("null")
+				Expect(db.PutReceipt(swap.NewSwapReceipt(blob))).ShouldNot(HaveOccurred())
+				Expect(db.PutReceipt(swap.NewSwapReceipt(other))).ShouldNot(HaveOccurred())
+
+				statuses := []int{swap.Initiated, swap.Audited, swap.AuditPending}
+				for i := 0; i < 300; i++ {
+					status := statuses[i%len(statuses)]
+					Expect(db.UpdateReceipt(swap.NewReceiptUpdate(blob.ID, func(receipt *swap.SwapReceipt) {
+						receipt.Status = status
+					}))).ShouldNot(HaveOccurred())
+				}
+				Expect(db.UpdateReceipt(swap.NewReceiptUpdate(other.ID, func(receipt *swap.SwapReceipt) {
+					receipt.Status = swap.Initiated
+				}))).ShouldNot(HaveOccurred())
+
+				events, err := db.SwapEvents(blob.ID)
+				Expect(err).ShouldNot(HaveOccurred())
+				if len(events) != 300 {
+					return false
+				}
+				for i, event := range events {
+					if event.NewStatus != statuses[i%len(statuses)] {
+						return false
+					}
+				}
+				otherEvents, err := db.SwapEvents(other.ID)
+				Expect(err).ShouldNot(HaveOccurred())
+				return len(otherEvents) == 1 && otherEvents[0].NewStatus == swap.Initiated
+			}
+
+			Expect(quick.Check(test, &quick.Config{MaxCount: 5})).ShouldNot(HaveOccurred())
+		})
+
+		It("should store secrets encrypted under the password", func() {
+			ldb, err := leveldb.OpenFile("./db-test", nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			db := New(ldb)
+			defer ldb.Close()
+
+			test := func(id swap.SwapID, password string, secret [32]byte) bool {
+				encryptedSecret, err := swap.EncryptSecret(password, secret)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(db.PutSecret(id, encryptedSecret)).ShouldNot(HaveOccurred())
+
+				stored, err := db.Secret(id)
+				Expect(err).ShouldNot(HaveOccurred())
+				decrypted, err := swap.DecryptSecret(password, stored)
+				Expect(err).ShouldNot(HaveOccurred())
+				_, err = swap.DecryptSecret(password+"wrong", stored)
+				return decrypted == secret && err != nil
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should store schedules without their password, until they are deleted", func() {
+			ldb, err := leveldb.OpenFile("./db-test", nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			db := New(ldb)
+			defer ldb.Close()
+
+			test := func(id [32]byte, template swap.SwapBlob, interval int64, maxTotal string) bool {
+				template.DelayInfo = []byte("null")
+				stored := schedule.Schedule{
+					ID:       schedule.ScheduleID(base64.StdEncoding.EncodeToString(id[:])),
+					Template: template,
+					Interval: interval,
+					MaxTotal: maxTotal,
+				}
+				Expect(db.PutSchedule(stored)).ShouldNot(HaveOccurred())
+				stored.Template.Password = ""
+				stored.Template.Secret = [32]byte{}
+
+				found := false
+				schedules, err := db.Schedules()
+				Expect(err).ShouldNot(HaveOccurred())
+				for _, schedule := range schedules {
+					if schedule.ID == stored.ID {
+						found = reflect.DeepEqual(schedule, stored)
+					}
+				}
+
+				Expect(db.DeleteSchedule(stored.ID)).ShouldNot(HaveOccurred())
+				schedules, err = db.Schedules()
+				Expect(err).ShouldNot(HaveOccurred())
+				for _, schedule := range schedules {
+					if schedule.ID == stored.ID {
+						return false
+					}
+				}
+				return found
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should store address book entries until they are deleted", func() {
+			ldb, err := leveldb.OpenFile("./db-test", nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			db := New(ldb)
+			defer ldb.Close()
+
+			test := func(id [32]byte, address, label string, activeAt int64) bool {
+				stored := wallet.AddressBookEntry{
+					ID:         base64.StdEncoding.EncodeToString(id[:]),
+					Blockchain: tokens.BITCOIN,
+					Address:    address,
+					Label:      label,
+					ActiveAt:   activeAt,
+				}
+				Expect(db.PutAddressBookEntry(stored)).ShouldNot(HaveOccurred())
+
+				found := false
+				entries, err := db.AddressBook()
+				Expect(err).ShouldNot(HaveOccurred())
+				for _, entry := range entries {
+					if entry.ID == stored.ID {
+						found = reflect.DeepEqual(entry, stored)
+					}
+				}
+
+				Expect(db.DeleteAddressBookEntry(stored.ID)).ShouldNot(HaveOccurred())
+				entries, err = db.AddressBook()
+				Expect(err).ShouldNot(HaveOccurred())
+				for _, entry := range entries {
+					if entry.ID == stored.ID {
+						return false
+					}
+				}
+				return found
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
 	})
 })
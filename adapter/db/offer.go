@@ -0,0 +1,50 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	TableOffers      = [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x07}
+	TableOffersStart = [40]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	TableOffersLimit = [40]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x07, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+func (db *dbStorage) PutOffer(offer swap.Offer) error {
+	offerData, err := json.Marshal(offer)
+	if err != nil {
+		return err
+	}
+	id, err := base64.StdEncoding.DecodeString(string(offer.ID))
+	if err != nil {
+		return err
+	}
+	return db.db.Put(append(TableOffers[:], id...), offerData, nil)
+}
+
+func (db *dbStorage) DeleteOffer(offerID swap.SwapID) error {
+	id, err := base64.StdEncoding.DecodeString(string(offerID))
+	if err != nil {
+		return err
+	}
+	return db.db.Delete(append(TableOffers[:], id...), nil)
+}
+
+func (db *dbStorage) Offers() ([]swap.Offer, error) {
+	iterator := db.db.NewIterator(&util.Range{Start: TableOffersStart[:], Limit: TableOffersLimit[:]}, nil)
+	defer iterator.Release()
+	offers := []swap.Offer{}
+	for iterator.Next() {
+		value := iterator.Value()
+		offer := swap.Offer{}
+		if err := json.Unmarshal(value, &offer); err != nil {
+			return offers, err
+		}
+		offers = append(offers, offer)
+	}
+	return offers, iterator.Error()
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/renproject/swapperd/core/wallet/schedule"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	TableSchedules      = [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06}
+	TableSchedulesStart = [40]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	TableSchedulesLimit = [40]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+func (db *dbStorage) PutSchedule(schedule schedule.Schedule) error {
+	schedule.Template.Password = ""
+	scheduleData, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	id, err := base64.StdEncoding.DecodeString(string(schedule.ID))
+	if err != nil {
+		return err
+	}
+	return db.db.Put(append(TableSchedules[:], id...), scheduleData, nil)
+}
+
+func (db *dbStorage) DeleteSchedule(scheduleID schedule.ScheduleID) error {
+	id, err := base64.StdEncoding.DecodeString(string(scheduleID))
+	if err != nil {
+		return err
+	}
+	return db.db.Delete(append(TableSchedules[:], id...), nil)
+}
+
+func (db *dbStorage) Schedules() ([]schedule.Schedule, error) {
+	iterator := db.db.NewIterator(&util.Range{Start: TableSchedulesStart[:], Limit: TableSchedulesLimit[:]}, nil)
+	defer iterator.Release()
+	schedules := []schedule.Schedule{}
+	for iterator.Next() {
+		value := iterator.Value()
+		schedule := schedule.Schedule{}
+		if err := json.Unmarshal(value, &schedule); err != nil {
+			return schedules, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, iterator.Error()
+}
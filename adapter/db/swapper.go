@@ -2,13 +2,20 @@ package db
 
 import (
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
+var (
+	TableSwapEvents  = [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04}
+	TableSwapSecrets = [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05}
+)
+
 func (db *dbStorage) PutReceipt(receipt swap.SwapReceipt) error {
 	receiptData, err := json.Marshal(receipt)
 	if err != nil {
@@ -34,12 +41,102 @@ func (db *dbStorage) UpdateReceipt(receiptUpdate swap.ReceiptUpdate) error {
 	if err := json.Unmarshal(receiptBytes, &receipt); err != nil {
 		return err
 	}
+	oldStatus := receipt.Status
 	receiptUpdate.Update(&receipt)
 	updatedReceiptBytes, err := json.Marshal(receipt)
 	if err != nil {
 		return err
 	}
-	return db.db.Put(append(TableSwapReceipts[:], id...), updatedReceiptBytes, nil)
+	if err := db.db.Put(append(TableSwapReceipts[:], id...), updatedReceiptBytes, nil); err != nil {
+		return err
+	}
+	if oldStatus == receipt.Status && receiptUpdate.Error == nil {
+		return nil
+	}
+	return db.appendSwapEvent(id, swap.NewSwapEvent(oldStatus, receipt, receiptUpdate.Error))
+}
+
+func (db *dbStorage) SwapEvents(swapID swap.SwapID) ([]swap.SwapEvent, error) {
+	id, err := base64.StdEncoding.DecodeString(string(swapID))
+	if err != nil {
+		return nil, err
+	}
+	return db.swapEvents(id)
+}
+
+func (db *dbStorage) swapEvents(id []byte) ([]swap.SwapEvent, error) {
+	events := []swap.SwapEvent{}
+	iterator := db.db.NewIterator(util.BytesPrefix(swapEventsPrefix(id)), nil)
+	defer iterator.Release()
+	for iterator.Next() {
+		event := swap.SwapEvent{}
+		if err := json.Unmarshal(iterator.Value(), &event); err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+	return events, iterator.Error()
+}
+
+// appendSwapEvent adds the event to the event log of the swap, unless it
+// repeats the last event (for example, the same error on every retry). Each
+// event is stored under the id of the swap and its sequence number.
+func (db *dbStorage) appendSwapEvent(id []byte, event swap.SwapEvent) error {
+	prefix := swapEventsPrefix(id)
+	seq := uint64(0)
+	iterator := db.db.NewIterator(util.BytesPrefix(prefix), nil)
+	if iterator.Last() {
+		last := swap.SwapEvent{}
+		if err := json.Unmarshal(iterator.Value(), &last); err != nil {
+			iterator.Release()
+			return err
+		}
+		if event.OldStatus == event.NewStatus && last.NewStatus == event.NewStatus && last.Error == event.Error {
+			iterator.Release()
+			return nil
+		}
+		seq = binary.BigEndian.Uint64(iterator.Key()[len(prefix):]) + 1
+	}
+	iterator.Release()
+	if err := iterator.Error(); err != nil {
+		return err
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], seq)
+	return db.db.Put(key, eventBytes, nil)
+}
+
+func swapEventsPrefix(id []byte) []byte {
+	return append(append([]byte{}, TableSwapEvents[:]...), id...)
+}
+
+func (db *dbStorage) PutSecret(swapID swap.SwapID, encryptedSecret string) error {
+	id, err := base64.StdEncoding.DecodeString(string(swapID))
+	if err != nil {
+		return err
+	}
+	return db.db.Put(append(TableSwapSecrets[:], id...), []byte(encryptedSecret), nil)
+}
+
+func (db *dbStorage) Secret(swapID swap.SwapID) (string, error) {
+	id, err := base64.StdEncoding.DecodeString(string(swapID))
+	if err != nil {
+		return "", err
+	}
+	encryptedSecret, err := db.db.Get(append(TableSwapSecrets[:], id...), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return "", swap.ErrSecretNotFound
+		}
+		return "", err
+	}
+	return string(encryptedSecret), nil
 }
 
 func (db *dbStorage) Receipts() ([]swap.SwapReceipt, error) {
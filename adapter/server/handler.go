@@ -12,10 +12,12 @@ import (
 	"io/ioutil"
 	"math/big"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/renproject/swapperd/adapter/wallet"
 	coreWallet "github.com/renproject/swapperd/core/wallet"
+	"github.com/renproject/swapperd/core/wallet/schedule"
 	"github.com/renproject/swapperd/core/wallet/swapper"
 	"github.com/renproject/swapperd/core/wallet/transfer"
 	"github.com/renproject/swapperd/foundation/blockchain"
@@ -28,12 +30,43 @@ import (
 
 var ErrHandlerIsShuttingDown = fmt.Errorf("Http handler is shutting down")
 
+// ErrCannotGenerateSwapID is returned when the random id of a new swap cannot
+// be generated.
+var ErrCannotGenerateSwapID = fmt.Errorf("cannot generate swap id")
+
 type handler struct {
 	version    string
 	bootloaded map[string]bool
 	wallet     wallet.Wallet
+	estimator  SwapEstimator
 	storage    Storage
 	receiver   *Receiver
+
+	// peersMu guards the peers, which map the public keys of the peers that
+	// can offer swaps to the password that the offers are made to.
+	peersMu *sync.RWMutex
+	peers   map[string]offerPeer
+
+	// offersMu guards the stored offers, and the offers that are being
+	// accepted. Offers are accepted without holding offersMu, because
+	// accepting an offer posts its swap to the peer.
+	offersMu  *sync.Mutex
+	accepting map[swap.SwapID]bool
+
+	ledger *Ledger
+}
+
+// An offerPeer is a peer that can offer swaps to a password. Its offers are
+// accepted automatically, or stored until they are accepted or rejected.
+type offerPeer struct {
+	password   string
+	autoAccept bool
+}
+
+// The SwapEstimator validates the contracts of a swap, and estimates the cost
+// of each of its legs, without executing it.
+type SwapEstimator interface {
+	EstimateSwapCosts(blob swap.SwapBlob) (blockchain.Cost, blockchain.Cost, error)
 }
 
 // The Handler for swapperd requests
@@ -42,6 +75,7 @@ type Handler interface {
 	GetInfo(password string) GetInfoResponse
 	GetSwap(password string, id swap.SwapID) (GetSwapResponse, error)
 	GetSwaps(password string) (GetSwapsResponse, error)
+	GetSwapEvents(password string, id swap.SwapID) (GetSwapEventsResponse, error)
 	GetBalances(password string) (GetBalancesResponse, error)
 	GetBalance(password string, token tokens.Token) (GetBalanceResponse, error)
 	GetAddresses(password string) (GetAddressesResponse, error)
@@ -50,19 +84,60 @@ type Handler interface {
 	GetJSONSignature(password string, message json.RawMessage) (GetSignatureResponseJSON, error)
 	GetBase64Signature(password string, message string) (GetSignatureResponseString, error)
 	GetHexSignature(password string, message string) (GetSignatureResponseString, error)
+	VerifyJSONSignature(req PostVerifyRequestJSON) (PostVerifyResponse, error)
+	VerifyBase64Signature(req PostVerifyRequestString) (PostVerifyResponse, error)
+	VerifyHexSignature(req PostVerifyRequestString) (PostVerifyResponse, error)
 	PostTransfers(PostTransfersRequest) error
 	PostSwaps(PostSwapRequest) (PostSwapResponse, error)
 	PostDelayedSwaps(PostSwapRequest) error
+	PostSwapPreflight(PostSwapRequest) (PostSwapPreflightResponse, error)
+	PostSwapFill(id swap.SwapID, req PostSwapFillRequest) error
+	CancelSwap(password string, id swap.SwapID) error
+	GetSchedules(password string) (GetSchedulesResponse, error)
+	GetSchedule(password string, id schedule.ScheduleID) (GetScheduleResponse, error)
+	PostSchedule(password string, req PostScheduleRequest) (PostScheduleResponse, error)
+	UpdateSchedule(password string, id schedule.ScheduleID, req PostScheduleRequest) error
+	CancelSchedule(password string, id schedule.ScheduleID) error
+	PostOffer(req PostOfferRequest) (PostOfferResponse, error)
+	GetOffers(password string) (GetOffersResponse, error)
+	AcceptOffer(password string, id swap.SwapID) (PostSwapResponse, error)
+	RejectOffer(password string, id swap.SwapID) error
+	PutOfferPolicy(password string, req PutOfferPolicyRequest) error
+	GetAddressBook(password string) (GetAddressBookResponse, error)
+	PostAddressBookEntry(password string, req PostAddressBookRequest) (wallet.AddressBookEntry, error)
+	DeleteAddressBookEntry(password string, id string) error
 	Shutdown()
 }
 
-func NewHandler(cap int, version string, wallet wallet.Wallet, storage Storage, receiver *Receiver) Handler {
+func NewHandler(cap int, version string, wallet wallet.Wallet, estimator SwapEstimator, storage Storage, ledger *Ledger, receiver *Receiver) Handler {
 	return &handler{
 		version:    version,
 		bootloaded: map[string]bool{},
 		wallet:     wallet,
+		estimator:  estimator,
 		storage:    storage,
 		receiver:   receiver,
+		peersMu:    new(sync.RWMutex),
+		peers:      map[string]offerPeer{},
+		offersMu:   new(sync.Mutex),
+		accepting:  map[swap.SwapID]bool{},
+		ledger:     ledger,
+	}
+}
+
+// NewSwapBuilder returns a schedule.SwapBuilder that builds delayed swaps the
+// same way as the delayed swaps posted to swapperd, against the balances that
+// are not reserved in the ledger of the server.
+func NewSwapBuilder(wallet wallet.Wallet, storage Storage, ledger *Ledger) schedule.SwapBuilder {
+	return &handler{
+		bootloaded: map[string]bool{},
+		wallet:     wallet,
+		storage:    storage,
+		peersMu:    new(sync.RWMutex),
+		peers:      map[string]offerPeer{},
+		offersMu:   new(sync.Mutex),
+		accepting:  map[swap.SwapID]bool{},
+		ledger:     ledger,
 	}
 }
 
@@ -72,6 +147,7 @@ func (handler *handler) GetInfo(password string) GetInfoResponse {
 		Version:         handler.version,
 		Bootloaded:      handler.bootloaded[passwordHash(password)],
 		SupportedTokens: handler.wallet.SupportedTokens(),
+		TimeLockPolicy:  handler.wallet.TimeLockPolicy(),
 	}
 }
 
@@ -129,6 +205,574 @@ func (handler *handler) GetSwap(password string, id swap.SwapID) (GetSwapRespons
 	return GetSwapResponse(receipt), nil
 }
 
+func (handler *handler) GetSwapEvents(password string, id swap.SwapID) (GetSwapEventsResponse, error) {
+	handler.bootload(password)
+	if _, err := handler.getSwapReceipt(password, id); err != nil {
+		return GetSwapEventsResponse{}, err
+	}
+
+	events, err := handler.storage.SwapEvents(id)
+	if err != nil {
+		return GetSwapEventsResponse{}, err
+	}
+	return GetSwapEventsResponse{events}, nil
+}
+
+func (handler *handler) CancelSwap(password string, id swap.SwapID) error {
+	handler.bootload(password)
+	receipt, err := handler.getSwapReceipt(password, id)
+	if err != nil {
+		return err
+	}
+
+	switch receipt.Status {
+	case swap.Redeemed, swap.Refunded, swap.Cancelled, swap.Expired:
+		return fmt.Errorf("swap has already finished")
+	}
+	return handler.Write(swapper.CancelSwap{ID: id})
+}
+
+// PostSwapFill fills a delayed swap with a swap pushed by the broker. The
+// request is not authenticated with a password, the filled swap is only
+// accepted if it is signed by the broker public key of the delayed swap.
+func (handler *handler) PostSwapFill(id swap.SwapID, req PostSwapFillRequest) error {
+	signature, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	if len(req.Message) == 0 {
+		return fmt.Errorf("missing filled swap")
+	}
+
+	receipts, err := handler.storage.Receipts()
+	if err != nil {
+		return err
+	}
+	for _, receipt := range receipts {
+		if receipt.ID != id {
+			continue
+		}
+		if !receipt.Delay {
+			return fmt.Errorf("swap is not delayed")
+		}
+		switch receipt.Status {
+		case swap.Redeemed, swap.Refunded, swap.Cancelled, swap.Expired:
+			return fmt.Errorf("swap has already finished")
+		}
+		return handler.Write(swapper.FillSwap{ID: id, Message: req.Message, Signature: signature})
+	}
+	return fmt.Errorf("swap receipt not found")
+}
+
+func (handler *handler) GetSchedules(password string) (GetSchedulesResponse, error) {
+	handler.bootload(password)
+	schedules, err := handler.getSchedules(password)
+	if err != nil {
+		return GetSchedulesResponse{}, err
+	}
+	resp := GetSchedulesResponse{Schedules: []schedule.Schedule{}}
+	for _, schedule := range schedules {
+		schedule.Template.PasswordHash = ""
+		resp.Schedules = append(resp.Schedules, schedule)
+	}
+	return resp, nil
+}
+
+func (handler *handler) GetSchedule(password string, id schedule.ScheduleID) (GetScheduleResponse, error) {
+	handler.bootload(password)
+	schedule, err := handler.getSchedule(password, id)
+	if err != nil {
+		return GetScheduleResponse{}, err
+	}
+	schedule.Template.PasswordHash = ""
+	return GetScheduleResponse(schedule), nil
+}
+
+// PostSchedule registers a recurring delayed swap. The first swap is posted at
+// the start of the schedule, or on the next tick if it has no start.
+func (handler *handler) PostSchedule(password string, req PostScheduleRequest) (PostScheduleResponse, error) {
+	handler.bootload(password)
+	template, delayDuration, err := handler.patchScheduleTemplate(password, req)
+	if err != nil {
+		return PostScheduleResponse{}, err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return PostScheduleResponse{}, err
+	}
+	template.PasswordHash = base64.StdEncoding.EncodeToString(passwordHash)
+
+	scheduleID := [32]byte{}
+	if _, err := rand.Read(scheduleID[:]); err != nil {
+		return PostScheduleResponse{}, err
+	}
+	id := schedule.ScheduleID(base64.StdEncoding.EncodeToString(scheduleID[:]))
+	start := req.Start
+	if start == 0 {
+		start = time.Now().Unix()
+	}
+	if err := handler.Write(schedule.ScheduleRequest{
+		ID:            id,
+		Template:      template,
+		Interval:      req.Interval,
+		MaxTotal:      req.MaxTotal,
+		DelayDuration: delayDuration,
+		Sent:          "0",
+		NextSwap:      start,
+	}); err != nil {
+		return PostScheduleResponse{}, err
+	}
+	return PostScheduleResponse{ID: id}, nil
+}
+
+// UpdateSchedule replaces the template, interval and maximum total of a
+// schedule, keeping the swaps it has already posted.
+func (handler *handler) UpdateSchedule(password string, id schedule.ScheduleID, req PostScheduleRequest) error {
+	handler.bootload(password)
+	existing, err := handler.getSchedule(password, id)
+	if err != nil {
+		return err
+	}
+	if existing.Completed {
+		return fmt.Errorf("schedule has already completed")
+	}
+	template, delayDuration, err := handler.patchScheduleTemplate(password, req)
+	if err != nil {
+		return err
+	}
+	template.PasswordHash = existing.Template.PasswordHash
+	return handler.Write(schedule.UpdateSchedule{
+		ID:            id,
+		Template:      template,
+		Interval:      req.Interval,
+		MaxTotal:      req.MaxTotal,
+		DelayDuration: delayDuration,
+	})
+}
+
+func (handler *handler) CancelSchedule(password string, id schedule.ScheduleID) error {
+	handler.bootload(password)
+	if _, err := handler.getSchedule(password, id); err != nil {
+		return err
+	}
+	return handler.Write(schedule.CancelSchedule{ID: id})
+}
+
+// patchScheduleTemplate validates a schedule, and returns the template of its
+// swaps. The template is validated as a delayed swap, so the balance check only
+// covers the first swap. The delay deadline of the template is returned as
+// the number of seconds that each swap can be filled for, so that it can be
+// rebased onto the time that each swap is posted.
+func (handler *handler) patchScheduleTemplate(password string, req PostScheduleRequest) (swap.SwapBlob, int64, error) {
+	if req.Interval < schedule.MinInterval {
+		return swap.SwapBlob{}, 0, fmt.Errorf("interval must be at least %d seconds", schedule.MinInterval)
+	}
+	maxTotal, ok := new(big.Int).SetString(req.MaxTotal, 10)
+	if !ok || maxTotal.Sign() <= 0 {
+		return swap.SwapBlob{}, 0, fmt.Errorf("invalid max total")
+	}
+	sendAmount, ok := new(big.Int).SetString(req.Swap.SendAmount, 10)
+	if !ok || sendAmount.Sign() <= 0 {
+		return swap.SwapBlob{}, 0, fmt.Errorf("invalid send amount")
+	}
+	if sendAmount.Cmp(maxTotal) > 0 {
+		return swap.SwapBlob{}, 0, fmt.Errorf("send amount is above the max total")
+	}
+
+	template := swap.SwapBlob(req.Swap)
+	template.Password = password
+	template.Delay = true
+	template.Cancelled = false
+	if template.Speed == blockchain.Nil {
+		template.Speed = blockchain.Fast
+	}
+	if _, err := handler.patchDelayedSwap(template); err != nil {
+		return swap.SwapBlob{}, 0, err
+	}
+	delayDuration := int64(0)
+	if template.DelayDeadline != 0 {
+		delayDuration = template.DelayDeadline - time.Now().Unix()
+		template.DelayDeadline = 0
+	}
+	return template, delayDuration, nil
+}
+
+// PostOffer receives a swap offered by another swapperd. The offer has to be
+// signed by the id public key of the other swapperd, which has to be trusted
+// by the offer policy of a password. Offers from peers that are trusted to be
+// accepted automatically are accepted immediately, other offers are stored
+// until they are accepted or rejected by the password. A swap can only be
+// offered once.
+func (handler *handler) PostOffer(req PostOfferRequest) (PostOfferResponse, error) {
+	if req.PublicKey == "" {
+		return PostOfferResponse{}, fmt.Errorf("offer is not signed")
+	}
+	handler.peersMu.RLock()
+	peer, trusted := handler.peers[req.PublicKey]
+	handler.peersMu.RUnlock()
+	if !trusted {
+		return PostOfferResponse{}, fmt.Errorf("offer is not from a trusted peer")
+	}
+	req.Swap.CounterpartyPublicKey = req.PublicKey
+	req.Swap.Signature = req.Signature
+	if err := verifySwapSignature(req.Swap); err != nil {
+		return PostOfferResponse{}, err
+	}
+	if err := verifyOfferedSwap(req.Swap); err != nil {
+		return PostOfferResponse{}, err
+	}
+	if req.Swap.ResponderTimeLock() <= time.Now().Unix() {
+		return PostOfferResponse{}, fmt.Errorf("offer has expired")
+	}
+
+	offerID := [32]byte{}
+	if _, err := rand.Read(offerID[:]); err != nil {
+		return PostOfferResponse{}, err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(peer.password), bcrypt.DefaultCost)
+	if err != nil {
+		return PostOfferResponse{}, err
+	}
+	offer := swap.Offer{
+		ID:           swap.SwapID(base64.StdEncoding.EncodeToString(offerID[:])),
+		Swap:         req.Swap,
+		Signature:    req.Signature,
+		PublicKey:    req.PublicKey,
+		Timestamp:    time.Now().Unix(),
+		PasswordHash: base64.StdEncoding.EncodeToString(passwordHash),
+	}
+
+	// The offer is stored before it is accepted, so that the same swap
+	// cannot be offered again while it is being accepted.
+	handler.offersMu.Lock()
+	if err := handler.verifyNewOffer(offer); err != nil {
+		handler.offersMu.Unlock()
+		return PostOfferResponse{}, err
+	}
+	if err := handler.storage.PutOffer(offer); err != nil {
+		handler.offersMu.Unlock()
+		return PostOfferResponse{}, err
+	}
+	if !peer.autoAccept {
+		handler.offersMu.Unlock()
+		return PostOfferResponse{ID: offer.ID}, nil
+	}
+	handler.accepting[offer.ID] = true
+	handler.offersMu.Unlock()
+
+	swapResp, err := handler.acceptOffer(peer.password, offer)
+	if err := handler.finishAccepting(offer, err == nil); err != nil {
+		return PostOfferResponse{}, err
+	}
+	if err != nil {
+		return PostOfferResponse{ID: offer.ID, Error: err.Error()}, nil
+	}
+	return PostOfferResponse{ID: offer.ID, Accepted: true, SwapID: swapResp.ID}, nil
+}
+
+// GetOffers returns the offers, that are made to the password, which have not
+// been accepted and have not expired.
+func (handler *handler) GetOffers(password string) (GetOffersResponse, error) {
+	handler.bootload(password)
+	handler.offersMu.Lock()
+	defer handler.offersMu.Unlock()
+	offers, err := handler.getOffers(password)
+	if err != nil {
+		return GetOffersResponse{}, err
+	}
+	for i := range offers {
+		offers[i].PasswordHash = ""
+	}
+	return GetOffersResponse{Offers: offers}, nil
+}
+
+// AcceptOffer posts the swap of an offer as the responder.
+func (handler *handler) AcceptOffer(password string, id swap.SwapID) (PostSwapResponse, error) {
+	handler.bootload(password)
+	handler.offersMu.Lock()
+	offer, err := handler.getOffer(password, id)
+	if err != nil {
+		handler.offersMu.Unlock()
+		return PostSwapResponse{}, err
+	}
+	handler.accepting[offer.ID] = true
+	handler.offersMu.Unlock()
+
+	resp, err := handler.acceptOffer(password, offer)
+	if finishErr := handler.finishAccepting(offer, err == nil); err == nil {
+		err = finishErr
+	}
+	return resp, err
+}
+
+// finishAccepting records whether an offer that was being accepted has been
+// accepted.
+func (handler *handler) finishAccepting(offer swap.Offer, accepted bool) error {
+	handler.offersMu.Lock()
+	defer handler.offersMu.Unlock()
+	delete(handler.accepting, offer.ID)
+	if !accepted {
+		return nil
+	}
+	offer.Accepted = true
+	return handler.storage.PutOffer(offer)
+}
+
+func (handler *handler) RejectOffer(password string, id swap.SwapID) error {
+	handler.bootload(password)
+	handler.offersMu.Lock()
+	defer handler.offersMu.Unlock()
+	if _, err := handler.getOffer(password, id); err != nil {
+		return err
+	}
+	return handler.storage.DeleteOffer(id)
+}
+
+// PutOfferPolicy replaces the peers that can offer swaps to the password. The
+// offers of the peers are accepted automatically, and the offers of the
+// review peers are stored until the password accepts or rejects them. Offer
+// policies are only held in memory, and have to be put again after swapperd
+// restarts.
+func (handler *handler) PutOfferPolicy(password string, req PutOfferPolicyRequest) error {
+	handler.bootload(password)
+	for _, peer := range append(req.Peers, req.ReviewPeers...) {
+		pubKey, err := base64.StdEncoding.DecodeString(peer)
+		if err != nil || len(pubKey) == 0 {
+			return fmt.Errorf("invalid peer public key: %s", peer)
+		}
+	}
+
+	handler.peersMu.Lock()
+	defer handler.peersMu.Unlock()
+	for key, peer := range handler.peers {
+		if peer.password == password {
+			delete(handler.peers, key)
+		}
+	}
+	for _, key := range req.ReviewPeers {
+		handler.peers[key] = offerPeer{password: password}
+	}
+	for _, key := range req.Peers {
+		handler.peers[key] = offerPeer{password: password, autoAccept: true}
+	}
+	return nil
+}
+
+func (handler *handler) GetAddressBook(password string) (GetAddressBookResponse, error) {
+	handler.bootload(password)
+	entries, err := handler.getAddressBook(password)
+	if err != nil {
+		return GetAddressBookResponse{}, err
+	}
+	now := time.Now().Unix()
+	resp := GetAddressBookResponse{Entries: []AddressBookEntryResponse{}}
+	for _, entry := range entries {
+		entry.PasswordHash = ""
+		resp.Entries = append(resp.Entries, AddressBookEntryResponse{entry, entry.Active(now)})
+	}
+	return resp, nil
+}
+
+// PostAddressBookEntry adds an address to the address book of the password.
+// The address can only be withdrawn to once the delay of the address book has
+// passed.
+func (handler *handler) PostAddressBookEntry(password string, req PostAddressBookRequest) (wallet.AddressBookEntry, error) {
+	handler.bootload(password)
+	token, err := blockchain.PatchToken(req.Token)
+	if err != nil {
+		return wallet.AddressBookEntry{}, err
+	}
+	entry, err := handler.wallet.NewAddressBookEntry(token.Blockchain, req.Address, req.Label)
+	if err != nil {
+		return wallet.AddressBookEntry{}, err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return wallet.AddressBookEntry{}, err
+	}
+	entry.PasswordHash = base64.StdEncoding.EncodeToString(passwordHash)
+	if err := handler.storage.PutAddressBookEntry(entry); err != nil {
+		return wallet.AddressBookEntry{}, err
+	}
+	entry.PasswordHash = ""
+	return entry, nil
+}
+
+func (handler *handler) DeleteAddressBookEntry(password string, id string) error {
+	handler.bootload(password)
+	entries, err := handler.getAddressBook(password)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.ID == id {
+			return handler.storage.DeleteAddressBookEntry(id)
+		}
+	}
+	return fmt.Errorf("address book entry not found")
+}
+
+// getAddressBook returns the address book entries of the password.
+func (handler *handler) getAddressBook(password string) ([]wallet.AddressBookEntry, error) {
+	entries, err := handler.storage.AddressBook()
+	if err != nil {
+		return nil, err
+	}
+	passwordEntries := []wallet.AddressBookEntry{}
+	for _, entry := range entries {
+		if matchesPassword(entry.PasswordHash, password) {
+			passwordEntries = append(passwordEntries, entry)
+		}
+	}
+	return passwordEntries, nil
+}
+
+// verifyWithdrawAddress checks that funds can be withdrawn to the address,
+// when withdrawals are restricted to the address book.
+func (handler *handler) verifyWithdrawAddress(password string, blockchain tokens.BlockchainName, address string) error {
+	if !handler.wallet.AddressBookRestricted() {
+		return nil
+	}
+	entries, err := handler.getAddressBook(password)
+	if err != nil {
+		return err
+	}
+	return wallet.VerifyAddressBook(entries, blockchain, address)
+}
+
+func (handler *handler) acceptOffer(password string, offer swap.Offer) (PostSwapResponse, error) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return PostSwapResponse{}, err
+	}
+	// Only the fields of the offer that are signed by the initiator are used.
+	swapReq := PostSwapRequest(signedSwap(offer.Swap))
+	swapReq.Password = password
+	swapReq.PasswordHash = base64.StdEncoding.EncodeToString(passwordHash)
+	swapReq.CounterpartyPublicKey = offer.PublicKey
+	swapReq.Signature = offer.Signature
+	swapReq.Speed = blockchain.Fast
+	return handler.PostSwaps(swapReq)
+}
+
+// getOffers returns the offers, that are made to the given password, which
+// have not been accepted and have not expired. Expired offers are deleted.
+func (handler *handler) getOffers(password string) ([]swap.Offer, error) {
+	stored, err := handler.storage.Offers()
+	if err != nil {
+		return nil, err
+	}
+	offers := []swap.Offer{}
+	for _, offer := range stored {
+		if offer.Expired(time.Now().Unix()) {
+			if err := handler.storage.DeleteOffer(offer.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if offer.Accepted {
+			continue
+		}
+		passwordHash, err := base64.StdEncoding.DecodeString(offer.PasswordHash)
+		if err != nil {
+			return nil, fmt.Errorf("corrupted password")
+		}
+		if bcrypt.CompareHashAndPassword(passwordHash, []byte(password)) != nil {
+			continue
+		}
+		offers = append(offers, offer)
+	}
+	return offers, nil
+}
+
+func (handler *handler) getOffer(password string, id swap.SwapID) (swap.Offer, error) {
+	offers, err := handler.getOffers(password)
+	if err != nil {
+		return swap.Offer{}, err
+	}
+	for _, offer := range offers {
+		if offer.ID == id {
+			if handler.accepting[offer.ID] {
+				return swap.Offer{}, fmt.Errorf("offer is being accepted")
+			}
+			return offer, nil
+		}
+	}
+	return swap.Offer{}, fmt.Errorf("offer not found")
+}
+
+// verifyNewOffer checks that the swap of the offer has not been offered
+// before, by comparing its secret hash with the stored offers.
+func (handler *handler) verifyNewOffer(offer swap.Offer) error {
+	stored, err := handler.storage.Offers()
+	if err != nil {
+		return err
+	}
+	for _, storedOffer := range stored {
+		if storedOffer.Swap.SecretHash == offer.Swap.SecretHash {
+			return fmt.Errorf("swap with secret hash %s has already been offered", offer.Swap.SecretHash)
+		}
+	}
+	return nil
+}
+
+// getSchedules returns the schedules that belong to the given password.
+func (handler *handler) getSchedules(password string) ([]schedule.Schedule, error) {
+	stored, err := handler.storage.Schedules()
+	if err != nil {
+		return nil, err
+	}
+	schedules := []schedule.Schedule{}
+	for _, schedule := range stored {
+		passwordHash, err := base64.StdEncoding.DecodeString(schedule.Template.PasswordHash)
+		if err != nil {
+			return nil, fmt.Errorf("corrupted password")
+		}
+		if bcrypt.CompareHashAndPassword(passwordHash, []byte(password)) != nil {
+			continue
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (handler *handler) getSchedule(password string, id schedule.ScheduleID) (schedule.Schedule, error) {
+	schedules, err := handler.getSchedules(password)
+	if err != nil {
+		return schedule.Schedule{}, err
+	}
+	for _, schedule := range schedules {
+		if schedule.ID == id {
+			return schedule, nil
+		}
+	}
+	return schedule.Schedule{}, fmt.Errorf("schedule not found")
+}
+
+// getSwapReceipt returns the receipt of the swap with the given id, if it
+// belongs to the given password.
+func (handler *handler) getSwapReceipt(password string, id swap.SwapID) (swap.SwapReceipt, error) {
+	receipts, err := handler.getSwapReceipts(password)
+	if err != nil {
+		return swap.SwapReceipt{}, err
+	}
+
+	receipt, ok := receipts[id]
+	if !ok {
+		return swap.SwapReceipt{}, fmt.Errorf("swap receipt not found")
+	}
+
+	passwordHash, err := base64.StdEncoding.DecodeString(receipt.PasswordHash)
+	if receipt.PasswordHash != "" && err != nil {
+		return swap.SwapReceipt{}, fmt.Errorf("corrupted password")
+	}
+
+	if receipt.PasswordHash != "" && bcrypt.CompareHashAndPassword(passwordHash, []byte(password)) != nil {
+		return swap.SwapReceipt{}, fmt.Errorf("swap receipt not found")
+	}
+	return receipt, nil
+}
+
 func (handler *handler) getSwapReceipts(password string) (map[swap.SwapID]swap.SwapReceipt, error) {
 	receiptMap := map[swap.SwapID]swap.SwapReceipt{}
 
@@ -147,13 +791,38 @@ func (handler *handler) getSwapReceipts(password string) (map[swap.SwapID]swap.S
 func (handler *handler) GetBalances(password string) (GetBalancesResponse, error) {
 	handler.bootload(password)
 	balanceMap, err := handler.wallet.Balances(password)
-	return GetBalancesResponse(balanceMap), err
+	if err != nil {
+		return GetBalancesResponse{}, err
+	}
+
+	handler.lockLedger(password)
+	reservations, err := handler.reservations(password)
+	handler.ledger.mu.Unlock()
+	if err != nil {
+		return GetBalancesResponse{}, err
+	}
+
+	resp := GetBalancesResponse{}
+	for token, balance := range balanceMap {
+		resp[token] = balanceResponse(balance, reservations[token])
+	}
+	return resp, nil
 }
 
 func (handler *handler) GetBalance(password string, token tokens.Token) (GetBalanceResponse, error) {
 	handler.bootload(password)
 	balance, err := handler.wallet.Balance(password, token)
-	return GetBalanceResponse(balance), err
+	if err != nil {
+		return GetBalanceResponse{}, err
+	}
+
+	handler.lockLedger(password)
+	reservations, err := handler.reservations(password)
+	handler.ledger.mu.Unlock()
+	if err != nil {
+		return GetBalanceResponse{}, err
+	}
+	return balanceResponse(balance, reservations[token.Name]), nil
 }
 
 func (handler *handler) GetTransfers(password string) (GetTransfersResponse, error) {
@@ -193,7 +862,11 @@ func (handler *handler) PostSwaps(swapReq PostSwapRequest) (PostSwapResponse, er
 		return PostSwapResponse{}, err
 	}
 
+	if err := handler.reserveSwap(blob); err != nil {
+		return PostSwapResponse{}, err
+	}
 	if err := handler.Write(swapper.SwapRequest(blob)); err != nil {
+		handler.releaseSwap(blob.ID)
 		return PostSwapResponse{}, err
 	}
 	return handler.buildSwapResponse(blob)
@@ -202,17 +875,50 @@ func (handler *handler) PostSwaps(swapReq PostSwapRequest) (PostSwapResponse, er
 func (handler *handler) PostDelayedSwaps(swapReq PostSwapRequest) error {
 	handler.bootload(swapReq.Password)
 
-	blob, err := handler.patchDelayedSwap(swap.SwapBlob(swapReq))
+	blob, err := handler.BuildSwap(swap.SwapBlob(swapReq))
 	if err != nil {
 		return err
 	}
+	if err := handler.reserveSwap(blob); err != nil {
+		return err
+	}
+	if err := handler.Write(swapper.SwapRequest(blob)); err != nil {
+		handler.releaseSwap(blob.ID)
+		return err
+	}
+	return nil
+}
 
-	blob, err = handler.signDelayInfo(blob)
+// BuildSwap validates a delayed swap, fills in its id, secret and timelocks,
+// and signs its delay info.
+func (handler *handler) BuildSwap(template swap.SwapBlob) (swap.SwapBlob, error) {
+	blob, err := handler.patchDelayedSwap(template)
 	if err != nil {
-		return err
+		return blob, err
 	}
+	return handler.signDelayInfo(blob)
+}
 
-	return handler.Write(swapper.SwapRequest(blob))
+// PostSwapPreflight runs every validation of a new swap and estimates its
+// costs, without storing or executing the swap.
+func (handler *handler) PostSwapPreflight(swapReq PostSwapRequest) (PostSwapPreflightResponse, error) {
+	blob, checks := handler.preflightSwap(swap.SwapBlob(swapReq))
+	if err := preflightError(checks); err == ErrCannotGenerateSwapID {
+		return PostSwapPreflightResponse{}, err
+	}
+	resp := PostSwapPreflightResponse{
+		TimeLock:       blob.TimeLock,
+		TimeLockMargin: blob.TimeLockMargin,
+	}
+	if preflightError(checks) == nil {
+		sendCost, receiveCost, err := handler.estimator.EstimateSwapCosts(blob)
+		checks = append(checks, NewPreflightCheck("contracts", err))
+		resp.SendCost = blockchain.CostToCostBlob(sendCost)
+		resp.ReceiveCost = blockchain.CostToCostBlob(receiveCost)
+	}
+	resp.Checks = checks
+	resp.Valid = preflightError(checks) == nil
+	return resp, nil
 }
 
 func (handler *handler) PostTransfers(req PostTransfersRequest) error {
@@ -221,13 +927,16 @@ func (handler *handler) PostTransfers(req PostTransfersRequest) error {
 		req.Speed = blockchain.Fast
 	}
 
-	token, err := tokens.PatchToken(req.Token)
+	token, err := blockchain.PatchToken(req.Token)
 	if err != nil {
 		return err
 	}
 	if err := handler.wallet.VerifyAddress(token.Blockchain, req.To); err != nil {
 		return err
 	}
+	if err := handler.verifyWithdrawAddress(req.Password, token.Blockchain, req.To); err != nil {
+		return err
+	}
 	if req.SendAll {
 		balance, err := handler.wallet.Balance(req.Password, token)
 		if err != nil {
@@ -237,6 +946,9 @@ func (handler *handler) PostTransfers(req PostTransfersRequest) error {
 		if !ok {
 			return fmt.Errorf("unable to decode balance: %s", balance.Amount)
 		}
+		if err := handler.reserveTransfer(req.Password, token, req.To, amount, true); err != nil {
+			return err
+		}
 		return handler.Write(transfer.NewTransferRequest(req.Password, token, req.To, amount, req.Speed, true))
 	}
 
@@ -245,7 +957,7 @@ func (handler *handler) PostTransfers(req PostTransfersRequest) error {
 		return fmt.Errorf("invalid amount %s", req.Amount)
 	}
 
-	if err := handler.wallet.VerifyBalance(req.Password, token, amount); err != nil {
+	if err := handler.reserveTransfer(req.Password, token, req.To, amount, false); err != nil {
 		return err
 	}
 
@@ -312,6 +1024,53 @@ func (handler *handler) GetHexSignature(password string, message string) (GetSig
 	}, nil
 }
 
+func (handler *handler) VerifyJSONSignature(req PostVerifyRequestJSON) (PostVerifyResponse, error) {
+	sig, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		return PostVerifyResponse{}, fmt.Errorf("invalid signature: %v", err)
+	}
+	return verifySignature(req.PublicKey, req.Message, sig), nil
+}
+
+func (handler *handler) VerifyBase64Signature(req PostVerifyRequestString) (PostVerifyResponse, error) {
+	msg, err := base64.StdEncoding.DecodeString(req.Message)
+	if err != nil {
+		return PostVerifyResponse{}, fmt.Errorf("invalid message: %v", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		return PostVerifyResponse{}, fmt.Errorf("invalid signature: %v", err)
+	}
+	return verifySignature(req.PublicKey, msg, sig), nil
+}
+
+func (handler *handler) VerifyHexSignature(req PostVerifyRequestString) (PostVerifyResponse, error) {
+	if len(req.Message) > 2 && req.Message[:2] == "0x" {
+		req.Message = req.Message[2:]
+	}
+	if len(req.Signature) > 2 && req.Signature[:2] == "0x" {
+		req.Signature = req.Signature[2:]
+	}
+	msg, err := hex.DecodeString(req.Message)
+	if err != nil {
+		return PostVerifyResponse{}, fmt.Errorf("invalid message: %v", err)
+	}
+	sig, err := hex.DecodeString(req.Signature)
+	if err != nil {
+		return PostVerifyResponse{}, fmt.Errorf("invalid signature: %v", err)
+	}
+	return verifySignature(req.PublicKey, msg, sig), nil
+}
+
+// verifySignature checks a signature produced by the /sign endpoints of
+// another swapperd.
+func verifySignature(publicKey string, message, signature []byte) PostVerifyResponse {
+	if err := wallet.VerifySignature(publicKey, message, signature); err != nil {
+		return PostVerifyResponse{Valid: false, Error: err.Error()}
+	}
+	return PostVerifyResponse{Valid: true}
+}
+
 func (handler *handler) Write(msg tau.Message) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer cancel()
@@ -328,90 +1087,124 @@ func (handler *handler) bootload(password string) {
 }
 
 func (handler *handler) patchSwap(swapBlob swap.SwapBlob) (swap.SwapBlob, error) {
-	sendToken, err := tokens.PatchToken(swapBlob.SendToken)
-	if err != nil {
-		return swapBlob, err
-	}
+	swapBlob, checks := handler.preflightSwap(swapBlob)
+	return swapBlob, preflightError(checks)
+}
 
-	if err := handler.wallet.VerifyAddress(sendToken.Blockchain, swapBlob.SendTo); err != nil {
-		return swapBlob, err
-	}
+func (handler *handler) patchDelayedSwap(blob swap.SwapBlob) (swap.SwapBlob, error) {
+	blob.Delay = true
+	blob, checks := handler.preflightSwap(blob)
+	return blob, preflightError(checks)
+}
 
-	receiveToken, err := tokens.PatchToken(swapBlob.ReceiveToken)
-	if err != nil {
-		return swapBlob, err
+// preflightSwap validates a new swap, and fills in its id, secret and
+// timelocks. It returns the result of every validation, validations that
+// depend on a failed validation are not run.
+func (handler *handler) preflightSwap(swapBlob swap.SwapBlob) (swap.SwapBlob, []PreflightCheck) {
+	checks := []PreflightCheck{}
+	if swapBlob.Delay {
+		checks = append(checks, NewPreflightCheck("delayCallbackUrl", verifyDelayCallbackURL(swapBlob.DelayCallbackURL)))
 	}
 
-	if err := handler.wallet.VerifyAddress(receiveToken.Blockchain, swapBlob.ReceiveFrom); err != nil {
-		return swapBlob, err
+	sendToken, err := blockchain.PatchToken(swapBlob.SendToken)
+	checks = append(checks, NewPreflightCheck("sendToken", err))
+	receiveToken, err := blockchain.PatchToken(swapBlob.ReceiveToken)
+	checks = append(checks, NewPreflightCheck("receiveToken", err))
+	checks = append(checks, NewPreflightCheck("bitcoinScriptType", swapBlob.BitcoinScriptType.Verify()))
+	if preflightError(checks) != nil {
+		return swapBlob, checks
 	}
 
-	if swapBlob.WithdrawAddress != "" {
-		if err := handler.wallet.VerifyAddress(receiveToken.Blockchain, swapBlob.WithdrawAddress); err != nil {
-			return swapBlob, err
+	if !swapBlob.Delay {
+		checks = append(checks,
+			NewPreflightCheck("sendTo", handler.wallet.VerifyAddress(sendToken.Blockchain, swapBlob.SendTo)),
+			NewPreflightCheck("receiveFrom", handler.wallet.VerifyAddress(receiveToken.Blockchain, swapBlob.ReceiveFrom)),
+		)
+		if swapBlob.WithdrawAddress != "" {
+			checks = append(checks, NewPreflightCheck("withdrawAddress", handler.wallet.VerifyAddress(receiveToken.Blockchain, swapBlob.WithdrawAddress)))
 		}
 	}
-
-	if err := handler.verifySendAmount(swapBlob.Password, sendToken, swapBlob.SendAmount, swapBlob.BrokerFee); err != nil {
-		return swapBlob, err
-	}
-
-	if err := handler.verifyReceiveAmount(swapBlob.Password, receiveToken); err != nil {
-		return swapBlob, err
+	if swapBlob.WithdrawAddress != "" {
+		checks = append(checks, NewPreflightCheck("addressBook", handler.verifyWithdrawAddress(swapBlob.Password, receiveToken.Blockchain, swapBlob.WithdrawAddress)))
 	}
+	checks = append(checks,
+		NewPreflightCheck("sendBalance", handler.verifySendAmount(swapBlob.Password, sendToken, swapBlob.SendAmount, swapBlob.BrokerFee)),
+		NewPreflightCheck("receiveBalance", handler.verifyReceiveAmount(swapBlob.Password, receiveToken)),
+		NewPreflightCheck("policy", handler.verifySwapPolicy(swapBlob, sendToken, receiveToken)),
+	)
 
 	swapID := [32]byte{}
-	rand.Read(swapID[:])
+	if _, err := rand.Read(swapID[:]); err != nil {
+		return swapBlob, append(checks, NewPreflightCheck("id", ErrCannotGenerateSwapID))
+	}
 	swapBlob.ID = swap.SwapID(base64.StdEncoding.EncodeToString(swapID[:]))
-	secret := [32]byte{}
-	if swapBlob.ShouldInitiateFirst {
-		swapBlob.TimeLock = time.Now().Unix() + 3*swap.ExpiryUnit
-		secret = genereateSecret(swapBlob.Password, swapBlob.ID)
+	policy := handler.wallet.TimeLockPolicy()
+	margin := policy.SafetyMargin(sendToken, receiveToken)
+	if swapBlob.ShouldInitiateFirst || swapBlob.Delay {
+		secret, err := swap.NewSecret()
+		checks = append(checks, NewPreflightCheck("secret", err))
+		if err != nil {
+			return swapBlob, checks
+		}
+		swapBlob.Secret = secret
 		hash := sha256.Sum256(secret[:])
 		swapBlob.SecretHash = base64.StdEncoding.EncodeToString(hash[:])
-		return swapBlob, nil
+		swapBlob.TimeLock = time.Now().Unix() + policy.Duration(sendToken, receiveToken)
+		swapBlob.TimeLockMargin = margin
+		if swapBlob.Delay {
+			if swapBlob.DelayDeadline == 0 {
+				swapBlob.DelayDeadline = time.Now().Unix() + handler.wallet.DelayedSwapDeadline()
+			}
+			checks = append(checks, NewPreflightCheck("delayDeadline", verifyDelayDeadline(swapBlob)))
+		}
+		return swapBlob, checks
 	}
 
-	secretHash, err := base64.StdEncoding.DecodeString(swapBlob.SecretHash)
-	if len(secretHash) != 32 || err != nil {
-		return swapBlob, fmt.Errorf("invalid secret hash")
-	}
-	if time.Now().Unix()+2*swap.ExpiryUnit > swapBlob.TimeLock {
-		return swapBlob, fmt.Errorf("not enough time to do the atomic swap")
-	}
-	return swapBlob, nil
+	return swapBlob, append(checks,
+		NewPreflightCheck("signature", verifySwapSignature(swapBlob)),
+		NewPreflightCheck("secretHash", verifySecretHash(swapBlob.SecretHash)),
+		NewPreflightCheck("timeLock", verifyTimeLock(swapBlob, margin)),
+	)
 }
 
-func (handler *handler) patchDelayedSwap(blob swap.SwapBlob) (swap.SwapBlob, error) {
-	if blob.DelayCallbackURL == "" {
-		return blob, fmt.Errorf("delay url cannot be empty")
+func verifyDelayCallbackURL(url string) error {
+	if url == "" {
+		return fmt.Errorf("delay url cannot be empty")
 	}
+	return nil
+}
 
-	swapID := [32]byte{}
-	rand.Read(swapID[:])
-	blob.ID = swap.SwapID(base64.StdEncoding.EncodeToString(swapID[:]))
+func verifySecretHash(secretHash string) error {
+	hash, err := base64.StdEncoding.DecodeString(secretHash)
+	if len(hash) != 32 || err != nil {
+		return fmt.Errorf("invalid secret hash")
+	}
+	return nil
+}
 
-	sendToken, err := tokens.PatchToken(string(blob.SendToken))
-	if err != nil {
-		return blob, err
+// verifyDelayDeadline checks that a delayed swap can be filled before its
+// deadline, and that the deadline is before the timelock.
+func verifyDelayDeadline(blob swap.SwapBlob) error {
+	if blob.DelayDeadline <= time.Now().Unix() {
+		return fmt.Errorf("delay deadline has already passed")
 	}
-	if err := handler.verifySendAmount(blob.Password, sendToken, blob.SendAmount, blob.BrokerFee); err != nil {
-		return blob, err
+	if blob.DelayDeadline >= blob.ResponderTimeLock() {
+		return fmt.Errorf("delay deadline must be before the timelock")
 	}
+	return nil
+}
 
-	receiveToken, err := tokens.PatchToken(string(blob.ReceiveToken))
-	if err != nil {
-		return blob, err
+// verifyTimeLock checks that the responder's timelock expires at least the
+// safety margin before the initiator's TimeLock, and leaves at least the
+// safety margin to execute the swap.
+func verifyTimeLock(blob swap.SwapBlob, margin int64) error {
+	if gap := blob.TimeLock - blob.ResponderTimeLock(); gap < margin {
+		return fmt.Errorf("timelock margin of %d seconds is below the minimum of %d seconds", gap, margin)
 	}
-	if err := handler.verifyReceiveAmount(blob.Password, receiveToken); err != nil {
-		return blob, err
+	if time.Now().Unix()+margin > blob.ResponderTimeLock() {
+		return fmt.Errorf("not enough time to do the atomic swap")
 	}
-
-	secret := genereateSecret(blob.Password, blob.ID)
-	secretHash := sha256.Sum256(secret[:])
-	blob.SecretHash = base64.StdEncoding.EncodeToString(secretHash[:])
-	blob.TimeLock = time.Now().Unix() + 3*swap.ExpiryUnit
-	return blob, nil
+	return nil
 }
 
 func (handler *handler) verifySendAmount(password string, token tokens.Token, amount string, fee int64) error {
@@ -419,7 +1212,9 @@ func (handler *handler) verifySendAmount(password string, token tokens.Token, am
 	if !ok {
 		return fmt.Errorf("invalid send amount")
 	}
-	return handler.wallet.VerifyBalance(password, token, withFees(sendAmount, fee))
+	handler.lockLedger(password)
+	defer handler.ledger.mu.Unlock()
+	return handler.verifyAvailableBalance(password, token, withFees(sendAmount, fee))
 }
 
 func (handler *handler) verifyReceiveAmount(password string, token tokens.Token) error {
@@ -455,12 +1250,12 @@ func (handler *handler) buildSwapResponse(blob swap.SwapBlob) (PostSwapResponse,
 	responseBlob.ReceiveAmount = blob.SendAmount
 	swapResponse := PostSwapResponse{}
 
-	sendToken, err := tokens.PatchToken(responseBlob.SendToken)
+	sendToken, err := blockchain.PatchToken(responseBlob.SendToken)
 	if err != nil {
 		return swapResponse, err
 	}
 
-	receiveToken, err := tokens.PatchToken(responseBlob.ReceiveToken)
+	receiveToken, err := blockchain.PatchToken(responseBlob.ReceiveToken)
 	if err != nil {
 		return swapResponse, err
 	}
@@ -479,12 +1274,14 @@ func (handler *handler) buildSwapResponse(blob swap.SwapBlob) (PostSwapResponse,
 	responseBlob.ReceiveFrom = receiveFrom
 	responseBlob.SecretHash = blob.SecretHash
 	responseBlob.TimeLock = blob.TimeLock
+	responseBlob.TimeLockMargin = blob.TimeLockMargin
 
 	responseBlob.BrokerFee = blob.BrokerFee
 	responseBlob.BrokerSendTokenAddr = blob.BrokerReceiveTokenAddr
 	responseBlob.BrokerReceiveTokenAddr = blob.BrokerSendTokenAddr
+	responseBlob.BitcoinScriptType = blob.BitcoinScriptType
 
-	responseBlobBytes, err := json.Marshal(responseBlob)
+	responseBlobBytes, err := signedSwapMessage(responseBlob)
 	if err != nil {
 		return swapResponse, err
 	}
@@ -495,8 +1292,13 @@ func (handler *handler) buildSwapResponse(blob swap.SwapBlob) (PostSwapResponse,
 	}
 
 	if blob.ShouldInitiateFirst {
+		publicKey, err := handler.wallet.ID(blob.Password, "")
+		if err != nil {
+			return swapResponse, err
+		}
 		swapResponse.Swap = responseBlob
 		swapResponse.Signature = base64.StdEncoding.EncodeToString(responseBlobSig)
+		swapResponse.PublicKey = publicKey
 	}
 
 	if blob.ResponseURL != "" {
@@ -525,6 +1327,62 @@ func (handler *handler) buildSwapResponse(blob swap.SwapBlob) (PostSwapResponse,
 	return swapResponse, nil
 }
 
+// signedSwapMessage returns the message that is signed by the initiator of a
+// swap, when it mirrors the swap for the responder. It only holds the fields
+// that are agreed on by both parties.
+func signedSwapMessage(blob swap.SwapBlob) ([]byte, error) {
+	return json.Marshal(signedSwap(blob))
+}
+
+// signedSwap returns the swap with only the fields that are signed by the
+// initiator of the swap.
+func signedSwap(blob swap.SwapBlob) swap.SwapBlob {
+	return swap.SwapBlob{
+		SendToken:              blob.SendToken,
+		ReceiveToken:           blob.ReceiveToken,
+		SendAmount:             blob.SendAmount,
+		ReceiveAmount:          blob.ReceiveAmount,
+		SendTo:                 blob.SendTo,
+		ReceiveFrom:            blob.ReceiveFrom,
+		SecretHash:             blob.SecretHash,
+		TimeLock:               blob.TimeLock,
+		TimeLockMargin:         blob.TimeLockMargin,
+		BrokerFee:              blob.BrokerFee,
+		BrokerSendTokenAddr:    blob.BrokerSendTokenAddr,
+		BrokerReceiveTokenAddr: blob.BrokerReceiveTokenAddr,
+		BitcoinScriptType:      blob.BitcoinScriptType,
+	}
+}
+
+// verifyOfferedSwap checks that an offered swap does not set the fields that
+// are chosen by the responder, which are not signed by the initiator.
+func verifyOfferedSwap(blob swap.SwapBlob) error {
+	if blob.WithdrawAddress != "" || blob.Delay || len(blob.DelayInfo) != 0 || blob.DelayCallbackURL != "" || blob.DelayDeadline != 0 {
+		return fmt.Errorf("offer sets fields that are not signed")
+	}
+	return nil
+}
+
+// verifySwapSignature checks that a swap was signed by the counterparty, if
+// the swap names its public key.
+func verifySwapSignature(blob swap.SwapBlob) error {
+	if blob.CounterpartyPublicKey == "" {
+		return nil
+	}
+	if blob.Signature == "" {
+		return fmt.Errorf("swap is not signed by the counterparty")
+	}
+	signature, err := base64.StdEncoding.DecodeString(blob.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	message, err := signedSwapMessage(blob)
+	if err != nil {
+		return err
+	}
+	return wallet.VerifySignature(blob.CounterpartyPublicKey, message, signature)
+}
+
 func (handler *handler) sign(password string, message []byte) ([]byte, error) {
 	signer, err := handler.wallet.ECDSASigner(password)
 	if err != nil {
@@ -538,10 +1396,6 @@ func (handler *handler) sign(password string, message []byte) ([]byte, error) {
 	return sig, nil
 }
 
-func genereateSecret(password string, id swap.SwapID) [32]byte {
-	return sha3.Sum256(append([]byte(password), []byte(id)...))
-}
-
 func passwordHash(password string) string {
 	passwordHash32 := sha3.Sum256([]byte(password))
 	return base64.StdEncoding.EncodeToString(passwordHash32[:])
@@ -7,13 +7,14 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 
 	"github.com/gorilla/mux"
 	"github.com/renproject/swapperd/adapter/wallet"
+	"github.com/renproject/swapperd/core/wallet/schedule"
 	"github.com/renproject/swapperd/core/wallet/transfer"
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
-	"github.com/renproject/tokens"
 	"github.com/rs/cors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
@@ -21,7 +22,15 @@ import (
 
 type Storage interface {
 	Receipts() ([]swap.SwapReceipt, error)
+	SwapEvents(id swap.SwapID) ([]swap.SwapEvent, error)
 	Transfers() ([]transfer.TransferReceipt, error)
+	Schedules() ([]schedule.Schedule, error)
+	PutOffer(offer swap.Offer) error
+	DeleteOffer(id swap.SwapID) error
+	Offers() ([]swap.Offer, error)
+	PutAddressBookEntry(entry wallet.AddressBookEntry) error
+	DeleteAddressBookEntry(id string) error
+	AddressBook() ([]wallet.AddressBookEntry, error)
 }
 
 type httpServer struct {
@@ -30,16 +39,34 @@ type httpServer struct {
 	logger  logrus.FieldLogger
 }
 
-func NewHttpServer(cap int, port, version string, receiver *Receiver, storage Storage, wallet wallet.Wallet, logger logrus.FieldLogger) Server {
-	return &httpServer{port, NewHandler(cap, version, wallet, storage, receiver), logger}
+func NewHttpServer(cap int, port, version string, receiver *Receiver, storage Storage, ledger *Ledger, wallet wallet.Wallet, estimator SwapEstimator, logger logrus.FieldLogger) Server {
+	return &httpServer{port, NewHandler(cap, version, wallet, estimator, storage, ledger, receiver), logger}
 }
 
 // NewHttpListener creates a new http listener
 func (server *httpServer) Run(done <-chan struct{}) {
-	r := mux.NewRouter()
+	r := mux.NewRouter().UseEncodedPath()
 	r.HandleFunc("/swaps", server.postSwapsHandler(server.handler)).Methods("POST")
 	r.HandleFunc("/swaps", server.getSwapsHandler(server.handler)).Methods("GET")
+	r.HandleFunc("/swaps/preflight", server.postSwapPreflightHandler(server.handler)).Methods("POST")
+	r.HandleFunc("/swaps/{id}/events", server.getSwapEventsHandler(server.handler)).Methods("GET")
+	r.HandleFunc("/swaps/{id}", server.cancelSwapHandler(server.handler)).Methods("DELETE")
+	r.HandleFunc("/swaps/{id}/refund", server.cancelSwapHandler(server.handler)).Methods("POST")
+	r.HandleFunc("/swaps/{id}/fill", server.postSwapFillHandler(server.handler)).Methods("POST")
 	// r.HandleFunc("/swaps/{id}", server.getSwapHandler(server.handler)).Methods("GET")
+	r.HandleFunc("/schedules", server.postSchedulesHandler(server.handler)).Methods("POST")
+	r.HandleFunc("/schedules", server.getSchedulesHandler(server.handler)).Methods("GET")
+	r.HandleFunc("/schedules/{id}", server.getScheduleHandler(server.handler)).Methods("GET")
+	r.HandleFunc("/schedules/{id}", server.putScheduleHandler(server.handler)).Methods("PUT")
+	r.HandleFunc("/schedules/{id}", server.cancelScheduleHandler(server.handler)).Methods("DELETE")
+	r.HandleFunc("/offers", server.postOffersHandler(server.handler)).Methods("POST")
+	r.HandleFunc("/offers", server.getOffersHandler(server.handler)).Methods("GET")
+	r.HandleFunc("/offers/policy", server.putOfferPolicyHandler(server.handler)).Methods("PUT")
+	r.HandleFunc("/offers/{id}/accept", server.acceptOfferHandler(server.handler)).Methods("POST")
+	r.HandleFunc("/offers/{id}", server.rejectOfferHandler(server.handler)).Methods("DELETE")
+	r.HandleFunc("/addressbook", server.postAddressBookHandler(server.handler)).Methods("POST")
+	r.HandleFunc("/addressbook", server.getAddressBookHandler(server.handler)).Methods("GET")
+	r.HandleFunc("/addressbook/{id}", server.deleteAddressBookHandler(server.handler)).Methods("DELETE")
 	r.HandleFunc("/transfers", server.postTransfersHandler(server.handler)).Methods("POST")
 	r.HandleFunc("/transfers", server.getTransfersHandler(server.handler)).Methods("GET")
 	r.HandleFunc("/balances", server.getBalancesHandler(server.handler)).Methods("GET")
@@ -50,11 +77,12 @@ func (server *httpServer) Run(done <-chan struct{}) {
 	r.HandleFunc("/id/{type}", server.getIDHandler(server.handler)).Methods("GET")
 	r.HandleFunc("/id", server.getIDHandler(server.handler)).Methods("GET")
 	r.HandleFunc("/sign/{type}", server.postSignatureHandler(server.handler)).Methods("POST")
+	r.HandleFunc("/verify/{type}", server.postVerifyHandler(server.handler)).Methods("POST")
 	r.Use(recoveryHandler)
 	handler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowCredentials: true,
-		AllowedMethods:   []string{"GET", "POST"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
 	}).Handler(r)
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", server.port))
@@ -167,6 +195,72 @@ func (server *httpServer) getSwapsHandler(reqHandler Handler) http.HandlerFunc {
 	}
 }
 
+// getSwapEventsHandler handles the get swap events request, it returns the
+// history of state transitions of the swap with the given id.
+func (server *httpServer) getSwapEventsHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		swapID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid swap id: %v", err))
+			return
+		}
+
+		resp, err := reqHandler.GetSwapEvents(password, swap.SwapID(swapID))
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot get events of swap with id (%s): %v", swapID, err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(resp, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode swap events response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, respBytes)
+	}
+}
+
+// postSwapPreflightHandler handles the post swap preflight request, it runs
+// the validations of a new swap and estimates its costs without starting it.
+func (server *httpServer) postSwapPreflightHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		swapReq := PostSwapRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&swapReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode swap request: %v", err))
+			return
+		}
+		swapReq.Password = password
+		if swapReq.Speed == blockchain.Nil {
+			swapReq.Speed = blockchain.Fast
+		}
+
+		report, err := reqHandler.PostSwapPreflight(swapReq)
+		if err != nil {
+			server.writeRequestError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(report, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode preflight response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, respBytes)
+	}
+}
+
 // postSwapsHandler handles the post swaps request, it fills incomplete
 // information and starts the Atomic Swap.
 func (server *httpServer) postSwapsHandler(reqHandler Handler) http.HandlerFunc {
@@ -183,6 +277,7 @@ func (server *httpServer) postSwapsHandler(reqHandler Handler) http.HandlerFunc
 			return
 		}
 		swapReq.Password = password
+		swapReq.Cancelled = false
 		if swapReq.Speed == blockchain.Nil {
 			swapReq.Speed = blockchain.Fast
 		}
@@ -196,7 +291,7 @@ func (server *httpServer) postSwapsHandler(reqHandler Handler) http.HandlerFunc
 
 		if swapReq.Delay {
 			if err := reqHandler.PostDelayedSwaps(swapReq); err != nil {
-				server.writeError(w, r, http.StatusBadRequest, err.Error())
+				server.writeRequestError(w, r, http.StatusBadRequest, err)
 				return
 			}
 			server.writeResponse(w, r, http.StatusCreated, []byte{})
@@ -204,7 +299,7 @@ func (server *httpServer) postSwapsHandler(reqHandler Handler) http.HandlerFunc
 		}
 		patchedSwap, err := reqHandler.PostSwaps(swapReq)
 		if err != nil {
-			server.writeError(w, r, http.StatusBadRequest, err.Error())
+			server.writeRequestError(w, r, http.StatusBadRequest, err)
 			return
 		}
 
@@ -228,6 +323,407 @@ func (server *httpServer) postSwapsHandler(reqHandler Handler) http.HandlerFunc
 	}
 }
 
+// cancelSwapHandler handles the cancel swap request, it stops a swap that has
+// not been initiated and refunds one that has, once its timelock expires.
+func (server *httpServer) cancelSwapHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		swapID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid swap id: %v", err))
+			return
+		}
+
+		if err := reqHandler.CancelSwap(password, swap.SwapID(swapID)); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot cancel swap with id (%s): %v", swapID, err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, []byte{})
+	}
+}
+
+// postSwapFillHandler handles the post swap fill request, it lets the broker
+// push the filled swap of a delayed swap instead of waiting for the callback.
+// The request is authenticated by the broker's signature of the filled swap.
+func (server *httpServer) postSwapFillHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		swapID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid swap id: %v", err))
+			return
+		}
+
+		fillReq := PostSwapFillRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&fillReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode swap fill request: %v", err))
+			return
+		}
+
+		if err := reqHandler.PostSwapFill(swap.SwapID(swapID), fillReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot fill swap with id (%s): %v", swapID, err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusAccepted, []byte{})
+	}
+}
+
+// postSchedulesHandler handles the post schedules request, it registers a
+// recurring delayed swap.
+func (server *httpServer) postSchedulesHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		scheduleReq := PostScheduleRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&scheduleReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode schedule request: %v", err))
+			return
+		}
+
+		resp, err := reqHandler.PostSchedule(password, scheduleReq)
+		if err != nil {
+			server.writeRequestError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(resp, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode schedule response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusCreated, respBytes)
+	}
+}
+
+// getSchedulesHandler handles the get schedules request, it returns the
+// schedules of the password.
+func (server *httpServer) getSchedulesHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		resp, err := reqHandler.GetSchedules(password)
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot get schedules: %v", err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(resp, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode schedules response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, respBytes)
+	}
+}
+
+// getScheduleHandler handles the get schedule request, it returns a schedule
+// and the state of its swaps.
+func (server *httpServer) getScheduleHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		scheduleID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid schedule id: %v", err))
+			return
+		}
+
+		resp, err := reqHandler.GetSchedule(password, schedule.ScheduleID(scheduleID))
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot get schedule with id (%s): %v", scheduleID, err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(resp, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode schedule response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, respBytes)
+	}
+}
+
+// putScheduleHandler handles the put schedule request, it updates the swaps
+// that a schedule posts from then on.
+func (server *httpServer) putScheduleHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		scheduleID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid schedule id: %v", err))
+			return
+		}
+
+		scheduleReq := PostScheduleRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&scheduleReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode schedule request: %v", err))
+			return
+		}
+
+		if err := reqHandler.UpdateSchedule(password, schedule.ScheduleID(scheduleID), scheduleReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot update schedule with id (%s): %v", scheduleID, err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, []byte{})
+	}
+}
+
+// cancelScheduleHandler handles the cancel schedule request, it stops a
+// schedule from posting new swaps. Swaps that it has already posted are not
+// cancelled.
+func (server *httpServer) cancelScheduleHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		scheduleID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid schedule id: %v", err))
+			return
+		}
+
+		if err := reqHandler.CancelSchedule(password, schedule.ScheduleID(scheduleID)); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot cancel schedule with id (%s): %v", scheduleID, err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, []byte{})
+	}
+}
+
+// postOffersHandler handles the post offers request, it receives a swap
+// offered by another swapperd. The request is authenticated by the signature
+// of the offer.
+func (server *httpServer) postOffersHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offerReq := PostOfferRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&offerReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode offer request: %v", err))
+			return
+		}
+
+		resp, err := reqHandler.PostOffer(offerReq)
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot receive offer: %v", err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(resp, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode offer response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, respBytes)
+	}
+}
+
+// getOffersHandler handles the get offers request, it returns the offers that
+// have not been accepted or rejected.
+func (server *httpServer) getOffersHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		resp, err := reqHandler.GetOffers(password)
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot get offers: %v", err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(resp, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode offers response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, respBytes)
+	}
+}
+
+// acceptOfferHandler handles the accept offer request, it posts the swap of
+// the offer as the responder.
+func (server *httpServer) acceptOfferHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		offerID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid offer id: %v", err))
+			return
+		}
+
+		resp, err := reqHandler.AcceptOffer(password, swap.SwapID(offerID))
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot accept offer with id (%s): %v", offerID, err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(PostRedeemSwapResponse{resp.ID}, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode swap response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusCreated, respBytes)
+	}
+}
+
+// rejectOfferHandler handles the reject offer request, it deletes an offer
+// without posting its swap.
+func (server *httpServer) rejectOfferHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		offerID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid offer id: %v", err))
+			return
+		}
+
+		if err := reqHandler.RejectOffer(password, swap.SwapID(offerID)); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot reject offer with id (%s): %v", offerID, err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, []byte{})
+	}
+}
+
+// putOfferPolicyHandler handles the put offer policy request, it sets the
+// peers whose offers are accepted automatically with the password.
+func (server *httpServer) putOfferPolicyHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		policyReq := PutOfferPolicyRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&policyReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode offer policy request: %v", err))
+			return
+		}
+
+		if err := reqHandler.PutOfferPolicy(password, policyReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot put offer policy: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, []byte{})
+	}
+}
+
+// postAddressBookHandler handles the post address book request, it adds an
+// address that can be withdrawn to once the delay of the address book passed.
+func (server *httpServer) postAddressBookHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		entryReq := PostAddressBookRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&entryReq); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode address book request: %v", err))
+			return
+		}
+
+		entry, err := reqHandler.PostAddressBookEntry(password, entryReq)
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot add address: %v", err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(entry, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode address book response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusCreated, respBytes)
+	}
+}
+
+// getAddressBookHandler handles the get address book request, it returns the
+// address book of the password.
+func (server *httpServer) getAddressBookHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		resp, err := reqHandler.GetAddressBook(password)
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot get address book: %v", err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(resp, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode address book response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, respBytes)
+	}
+}
+
+// deleteAddressBookHandler handles the delete address book request, it removes
+// an address from the address book.
+func (server *httpServer) deleteAddressBookHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok {
+			server.writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		entryID, err := url.PathUnescape(mux.Vars(r)["id"])
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid address book entry id: %v", err))
+			return
+		}
+
+		if err := reqHandler.DeleteAddressBookEntry(password, entryID); err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot delete address book entry with id (%s): %v", entryID, err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, []byte{})
+	}
+}
+
 // postTransferHandler handles the post withdrawal
 func (server *httpServer) postTransfersHandler(reqHandler Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -245,6 +741,10 @@ func (server *httpServer) postTransfersHandler(reqHandler Handler) http.HandlerF
 		transferReq.Password = password
 
 		if err := reqHandler.PostTransfers(transferReq); err != nil {
+			if _, ok := err.(swap.PolicyViolation); ok {
+				server.writeRequestError(w, r, http.StatusBadRequest, err)
+				return
+			}
 			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode transfers request: %v", err))
 			return
 		}
@@ -281,7 +781,7 @@ func (server *httpServer) getBalancesHandler(reqHandler Handler) http.HandlerFun
 			return
 		}
 
-		token, err := tokens.PatchToken(tokenName)
+		token, err := blockchain.PatchToken(tokenName)
 		if err != nil {
 			server.writeError(w, r, http.StatusBadRequest, err.Error())
 		}
@@ -353,7 +853,7 @@ func (server *httpServer) getAddressesHandler(reqHandler Handler) http.HandlerFu
 			}
 			server.writeResponse(w, r, http.StatusOK, respBytes)
 		} else {
-			token, err := tokens.PatchToken(tokenName)
+			token, err := blockchain.PatchToken(tokenName)
 			if err != nil {
 				server.writeError(w, r, http.StatusBadRequest, err.Error())
 			}
@@ -448,6 +948,49 @@ func (server *httpServer) postSignatureHandler(reqHandler Handler) http.HandlerF
 	}
 }
 
+func (server *httpServer) postVerifyHandler(reqHandler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var resp PostVerifyResponse
+		var err error
+
+		vars := mux.Vars(r)
+		switch vars["type"] {
+		case "json":
+			req := PostVerifyRequestJSON{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode verify request: %v", err))
+				return
+			}
+			resp, err = reqHandler.VerifyJSONSignature(req)
+		case "base64", "hex":
+			req := PostVerifyRequestString{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot decode verify request: %v", err))
+				return
+			}
+			if vars["type"] == "base64" {
+				resp, err = reqHandler.VerifyBase64Signature(req)
+			} else {
+				resp, err = reqHandler.VerifyHexSignature(req)
+			}
+		default:
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("unknown message type: %s", vars["type"]))
+			return
+		}
+		if err != nil {
+			server.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("cannot verify the signature: %v", err))
+			return
+		}
+
+		respBytes, err := json.MarshalIndent(resp, "\t", "")
+		if err != nil {
+			server.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("cannot encode verify response: %v", err))
+			return
+		}
+		server.writeResponse(w, r, http.StatusOK, respBytes)
+	}
+}
+
 func (server *httpServer) writeResponse(w http.ResponseWriter, r *http.Request, statusCode int, resp []byte) {
 	logger := server.logger
 	logger = logger.WithField("method", r.Method)
@@ -459,6 +1002,36 @@ func (server *httpServer) writeResponse(w http.ResponseWriter, r *http.Request,
 	w.Write(resp)
 }
 
+// writeRequestError writes the error of a request that was rejected. Policy
+// violations are forbidden, and are written with the rule that was violated.
+// Swaps that cannot be given an id are internal server errors.
+func (server *httpServer) writeRequestError(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	if err == ErrCannotGenerateSwapID {
+		server.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	violation, ok := err.(swap.PolicyViolation)
+	if !ok {
+		server.writeError(w, r, statusCode, err.Error())
+		return
+	}
+
+	respBytes, marshalErr := json.MarshalIndent(PolicyViolationResponse{Error: violation.Error(), Violation: violation}, "\t", "")
+	if marshalErr != nil {
+		server.writeError(w, r, http.StatusForbidden, violation.Error())
+		return
+	}
+	logger := server.logger
+	logger = logger.WithField("method", r.Method)
+	logger = logger.WithField("url", r.URL)
+	logger = logger.WithField("port", server.port)
+	logger = logger.WithField("status", http.StatusForbidden)
+	logger = logger.WithError(err)
+	logger.Warnf("failed to respond to a http request")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(respBytes)
+}
+
 func (server *httpServer) writeError(w http.ResponseWriter, r *http.Request, statusCode int, err string) {
 	logger := server.logger
 	logger = logger.WithField("method", r.Method)
@@ -2,19 +2,26 @@ package server_test
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"os"
 	"reflect"
+	"strconv"
+	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/renproject/tokens"
+	"golang.org/x/crypto/sha3"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/renproject/swapperd/adapter/server"
 
+	"github.com/renproject/swapperd/adapter/binder"
 	bc "github.com/renproject/swapperd/adapter/wallet"
 	"github.com/renproject/swapperd/core/wallet"
 	"github.com/renproject/swapperd/core/wallet/swapper"
@@ -27,15 +34,15 @@ var _ = Describe("Server Adapter", func() {
 	receiver := NewReceiver(128)
 	done := make(chan struct{})
 
-	buildServer := func() Server {
+	buildServer := func(receiver *Receiver, port string, policy swap.SpendingPolicy) Server {
 		config := bc.Testnet
 		config.Mnemonic = os.Getenv("MNEMONIC")
-		port := os.Getenv("PORT")
+		config.Policy = policy
 
 		logger := logger.NewStdOut()
 		blockchain := bc.New(config, logger)
 		storage := testutils.NewMockStorage()
-		httpServer := NewHttpServer(128, port, "", receiver, storage, blockchain, logger)
+		httpServer := NewHttpServer(128, port, "", receiver, storage, NewLedger(), blockchain, binder.NewBuilder(blockchain, logger), logger)
 		return httpServer
 	}
 
@@ -62,7 +69,7 @@ var _ = Describe("Server Adapter", func() {
 	}
 
 	BeforeSuite(func() {
-		go buildServer().Run(done)
+		go buildServer(receiver, os.Getenv("PORT"), swap.SpendingPolicy{}).Run(done)
 	})
 
 	Context("basic requests", func() {
@@ -97,6 +104,198 @@ var _ = Describe("Server Adapter", func() {
 			_, ok := msg.(swapper.SwapRequest)
 			Expect(ok).Should(BeTrue())
 		})
+
+		It("when getting the balances of swaps that are in progress", func() {
+			getBalance := func() GetBalanceResponse {
+				req, err := http.NewRequest("GET", fmt.Sprintf("http://localhost:%s/balances/BTC", os.Getenv("PORT")), nil)
+				Expect(err).Should(BeNil())
+				req.SetBasicAuth("", "Alice")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).Should(BeNil())
+				Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+				balanceResp := GetBalanceResponse{}
+				Expect(json.NewDecoder(resp.Body).Decode(&balanceResp)).Should(BeNil())
+				return balanceResp
+			}
+			amount := func(value string) *big.Int {
+				amount, ok := new(big.Int).SetString(value, 10)
+				Expect(ok).Should(BeTrue())
+				return amount
+			}
+
+			before := getBalance()
+			data, err := json.Marshal(buildSwap("Bob"))
+			Expect(err).Should(BeNil())
+			req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:%s/swaps", os.Getenv("PORT")), bytes.NewBuffer(data))
+			Expect(err).Should(BeNil())
+			req.SetBasicAuth("", "Alice")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusCreated))
+			_, err = receiver.Receive()
+			Expect(err).Should(BeNil())
+
+			// The swap is reserved until its funds are locked in the HTLC.
+			after := getBalance()
+			reserved := new(big.Int).Sub(amount(after.Reserved), amount(before.Reserved))
+			Expect(reserved.String()).Should(Equal("20000"))
+			Expect(after.LockedInHTLC).Should(Equal(before.LockedInHTLC))
+			available := new(big.Int).Sub(amount(after.Amount), amount(after.Reserved))
+			if available.Sign() < 0 {
+				available = big.NewInt(0)
+			}
+			Expect(after.Available).Should(Equal(available.String()))
+		})
+
+		It("when receiving an offer from another swapperd", func() {
+			secretHash := sha3.Sum256([]byte("offer"))
+			offerSwap := buildSwap("Bob")
+			offerSwap.ShouldInitiateFirst = false
+			offerSwap.SecretHash = base64.StdEncoding.EncodeToString(secretHash[:])
+			offerSwap.TimeLock = time.Now().Unix() + 6*swap.ExpiryUnit
+			offerSwap.TimeLockMargin = 3 * swap.ExpiryUnit
+			offerSwapBytes, err := json.Marshal(offerSwap)
+			Expect(err).Should(BeNil())
+
+			peer := testutils.NewMockSigner()
+			hash := sha3.Sum256(offerSwapBytes)
+			sig, err := peer.Sign(hash[:])
+			Expect(err).Should(BeNil())
+			peerKey := base64.StdEncoding.EncodeToString(crypto.FromECDSAPub(&peer.Key.PublicKey))
+			offer := PostOfferRequest{
+				Swap:      offerSwap,
+				Signature: base64.StdEncoding.EncodeToString(sig),
+				PublicKey: peerKey,
+			}
+
+			postOffer := func(offer PostOfferRequest) *http.Response {
+				data, err := json.Marshal(offer)
+				Expect(err).Should(BeNil())
+				resp, err := http.Post(fmt.Sprintf("http://localhost:%s/offers", os.Getenv("PORT")), "application/json", bytes.NewBuffer(data))
+				Expect(err).Should(BeNil())
+				return resp
+			}
+
+			getOffers := func(password string) GetOffersResponse {
+				req, err := http.NewRequest("GET", fmt.Sprintf("http://localhost:%s/offers", os.Getenv("PORT")), nil)
+				Expect(err).Should(BeNil())
+				req.SetBasicAuth("", password)
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).Should(BeNil())
+				Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+				offersResp := GetOffersResponse{}
+				Expect(json.NewDecoder(resp.Body).Decode(&offersResp)).Should(BeNil())
+				return offersResp
+			}
+
+			// Offers are only received from peers that are trusted.
+			Expect(postOffer(offer).StatusCode).Should(Equal(http.StatusBadRequest))
+
+			data, err := json.Marshal(PutOfferPolicyRequest{ReviewPeers: []string{peerKey}})
+			Expect(err).Should(BeNil())
+			req, err := http.NewRequest("PUT", fmt.Sprintf("http://localhost:%s/offers/policy", os.Getenv("PORT")), bytes.NewBuffer(data))
+			Expect(err).Should(BeNil())
+			req.SetBasicAuth("", "Alice")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+
+			forgedOffer := offer
+			forgedOffer.Swap.SendAmount = "40000"
+			Expect(postOffer(forgedOffer).StatusCode).Should(Equal(http.StatusBadRequest))
+
+			// The fields that are chosen by the responder cannot be offered.
+			forgedOffer = offer
+			forgedOffer.Swap.WithdrawAddress = offerSwap.SendTo
+			Expect(postOffer(forgedOffer).StatusCode).Should(Equal(http.StatusBadRequest))
+			forgedOffer = offer
+			forgedOffer.Swap.Delay = true
+			forgedOffer.Swap.DelayCallbackURL = "http://localhost"
+			Expect(postOffer(forgedOffer).StatusCode).Should(Equal(http.StatusBadRequest))
+
+			resp = postOffer(offer)
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			offerResp := PostOfferResponse{}
+			Expect(json.NewDecoder(resp.Body).Decode(&offerResp)).Should(BeNil())
+			Expect(offerResp.Accepted).Should(BeFalse())
+
+			// A swap can only be offered once.
+			Expect(postOffer(offer).StatusCode).Should(Equal(http.StatusBadRequest))
+
+			offersResp := getOffers("Alice")
+			Expect(offersResp.Offers).Should(HaveLen(1))
+			Expect(offersResp.Offers[0].ID).Should(Equal(offerResp.ID))
+			Expect(offersResp.Offers[0].PasswordHash).Should(BeEmpty())
+			Expect(getOffers("Eve").Offers).Should(BeEmpty())
+		})
+
+		It("when transferring more than the spending policy allows", func() {
+			// The spending policy only applies to a server of its own.
+			port, err := strconv.Atoi(os.Getenv("PORT"))
+			Expect(err).Should(BeNil())
+			policyPort := strconv.Itoa(port + 1)
+			policyDone := make(chan struct{})
+			defer close(policyDone)
+			go buildServer(NewReceiver(128), policyPort, swap.SpendingPolicy{
+				Limits: map[tokens.Name]swap.SpendingLimit{
+					tokens.NameBTC: {PerTransaction: "1000000"},
+				},
+			}).Run(policyDone)
+			Eventually(func() error {
+				_, err := http.Get(fmt.Sprintf("http://localhost:%s/info", policyPort))
+				return err
+			}).Should(BeNil())
+
+			config := bc.Testnet
+			config.Mnemonic = os.Getenv("MNEMONIC")
+			btcAddr, err := bc.New(config, logger.NewStdOut()).GetAddress("Alice", tokens.BITCOIN)
+			Expect(err).Should(BeNil())
+			data, err := json.Marshal(PostTransfersRequest{
+				Token:  "BTC",
+				To:     btcAddr,
+				Amount: "2000000",
+			})
+			Expect(err).Should(BeNil())
+			req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:%s/transfers", policyPort), bytes.NewBuffer(data))
+			Expect(err).Should(BeNil())
+			req.SetBasicAuth("", "Alice")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusForbidden))
+			violationResp := PolicyViolationResponse{}
+			Expect(json.NewDecoder(resp.Body).Decode(&violationResp)).Should(BeNil())
+			Expect(violationResp.Violation.Rule).Should(Equal(swap.RulePerTransactionLimit))
+			Expect(violationResp.Violation.Token).Should(Equal(tokens.NameBTC))
+		})
+
+		It("when verifying a signature of another swapperd", func() {
+			message := []byte("message")
+			peer := testutils.NewMockSigner()
+			hash := sha3.Sum256(message)
+			sig, err := peer.Sign(hash[:])
+			Expect(err).Should(BeNil())
+
+			postVerify := func(req PostVerifyRequestString) PostVerifyResponse {
+				data, err := json.Marshal(req)
+				Expect(err).Should(BeNil())
+				resp, err := http.Post(fmt.Sprintf("http://localhost:%s/verify/base64", os.Getenv("PORT")), "application/json", bytes.NewBuffer(data))
+				Expect(err).Should(BeNil())
+				Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+				verifyResp := PostVerifyResponse{}
+				Expect(json.NewDecoder(resp.Body).Decode(&verifyResp)).Should(BeNil())
+				return verifyResp
+			}
+
+			req := PostVerifyRequestString{
+				Message:   base64.StdEncoding.EncodeToString(message),
+				Signature: base64.StdEncoding.EncodeToString(sig),
+				PublicKey: crypto.PubkeyToAddress(peer.Key.PublicKey).Hex(),
+			}
+			Expect(postVerify(req).Valid).Should(BeTrue())
+
+			req.Message = base64.StdEncoding.EncodeToString([]byte("forged message"))
+			Expect(postVerify(req).Valid).Should(BeFalse())
+		})
 	})
 
 	AfterSuite(func() {
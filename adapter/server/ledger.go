@@ -0,0 +1,318 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/renproject/swapperd/core/wallet/transfer"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/tokens"
+)
+
+// ReservationTimeout is the time after which a reservation that has not been
+// recorded by a receipt is released. Requests that are rejected by the wallet
+// are never recorded.
+var ReservationTimeout = 10 * time.Minute
+
+// The Ledger holds the reservations of the swaps and transfers that have been
+// posted, until they are recorded by their receipts. Receipts are the ledger
+// of record, so reservations survive restarts once they are recorded. The
+// same Ledger must be shared by everything that posts swaps or transfers.
+type Ledger struct {
+	mu        *sync.Mutex
+	swaps     map[swap.SwapID]reservation
+	transfers []reservation
+
+	// matches caches whether the password hashes of receipts match the
+	// passwords that they have been checked against, so that the bcrypt hash
+	// of a receipt is only checked once for each password.
+	matchesMu *sync.Mutex
+	matches   map[passwordMatch]bool
+}
+
+type passwordMatch struct {
+	receiptHash  string
+	passwordHash string
+}
+
+type reservation struct {
+	token        tokens.Name
+	amount       *big.Int
+	sent         *big.Int // counts towards the spending policy
+	to           string
+	passwordHash string
+	reservedAt   time.Time
+}
+
+// NewLedger returns a Ledger without reservations.
+func NewLedger() *Ledger {
+	return &Ledger{
+		mu:        new(sync.Mutex),
+		swaps:     map[swap.SwapID]reservation{},
+		matchesMu: new(sync.Mutex),
+		matches:   map[passwordMatch]bool{},
+	}
+}
+
+// matchesPassword returns true if the receipt with the given password hash
+// belongs to the password, checking the hash only if it has not been checked
+// against the password before.
+func (ledger *Ledger) matchesPassword(receiptHash, password string) bool {
+	if receiptHash == "" {
+		return true
+	}
+	key := passwordMatch{receiptHash, passwordHash(password)}
+	ledger.matchesMu.Lock()
+	match, ok := ledger.matches[key]
+	ledger.matchesMu.Unlock()
+	if ok {
+		return match
+	}
+
+	match = matchesPassword(receiptHash, password)
+	ledger.matchesMu.Lock()
+	ledger.matches[key] = match
+	ledger.matchesMu.Unlock()
+	return match
+}
+
+// lockLedger locks the ledger, once the receipts of swaps and transfers have
+// been checked against the password. The receipts are checked before the
+// ledger is locked, so that requests do not wait for the bcrypt checks of
+// each other. Receipts that are stored in the meantime are checked while the
+// ledger is locked.
+func (handler *handler) lockLedger(password string) {
+	if receipts, err := handler.storage.Receipts(); err == nil {
+		for _, receipt := range receipts {
+			handler.ledger.matchesPassword(receipt.PasswordHash, password)
+		}
+	}
+	if transfers, err := handler.storage.Transfers(); err == nil {
+		for _, receipt := range transfers {
+			handler.ledger.matchesPassword(receipt.PasswordHash, password)
+		}
+	}
+	handler.ledger.mu.Lock()
+}
+
+// tokenReservations are the amounts of a token that are reserved by swaps that
+// have not sent their funds yet, and that are locked in the contracts of swaps
+// that have.
+type tokenReservations struct {
+	Reserved     *big.Int
+	LockedInHTLC *big.Int
+}
+
+// reserveSwap reserves the send amount of a swap, plus its broker fee, unless
+// the balance that is not reserved cannot cover it, or the swap exceeds the
+// spending policy.
+func (handler *handler) reserveSwap(blob swap.SwapBlob) error {
+	token, err := blockchain.PatchToken(string(blob.SendToken))
+	if err != nil {
+		return err
+	}
+	sendAmount, ok := new(big.Int).SetString(blob.SendAmount, 10)
+	if !ok {
+		return fmt.Errorf("invalid send amount")
+	}
+	amount := withFees(sendAmount, blob.BrokerFee)
+
+	handler.lockLedger(blob.Password)
+	defer handler.ledger.mu.Unlock()
+	if err := handler.verifySpendPolicy(blob.Password, token, sendAmount); err != nil {
+		return err
+	}
+	if err := handler.verifyAvailableBalance(blob.Password, token, amount); err != nil {
+		return err
+	}
+	handler.ledger.swaps[blob.ID] = reservation{
+		token:        token.Name,
+		amount:       amount,
+		sent:         sendAmount,
+		passwordHash: passwordHash(blob.Password),
+		reservedAt:   time.Now(),
+	}
+	return nil
+}
+
+// reserveTransfer reserves the amount of a transfer, unless the balance that
+// is not reserved cannot cover it, or the transfer exceeds the spending policy.
+func (handler *handler) reserveTransfer(password string, token tokens.Token, to string, amount *big.Int, sendAll bool) error {
+	handler.lockLedger(password)
+	defer handler.ledger.mu.Unlock()
+	if err := handler.verifySpendPolicy(password, token, amount); err != nil {
+		return err
+	}
+	if sendAll {
+		// The whole balance is sent, so it can only be sent when none of it
+		// is reserved.
+		reservations, err := handler.reservations(password)
+		if err != nil {
+			return err
+		}
+		if reserved := reservations[token.Name].Reserved; reserved != nil && reserved.Sign() > 0 {
+			return fmt.Errorf("cannot send the whole balance while %s %s is reserved", reserved, token.Name)
+		}
+	} else if err := handler.verifyAvailableBalance(password, token, amount); err != nil {
+		return err
+	}
+	handler.ledger.transfers = append(handler.ledger.transfers, reservation{
+		token:        token.Name,
+		amount:       amount,
+		sent:         amount,
+		to:           to,
+		passwordHash: passwordHash(password),
+		reservedAt:   time.Now(),
+	})
+	return nil
+}
+
+func (handler *handler) releaseSwap(id swap.SwapID) {
+	handler.ledger.mu.Lock()
+	defer handler.ledger.mu.Unlock()
+	delete(handler.ledger.swaps, id)
+}
+
+// verifyAvailableBalance checks that the balance of the token, minus the
+// amounts that are reserved, covers the amount. The ledger must be locked.
+func (handler *handler) verifyAvailableBalance(password string, token tokens.Token, amount *big.Int) error {
+	reservations, err := handler.reservations(password)
+	if err != nil {
+		return err
+	}
+	if reserved := reservations[token.Name].Reserved; reserved != nil && reserved.Sign() > 0 {
+		amount = new(big.Int).Add(amount, reserved)
+	}
+	return handler.wallet.VerifyBalance(password, token, amount)
+}
+
+// reservations returns the reservations of every token of the password, and
+// releases the reservations that have been recorded by finished receipts, or
+// have timed out. The ledger must be locked.
+func (handler *handler) reservations(password string) (map[tokens.Name]tokenReservations, error) {
+	reservations := map[tokens.Name]tokenReservations{}
+	add := func(token tokens.Name, amount *big.Int, locked bool) {
+		res, ok := reservations[token]
+		if !ok {
+			res = tokenReservations{Reserved: big.NewInt(0), LockedInHTLC: big.NewInt(0)}
+		}
+		if locked {
+			res.LockedInHTLC.Add(res.LockedInHTLC, amount)
+		} else {
+			res.Reserved.Add(res.Reserved, amount)
+		}
+		reservations[token] = res
+	}
+	now := time.Now()
+
+	receipts, err := handler.storage.Receipts()
+	if err != nil {
+		return nil, err
+	}
+	recorded := map[swap.SwapID]bool{}
+	for _, receipt := range receipts {
+		recorded[receipt.ID] = true
+		funded := receipt.SendContract.InitiateTxHash != ""
+		if swapFinished(receipt) {
+			delete(handler.ledger.swaps, receipt.ID)
+			continue
+		}
+		if res, ok := handler.ledger.swaps[receipt.ID]; ok {
+			if funded {
+				delete(handler.ledger.swaps, receipt.ID)
+			} else if res.passwordHash == passwordHash(password) {
+				add(res.token, res.amount, false)
+				continue
+			}
+		}
+		if !handler.ledger.matchesPassword(receipt.PasswordHash, password) {
+			continue
+		}
+		amount, ok := new(big.Int).SetString(receipt.SendAmount, 10)
+		if !ok {
+			return nil, fmt.Errorf("corrupted receipt of swap %s", receipt.ID)
+		}
+		add(receipt.SendToken, withFees(amount, receipt.BrokerFee), funded)
+	}
+	for id, res := range handler.ledger.swaps {
+		if recorded[id] {
+			continue
+		}
+		if now.Sub(res.reservedAt) > ReservationTimeout {
+			delete(handler.ledger.swaps, id)
+			continue
+		}
+		if res.passwordHash == passwordHash(password) {
+			add(res.token, res.amount, false)
+		}
+	}
+
+	transfers, err := handler.storage.Transfers()
+	if err != nil {
+		return nil, err
+	}
+	pending := []reservation{}
+	for _, res := range handler.ledger.transfers {
+		if now.Sub(res.reservedAt) > ReservationTimeout || transferRecorded(transfers, res) {
+			continue
+		}
+		pending = append(pending, res)
+		if res.passwordHash == passwordHash(password) {
+			add(res.token, res.amount, false)
+		}
+	}
+	handler.ledger.transfers = pending
+	return reservations, nil
+}
+
+// swapFinished returns true once the funds of a swap are no longer reserved
+// or locked, because it has been redeemed, refunded or cancelled, or failed
+// before its funds were sent.
+func swapFinished(receipt swap.SwapReceipt) bool {
+	switch receipt.Status {
+	case swap.Redeemed, swap.Refunded, swap.Cancelled, swap.Expired:
+		return true
+	case swap.AuditFailed:
+		return receipt.SendContract.InitiateTxHash == ""
+	default:
+		return false
+	}
+}
+
+// transferRecorded returns true if a transfer receipt, that was stored after
+// the reservation, records the transfer of the reservation.
+func transferRecorded(receipts []transfer.TransferReceipt, res reservation) bool {
+	for _, receipt := range receipts {
+		if receipt.Token.Name == res.token && receipt.To == res.to && receipt.Amount == res.amount.String() && receipt.Timestamp >= res.reservedAt.Unix() {
+			return true
+		}
+	}
+	return false
+}
+
+// balanceResponse splits the balance of a token into the amount that is
+// available, and the amount that is reserved.
+func balanceResponse(balance blockchain.Balance, reservations tokenReservations) GetBalanceResponse {
+	resp := GetBalanceResponse{
+		Balance:      balance,
+		Available:    balance.Amount,
+		Reserved:     "0",
+		LockedInHTLC: "0",
+	}
+	if reservations.Reserved == nil {
+		return resp
+	}
+	resp.Reserved = reservations.Reserved.String()
+	resp.LockedInHTLC = reservations.LockedInHTLC.String()
+	if amount, ok := new(big.Int).SetString(balance.Amount, 10); ok {
+		available := new(big.Int).Sub(amount, reservations.Reserved)
+		if available.Sign() < 0 {
+			available = big.NewInt(0)
+		}
+		resp.Available = available.String()
+	}
+	return resp
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/renproject/swapperd/adapter/wallet"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/swapperd/testutils"
+	"github.com/renproject/tokens"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ledgerWallet is a wallet with a fixed balance of every token.
+type ledgerWallet struct {
+	wallet.Wallet
+	balance *big.Int
+	policy  swap.SpendingPolicy
+}
+
+func (wallet *ledgerWallet) SpendingPolicy() swap.SpendingPolicy {
+	return wallet.policy
+}
+
+func (wallet *ledgerWallet) VerifyBalance(password string, token tokens.Token, amount *big.Int) error {
+	if amount != nil && amount.Cmp(wallet.balance) > 0 {
+		return fmt.Errorf("insufficient balance: have %s, need %s", wallet.balance, amount)
+	}
+	return nil
+}
+
+var _ = Describe("Ledger", func() {
+	var storage *testutils.MockStorage
+	var ledger *Ledger
+	var reqHandler *handler
+
+	BeforeEach(func() {
+		storage = testutils.NewMockStorage()
+		ledger = NewLedger()
+		reqHandler = &handler{
+			wallet:  &ledgerWallet{balance: big.NewInt(100000)},
+			storage: storage,
+			ledger:  ledger,
+		}
+	})
+
+	buildSwap := func(amount string, brokerFee int64) swap.SwapBlob {
+		id := [32]byte{}
+		rand.Read(id[:])
+		return swap.SwapBlob{
+			ID:         swap.SwapID(base64.StdEncoding.EncodeToString(id[:])),
+			SendToken:  tokens.NameBTC,
+			SendAmount: amount,
+			BrokerFee:  brokerFee,
+			Password:   "Alice",
+		}
+	}
+
+	balance := func(password string) GetBalanceResponse {
+		ledger.mu.Lock()
+		defer ledger.mu.Unlock()
+		reservations, err := reqHandler.reservations(password)
+		Expect(err).Should(BeNil())
+		return balanceResponse(blockchain.Balance{Amount: "100000"}, reservations[tokens.NameBTC])
+	}
+
+	Context("when reserving swaps", func() {
+		It("should reserve the send amount and the broker fee", func() {
+			Expect(reqHandler.reserveSwap(buildSwap("50000", 100))).Should(BeNil())
+			Expect(balance("Alice").Reserved).Should(Equal("50500"))
+			Expect(balance("Alice").Available).Should(Equal("49500"))
+			Expect(balance("Alice").LockedInHTLC).Should(Equal("0"))
+			Expect(balance("Bob").Reserved).Should(Equal("0"))
+		})
+
+		It("should not reserve more than the balance that is available", func() {
+			Expect(reqHandler.reserveSwap(buildSwap("50000", 100))).Should(BeNil())
+			Expect(reqHandler.reserveSwap(buildSwap("49600", 0))).ShouldNot(BeNil())
+			Expect(reqHandler.reserveSwap(buildSwap("49500", 0))).Should(BeNil())
+		})
+
+		It("should release a swap that is rejected", func() {
+			blob := buildSwap("50000", 100)
+			Expect(reqHandler.reserveSwap(blob)).Should(BeNil())
+			reqHandler.releaseSwap(blob.ID)
+			Expect(balance("Alice").Reserved).Should(Equal("0"))
+		})
+
+		It("should release a swap that is not recorded in time", func() {
+			timeout := ReservationTimeout
+			defer func() { ReservationTimeout = timeout }()
+			ReservationTimeout = time.Nanosecond
+
+			Expect(reqHandler.reserveSwap(buildSwap("50000", 100))).Should(BeNil())
+			time.Sleep(time.Millisecond)
+			Expect(balance("Alice").Reserved).Should(Equal("0"))
+		})
+	})
+
+	Context("when swaps are recorded by their receipts", func() {
+		It("should count the same amount as the reservation until the swap is finished", func() {
+			blob := buildSwap("50000", 100)
+			Expect(reqHandler.reserveSwap(blob)).Should(BeNil())
+
+			receipt := swap.NewSwapReceipt(blob)
+			Expect(storage.PutReceipt(receipt)).Should(BeNil())
+			Expect(balance("Alice").Reserved).Should(Equal("50500"))
+
+			receipt.SendContract.InitiateTxHash = "initiate"
+			receipt.Status = swap.Initiated
+			Expect(storage.PutReceipt(receipt)).Should(BeNil())
+			Expect(balance("Alice").Reserved).Should(Equal("0"))
+			Expect(balance("Alice").LockedInHTLC).Should(Equal("50500"))
+
+			receipt.Status = swap.Refunded
+			Expect(storage.PutReceipt(receipt)).Should(BeNil())
+			Expect(balance("Alice").Reserved).Should(Equal("0"))
+			Expect(balance("Alice").LockedInHTLC).Should(Equal("0"))
+		})
+
+		It("should count the receipts of swaps that were reserved before a restart", func() {
+			receipt := swap.NewSwapReceipt(buildSwap("50000", 100))
+			Expect(storage.PutReceipt(receipt)).Should(BeNil())
+			Expect(balance("Alice").Reserved).Should(Equal("50500"))
+			Expect(reqHandler.reserveSwap(buildSwap("49600", 0))).ShouldNot(BeNil())
+		})
+	})
+
+	Context("when the ledger is shared with the swap builder", func() {
+		It("should not build swaps that spend reserved funds", func() {
+			builder := NewSwapBuilder(reqHandler.wallet, storage, ledger).(*handler)
+			Expect(builder.verifySendAmount("Alice", tokens.BTC, "60000", 0)).Should(BeNil())
+			Expect(reqHandler.reserveSwap(buildSwap("50000", 100))).Should(BeNil())
+			Expect(builder.verifySendAmount("Alice", tokens.BTC, "60000", 0)).ShouldNot(BeNil())
+		})
+	})
+
+	Context("when spending under a daily limit", func() {
+		BeforeEach(func() {
+			reqHandler.wallet = &ledgerWallet{
+				balance: big.NewInt(100000),
+				policy: swap.SpendingPolicy{
+					Limits: map[tokens.Name]swap.SpendingLimit{
+						tokens.NameBTC: {Daily: "50000"},
+					},
+				},
+			}
+		})
+
+		It("should count the swaps and transfers that are in flight", func() {
+			Expect(reqHandler.reserveTransfer("Alice", tokens.BTC, "to", big.NewInt(30000), false)).Should(BeNil())
+			err := reqHandler.reserveSwap(buildSwap("30000", 0))
+			_, ok := err.(swap.PolicyViolation)
+			Expect(ok).Should(BeTrue())
+			Expect(reqHandler.reserveSwap(buildSwap("20000", 0))).Should(BeNil())
+			Expect(reqHandler.reserveTransfer("Bob", tokens.BTC, "to", big.NewInt(30000), false)).Should(BeNil())
+		})
+
+		It("should not exceed the limit with concurrent requests", func() {
+			accepted := int64(0)
+			mu := new(sync.Mutex)
+			wg := new(sync.WaitGroup)
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if reqHandler.reserveSwap(buildSwap("10000", 0)) == nil {
+						mu.Lock()
+						accepted++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			Expect(accepted).Should(Equal(int64(5)))
+		})
+	})
+
+	Context("when reserving transfers", func() {
+		It("should not send the whole balance while some of it is reserved", func() {
+			Expect(reqHandler.reserveTransfer("Alice", tokens.BTC, "to", big.NewInt(30000), false)).Should(BeNil())
+			Expect(balance("Alice").Reserved).Should(Equal("30000"))
+			Expect(reqHandler.reserveTransfer("Alice", tokens.BTC, "to", big.NewInt(100000), true)).ShouldNot(BeNil())
+			Expect(reqHandler.reserveTransfer("Bob", tokens.BTC, "to", big.NewInt(100000), true)).Should(BeNil())
+		})
+	})
+
+	Context("when receipts have password hashes", func() {
+		It("should check each receipt against a password once, before locking the ledger", func() {
+			hash, err := bcrypt.GenerateFromPassword([]byte("Alice"), bcrypt.MinCost)
+			Expect(err).Should(BeNil())
+			blob := buildSwap("50000", 0)
+			blob.PasswordHash = base64.StdEncoding.EncodeToString(hash)
+			Expect(storage.PutReceipt(swap.NewSwapReceipt(blob))).Should(BeNil())
+
+			reqHandler.lockLedger("Alice")
+			ledger.mu.Unlock()
+			Expect(ledger.matches).Should(Equal(map[passwordMatch]bool{
+				{blob.PasswordHash, passwordHash("Alice")}: true,
+			}))
+
+			Expect(balance("Alice").Reserved).Should(Equal("50000"))
+			Expect(balance("Bob").Reserved).Should(Equal("0"))
+			Expect(ledger.matches).Should(Equal(map[passwordMatch]bool{
+				{blob.PasswordHash, passwordHash("Alice")}: true,
+				{blob.PasswordHash, passwordHash("Bob")}:   false,
+			}))
+		})
+	})
+})
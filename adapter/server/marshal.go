@@ -3,6 +3,8 @@ package server
 import (
 	"encoding/json"
 
+	"github.com/renproject/swapperd/adapter/wallet"
+	"github.com/renproject/swapperd/core/wallet/schedule"
 	"github.com/renproject/swapperd/core/wallet/transfer"
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
@@ -10,9 +12,10 @@ import (
 )
 
 type GetInfoResponse struct {
-	Version         string         `json:"version"`
-	Bootloaded      bool           `json:"bootloaded"`
-	SupportedTokens []tokens.Token `json:"supportedTokens"`
+	Version         string              `json:"version"`
+	Bootloaded      bool                `json:"bootloaded"`
+	SupportedTokens []tokens.Token      `json:"supportedTokens"`
+	TimeLockPolicy  swap.TimeLockPolicy `json:"timeLockPolicy"`
 }
 
 type GetSwapsResponse struct {
@@ -21,8 +24,22 @@ type GetSwapsResponse struct {
 
 type GetSwapResponse swap.SwapReceipt
 
-type GetBalanceResponse blockchain.Balance
-type GetBalancesResponse map[tokens.Name]blockchain.Balance
+type GetSwapEventsResponse struct {
+	Events []swap.SwapEvent `json:"events"`
+}
+
+// GetBalanceResponse is the on-chain balance of a token, and the amounts that
+// are reserved by swaps and transfers that have not sent their funds yet, and
+// locked in the contracts of swaps that have. The available amount is the
+// balance that is not reserved.
+type GetBalanceResponse struct {
+	blockchain.Balance
+	Available    string `json:"available"`
+	Reserved     string `json:"reserved"`
+	LockedInHTLC string `json:"lockedInHTLC"`
+}
+
+type GetBalancesResponse map[tokens.Name]GetBalanceResponse
 
 type GetAddressesResponse map[tokens.Name]string
 type GetAddressResponse string
@@ -33,6 +50,114 @@ type PostSwapResponse struct {
 	ID        swap.SwapID   `json:"id"`
 	Swap      swap.SwapBlob `json:"swap,omitempty"`
 	Signature string        `json:"signature,omitempty"`
+	PublicKey string        `json:"publicKey,omitempty"`
+}
+
+// PostOfferRequest is the response of a swapperd that initiates a swap, posted
+// to the response url of the swap.
+type PostOfferRequest PostSwapResponse
+
+type PostOfferResponse struct {
+	ID       swap.SwapID `json:"id"`
+	Accepted bool        `json:"accepted"`
+	SwapID   swap.SwapID `json:"swapId,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type GetOffersResponse struct {
+	Offers []swap.Offer `json:"offers"`
+}
+
+// PutOfferPolicyRequest holds the base64 encoded id public keys of the peers
+// whose offers are accepted automatically, and of the review peers whose
+// offers are stored until they are accepted or rejected.
+type PutOfferPolicyRequest struct {
+	Peers       []string `json:"peers"`
+	ReviewPeers []string `json:"reviewPeers,omitempty"`
+}
+
+// PostAddressBookRequest adds the address of a token's blockchain to the
+// address book.
+type PostAddressBookRequest struct {
+	Token   string `json:"token"`
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+}
+
+type AddressBookEntryResponse struct {
+	wallet.AddressBookEntry
+	Active bool `json:"active"`
+}
+
+type GetAddressBookResponse struct {
+	Entries []AddressBookEntryResponse `json:"entries"`
+}
+
+type PostSwapPreflightResponse struct {
+	Valid          bool                `json:"valid"`
+	Checks         []PreflightCheck    `json:"checks"`
+	TimeLock       int64               `json:"timeLock,omitempty"`
+	TimeLockMargin int64               `json:"timeLockMargin,omitempty"`
+	SendCost       blockchain.CostBlob `json:"sendCost,omitempty"`
+	ReceiveCost    blockchain.CostBlob `json:"receiveCost,omitempty"`
+}
+
+// A PreflightCheck is the result of one of the validations of a new swap.
+type PreflightCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+
+	err error
+}
+
+func NewPreflightCheck(name string, err error) PreflightCheck {
+	check := PreflightCheck{
+		Name:   name,
+		Passed: err == nil,
+		err:    err,
+	}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// preflightError returns the error of the first failed check.
+func preflightError(checks []PreflightCheck) error {
+	for _, check := range checks {
+		if !check.Passed {
+			return check.err
+		}
+	}
+	return nil
+}
+
+// PostSwapFillRequest holds a filled delayed swap, as a json message signed by
+// the broker.
+type PostSwapFillRequest struct {
+	Message   json.RawMessage `json:"message"`
+	Signature string          `json:"signature"`
+}
+
+type GetSchedulesResponse struct {
+	Schedules []schedule.Schedule `json:"schedules"`
+}
+
+type GetScheduleResponse schedule.Schedule
+
+// PostScheduleRequest registers a recurring delayed swap. The swap is the
+// template of every swap of the schedule, the interval is in seconds, and the
+// max total is the maximum total send amount of all its swaps.
+type PostScheduleRequest struct {
+	Swap     PostSwapRequest `json:"swap"`
+	Interval int64           `json:"interval"`
+	MaxTotal string          `json:"maxTotal"`
+	Start    int64           `json:"start,omitempty"`
+}
+
+type PostScheduleResponse struct {
+	ID schedule.ScheduleID `json:"id"`
 }
 
 type PostRedeemSwapResponse struct {
@@ -60,6 +185,30 @@ type GetSignatureResponseString struct {
 	Signature string `json:"signature"`
 }
 
+// A PolicyViolationResponse is returned for swaps and transfers that are
+// rejected by the spending policy.
+type PolicyViolationResponse struct {
+	Error     string               `json:"error"`
+	Violation swap.PolicyViolation `json:"violation"`
+}
+
+type PostVerifyRequestJSON struct {
+	Message   json.RawMessage `json:"message"`
+	Signature string          `json:"signature"`
+	PublicKey string          `json:"publicKey"`
+}
+
+type PostVerifyRequestString struct {
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+	PublicKey string `json:"publicKey"`
+}
+
+type PostVerifyResponse struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
 type GetTransfersResponse struct {
 	Transfers []transfer.TransferReceipt `json:"transfers"`
 }
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/tokens"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// verifySwapPolicy checks a new swap against the spending policy. The amount
+// sent by the swap counts towards the daily limit of the send token.
+func (handler *handler) verifySwapPolicy(blob swap.SwapBlob, sendToken, receiveToken tokens.Token) error {
+	policy := handler.wallet.SpendingPolicy()
+	sendAmount, ok := new(big.Int).SetString(blob.SendAmount, 10)
+	if !ok {
+		return fmt.Errorf("invalid send amount: %s", blob.SendAmount)
+	}
+	receiveAmount, ok := new(big.Int).SetString(blob.ReceiveAmount, 10)
+	if !ok {
+		return fmt.Errorf("invalid receive amount: %s", blob.ReceiveAmount)
+	}
+	if err := policy.CheckSwap(sendToken, receiveToken, sendAmount, receiveAmount, blob.BrokerFee); err != nil {
+		return err
+	}
+	handler.lockLedger(blob.Password)
+	defer handler.ledger.mu.Unlock()
+	return handler.verifySpendPolicy(blob.Password, sendToken, sendAmount)
+}
+
+// verifySpendPolicy checks that sending the amount of the token does not
+// exceed the limits of the spending policy. The ledger must be locked, and
+// stay locked until the amount is reserved, so that concurrent requests
+// cannot exceed the limits together.
+func (handler *handler) verifySpendPolicy(password string, token tokens.Token, amount *big.Int) error {
+	policy := handler.wallet.SpendingPolicy()
+	if _, ok := policy.Limits[token.Name]; !ok {
+		return nil
+	}
+	spent, err := handler.spent(password, token.Name, time.Now().Unix()-swap.SpendingPeriod)
+	if err != nil {
+		return err
+	}
+	return policy.CheckSpend(token.Name, amount, spent)
+}
+
+// spent returns the amount of the token that has been sent by the swaps and
+// transfers of the password since the given time, as recorded by their
+// receipts, and the amount that is reserved by the swaps and transfers that
+// are in flight. Swaps that have been refunded, cancelled or expired did not
+// spend anything. The ledger must be locked.
+func (handler *handler) spent(password string, token tokens.Name, since int64) (*big.Int, error) {
+	spent := big.NewInt(0)
+	inFlight := func(res reservation) bool {
+		return res.token == token && res.passwordHash == passwordHash(password) && time.Since(res.reservedAt) <= ReservationTimeout
+	}
+
+	receipts, err := handler.storage.Receipts()
+	if err != nil {
+		return nil, err
+	}
+	recorded := map[swap.SwapID]bool{}
+	for _, receipt := range receipts {
+		recorded[receipt.ID] = true
+		if receipt.SendToken != token || receipt.Timestamp < since {
+			continue
+		}
+		if receipt.Status == swap.Refunded || receipt.Status == swap.Cancelled || receipt.Status == swap.Expired {
+			continue
+		}
+		if !handler.ledger.matchesPassword(receipt.PasswordHash, password) {
+			continue
+		}
+		amount, ok := new(big.Int).SetString(receipt.SendAmount, 10)
+		if !ok {
+			return nil, fmt.Errorf("corrupted receipt of swap %s", receipt.ID)
+		}
+		spent.Add(spent, amount)
+	}
+	for id, res := range handler.ledger.swaps {
+		if !recorded[id] && inFlight(res) {
+			spent.Add(spent, res.sent)
+		}
+	}
+
+	transfers, err := handler.storage.Transfers()
+	if err != nil {
+		return nil, err
+	}
+	for _, receipt := range transfers {
+		if receipt.Token.Name != token || receipt.Timestamp < since {
+			continue
+		}
+		if !handler.ledger.matchesPassword(receipt.PasswordHash, password) {
+			continue
+		}
+		amount, ok := new(big.Int).SetString(receipt.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("corrupted receipt of transfer %s", receipt.TxHash)
+		}
+		spent.Add(spent, amount)
+	}
+	for _, res := range handler.ledger.transfers {
+		if !transferRecorded(transfers, res) && inFlight(res) {
+			spent.Add(spent, res.sent)
+		}
+	}
+	return spent, nil
+}
+
+// matchesPassword returns true if the receipt with the given password hash
+// belongs to the password. Receipts without a password hash belong to every
+// password.
+func matchesPassword(passwordHash, password string) bool {
+	if passwordHash == "" {
+		return true
+	}
+	hash, err := base64.StdEncoding.DecodeString(passwordHash)
+	if err != nil {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
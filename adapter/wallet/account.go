@@ -6,9 +6,13 @@ import (
 	"crypto/rsa"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/renproject/libbtc-go"
 	"github.com/renproject/libeth-go"
+	"github.com/renproject/swapperd/adapter/binder/utxo"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/tokens"
 	"github.com/tyler-smith/go-bip32"
 	"github.com/tyler-smith/go-bip39"
 )
@@ -58,8 +62,135 @@ func (wallet *wallet) BitcoinAccount(password string) (libbtc.Account, error) {
 	return libbtc.NewAccount(client, privKey, logger), nil
 }
 
+// UTXOAccount returns the account of a Bitcoin fork.
+func (wallet *wallet) UTXOAccount(password string, blockchainName tokens.BlockchainName) (*utxo.Account, error) {
+	chain, config, err := wallet.utxoChain(blockchainName)
+	if err != nil {
+		return nil, err
+	}
+	coinType := uint32(1)
+	if config.Network.Name == "mainnet" {
+		coinType = chain.CoinType
+	}
+	privKey, err := wallet.loadECDSAKey(password, []uint32{44, coinType, 0, 0, 0})
+	if err != nil {
+		return nil, err
+	}
+	backend, err := wallet.UTXOBackend(blockchainName)
+	if err != nil {
+		return nil, err
+	}
+	return utxo.NewAccount(chain, config.Network.Name, backend, privKey)
+}
+
+// UTXOBacked returns true if the blockchain is read and written by a utxo
+// backend. Bitcoin is, unless it uses its default backend.
+func (wallet *wallet) UTXOBacked(blockchainName tokens.BlockchainName) bool {
+	if _, ok := utxo.Chains[blockchainName]; !ok {
+		return false
+	}
+	if blockchainName == tokens.BITCOIN {
+		return wallet.config.Bitcoin.Network.Backend != BackendDefault
+	}
+	return true
+}
+
+// UTXOBackend returns the backend of a utxo chain. The backends are built once,
+// when the wallet is created, so that their connections and watched addresses
+// are shared.
+func (wallet *wallet) UTXOBackend(blockchainName tokens.BlockchainName) (utxo.Backend, error) {
+	if _, _, err := wallet.utxoChain(blockchainName); err != nil {
+		return nil, err
+	}
+	if err := wallet.backendErrs[blockchainName]; err != nil {
+		return nil, err
+	}
+	return wallet.backends[blockchainName], nil
+}
+
+// newUTXOBackend returns the backend of the network of a utxo chain.
+func newUTXOBackend(chain utxo.Chain, network Network) (utxo.Backend, error) {
+	switch network.Backend {
+	case BackendDefault, BackendEsplora:
+		return chain.NewClient(network.Name, network.URL)
+	case BackendBitcoind:
+		net, err := chain.Network(network.Name)
+		if err != nil {
+			return nil, err
+		}
+		if network.URL == "" {
+			return nil, fmt.Errorf("no url configured for the %s backend of %s", network.Backend, chain.Token.Blockchain)
+		}
+		return utxo.NewBitcoindClient(network.URL, network.Username, network.Password, net), nil
+	case BackendElectrum:
+		net, err := chain.Network(network.Name)
+		if err != nil {
+			return nil, err
+		}
+		if network.URL == "" {
+			return nil, fmt.Errorf("no url configured for the %s backend of %s", network.Backend, chain.Token.Blockchain)
+		}
+		return utxo.NewElectrumClient(network.URL, net), nil
+	default:
+		return nil, fmt.Errorf("unsupported %s backend: %s", chain.Token.Blockchain, network.Backend)
+	}
+}
+
+// utxoChain returns the chain of a Bitcoin fork, and its configuration.
+func (wallet *wallet) utxoChain(blockchainName tokens.BlockchainName) (utxo.Chain, BlockchainConfig, error) {
+	chain, err := utxo.ChainOf(blockchainName)
+	if err != nil {
+		return utxo.Chain{}, BlockchainConfig{}, err
+	}
+	config := wallet.blockchainConfig(blockchainName)
+	if config.Network.Name == "" {
+		return utxo.Chain{}, BlockchainConfig{}, fmt.Errorf("%s is not configured", blockchainName)
+	}
+	return chain, config, nil
+}
+
+func (wallet *wallet) blockchainConfig(blockchainName tokens.BlockchainName) BlockchainConfig {
+	switch blockchainName {
+	case tokens.BITCOIN:
+		return wallet.config.Bitcoin
+	case tokens.ETHEREUM, tokens.ERC20:
+		return wallet.config.Ethereum
+	case blockchain.LITECOIN:
+		return wallet.config.Litecoin
+	case blockchain.BITCOINCASH:
+		return wallet.config.BitcoinCash
+	default:
+		return BlockchainConfig{}
+	}
+}
+
 func (wallet *wallet) ethereumClient() (libeth.Client, error) {
-	return libeth.NewInfuraClient(wallet.config.Ethereum.Network.Name, "172978c53e244bd78388e6d50a4ae2fa")
+	client, err := wallet.newEthereumClient()
+	if err != nil {
+		return nil, err
+	}
+	// The contracts of the registered ERC20 tokens are resolved through the
+	// address book of the client, like those of the tokens package.
+	for _, token := range blockchain.ERC20Tokens() {
+		client.WriteAddress(string(token.Name), common.HexToAddress(token.Address))
+		client.WriteAddress(fmt.Sprintf("%sSwap", token.Name), common.HexToAddress(token.SwapAddress))
+	}
+	return client, nil
+}
+
+func (wallet *wallet) newEthereumClient() (libeth.Client, error) {
+	network := wallet.config.Ethereum.Network
+	switch network.Backend {
+	case BackendDefault, BackendInfura:
+		return libeth.NewInfuraClient(network.Name, "172978c53e244bd78388e6d50a4ae2fa")
+	case BackendJSONRPC:
+		if network.URL == "" {
+			return nil, fmt.Errorf("no url configured for the %s backend of ethereum", network.Backend)
+		}
+		return libeth.NewClient(network.URL)
+	default:
+		return nil, fmt.Errorf("unsupported ethereum backend: %s", network.Backend)
+	}
 }
 
 func (wallet *wallet) bitcoinClient() (libbtc.Client, error) {
@@ -1 +1,77 @@
 package wallet_test
+
+import (
+	"context"
+
+	"github.com/renproject/swapperd/adapter/binder/utxo"
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/tokens"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/wallet"
+)
+
+var _ = Describe("UTXO accounts", func() {
+	newWallet := func(litecoin, bitcoinCash Network) Wallet {
+		return New(Config{
+			Mnemonic:    "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			Bitcoin:     BlockchainConfig{Network: Network{Name: "testnet"}},
+			Litecoin:    BlockchainConfig{Network: litecoin},
+			BitcoinCash: BlockchainConfig{Network: bitcoinCash},
+		}, logrus.StandardLogger())
+	}
+
+	Context("when getting the backend of a chain", func() {
+		It("should share the backend with every account", func() {
+			wallet := newWallet(Network{Name: "testnet", URL: "http://127.0.0.1:18332", Backend: BackendBitcoind}, Network{})
+			backend, err := wallet.UTXOBackend(blockchain.LITECOIN)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(backend).Should(BeAssignableToTypeOf(&utxo.BitcoindClient{}))
+
+			again, err := wallet.UTXOBackend(blockchain.LITECOIN)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(again).Should(BeIdenticalTo(backend))
+			for _, password := range []string{"alice", "bob"} {
+				account, err := wallet.UTXOAccount(password, blockchain.LITECOIN)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(account.Backend).Should(BeIdenticalTo(backend))
+			}
+		})
+
+		It("should build the configured backend", func() {
+			wallet := newWallet(Network{Name: "testnet"}, Network{Name: "testnet", URL: "tcp://127.0.0.1:50001", Backend: BackendElectrum})
+			backend, err := wallet.UTXOBackend(tokens.BITCOIN)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(backend).Should(BeAssignableToTypeOf(&utxo.Client{}))
+			backend, err = wallet.UTXOBackend(blockchain.LITECOIN)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(backend).Should(BeAssignableToTypeOf(&utxo.Client{}))
+			backend, err = wallet.UTXOBackend(blockchain.BITCOINCASH)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(backend).Should(BeAssignableToTypeOf(&utxo.ElectrumClient{}))
+		})
+
+		It("should close the connections of the backends", func() {
+			wallet := newWallet(Network{Name: "testnet"}, Network{Name: "testnet", URL: "tcp://127.0.0.1:50001", Backend: BackendElectrum})
+			backend, err := wallet.UTXOBackend(blockchain.BITCOINCASH)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(wallet.Close()).Should(Succeed())
+			_, err = backend.Confirmations(context.Background(), "")
+			Expect(err).Should(Equal(utxo.ErrElectrumClientClosed))
+		})
+
+		It("should not return the backend of a misconfigured or unconfigured chain", func() {
+			wallet := newWallet(Network{Name: "testnet", Backend: BackendBitcoind}, Network{})
+			_, err := wallet.UTXOBackend(blockchain.LITECOIN)
+			Expect(err).Should(HaveOccurred())
+			_, err = wallet.UTXOAccount("alice", blockchain.LITECOIN)
+			Expect(err).Should(HaveOccurred())
+			_, err = wallet.UTXOBackend(blockchain.BITCOINCASH)
+			Expect(err).Should(HaveOccurred())
+			_, err = wallet.UTXOBackend(tokens.ETHEREUM)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -30,6 +30,9 @@ func (wallet *wallet) Addresses(password string) (map[tokens.Name]string, error)
 }
 
 func (wallet *wallet) GetAddress(password string, blockchainName tokens.BlockchainName) (string, error) {
+	if wallet.UTXOBacked(blockchainName) {
+		return wallet.getUTXOAddress(password, blockchainName)
+	}
 	switch blockchainName {
 	case tokens.ETHEREUM, tokens.ERC20:
 		return wallet.getEthereumAddress(password)
@@ -60,7 +63,18 @@ func (wallet *wallet) getBitcoinAddress(password string) (string, error) {
 	return btcAddr.String(), nil
 }
 
+func (wallet *wallet) getUTXOAddress(password string, blockchainName tokens.BlockchainName) (string, error) {
+	account, err := wallet.UTXOAccount(password, blockchainName)
+	if err != nil {
+		return "", err
+	}
+	return account.EncodedAddress()
+}
+
 func (wallet *wallet) VerifyAddress(blockchainName tokens.BlockchainName, address string) error {
+	if wallet.UTXOBacked(blockchainName) {
+		return wallet.verifyUTXOAddress(blockchainName, address)
+	}
 	switch blockchainName {
 	case tokens.ETHEREUM, tokens.ERC20:
 		return wallet.verifyEthereumAddress(address)
@@ -107,3 +121,22 @@ func (wallet *wallet) verifyBitcoinAddress(address string) error {
 	}
 	return nil
 }
+
+func (wallet *wallet) verifyUTXOAddress(blockchainName tokens.BlockchainName, address string) error {
+	if address == "" {
+		return fmt.Errorf("Empty %s address", blockchainName)
+	}
+
+	chain, config, err := wallet.utxoChain(blockchainName)
+	if err != nil {
+		return err
+	}
+	network, err := chain.Network(config.Network.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := network.DecodeAddress(address); err != nil {
+		return fmt.Errorf("Invalid %s %s address: %s", config.Network.Name, blockchainName, address)
+	}
+	return nil
+}
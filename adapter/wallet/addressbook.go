@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/renproject/tokens"
+)
+
+// DefaultAddressBookDelay is the number of seconds after which new address
+// book entries can be used, unless the delay is configured.
+const DefaultAddressBookDelay = int64(24 * time.Hour / time.Second)
+
+// AddressBookConfig configures the address book of withdrawal addresses.
+type AddressBookConfig struct {
+	// Delay is the number of seconds after which new entries can be used. It
+	// gives the owners of the wallet time to notice, and remove, entries
+	// added by a compromised client.
+	Delay int64 `json:"delay"`
+
+	// Restricted limits the destinations of transfers, and the withdrawal
+	// addresses of swaps, to the active entries of the address book.
+	Restricted bool `json:"restricted"`
+}
+
+// An AddressBookEntry is an address that funds can be withdrawn to, once it
+// is active.
+type AddressBookEntry struct {
+	ID           string                `json:"id"`
+	Blockchain   tokens.BlockchainName `json:"blockchain"`
+	Address      string                `json:"address"`
+	Label        string                `json:"label,omitempty"`
+	CreatedAt    int64                 `json:"createdAt"`
+	ActiveAt     int64                 `json:"activeAt"`
+	PasswordHash string                `json:"passwordHash,omitempty"`
+}
+
+// Active returns true if the entry can be used at the given unix time.
+func (entry AddressBookEntry) Active(now int64) bool {
+	return now >= entry.ActiveAt
+}
+
+func (wallet *wallet) NewAddressBookEntry(blockchain tokens.BlockchainName, address, label string) (AddressBookEntry, error) {
+	if err := wallet.VerifyAddress(blockchain, address); err != nil {
+		return AddressBookEntry{}, err
+	}
+	id := [32]byte{}
+	if _, err := rand.Read(id[:]); err != nil {
+		return AddressBookEntry{}, err
+	}
+	delay := wallet.config.AddressBook.Delay
+	if delay <= 0 {
+		delay = DefaultAddressBookDelay
+	}
+	now := time.Now().Unix()
+	return AddressBookEntry{
+		ID:         base64.StdEncoding.EncodeToString(id[:]),
+		Blockchain: blockchain,
+		Address:    address,
+		Label:      label,
+		CreatedAt:  now,
+		ActiveAt:   now + delay,
+	}, nil
+}
+
+func (wallet *wallet) AddressBookRestricted() bool {
+	return wallet.config.AddressBook.Restricted
+}
+
+// VerifyAddressBook returns an error unless the address of the blockchain is
+// one of the active entries.
+func VerifyAddressBook(entries []AddressBookEntry, blockchain tokens.BlockchainName, address string) error {
+	now := time.Now().Unix()
+	for _, entry := range entries {
+		if entry.Blockchain != blockchain || entry.Address != address {
+			continue
+		}
+		if !entry.Active(now) {
+			return fmt.Errorf("address %s is in the address book but not active until %s", address, time.Unix(entry.ActiveAt, 0).UTC().Format(time.RFC3339))
+		}
+		return nil
+	}
+	return fmt.Errorf("address %s is not in the address book", address)
+}
@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/renproject/swapperd/adapter/binder/utxo"
+	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/tokens"
 )
 
 func (wallet *wallet) VerifyBalance(password string, token tokens.Token, amount *big.Int) error {
+	if wallet.UTXOBacked(token.Blockchain) {
+		return wallet.verifyUTXOBalance(password, token, amount)
+	}
 	switch token.Blockchain {
 	case tokens.ETHEREUM:
 		return wallet.verifyEthereumBalance(password, amount)
@@ -63,7 +68,7 @@ func (wallet *wallet) verifyERC20Balance(password string, token tokens.Token, am
 		}
 
 		expectedAmount := amount
-		if extraFee := token.AdditionalTransactionFee(erc20Amount); extraFee != nil {
+		if extraFee := blockchain.AdditionalTransactionFee(token, erc20Amount); extraFee != nil {
 			expectedAmount = new(big.Int).Add(amount, extraFee)
 		}
 
@@ -106,3 +111,36 @@ func (wallet *wallet) verifyBitcoinBalance(password string, amount *big.Int) err
 	}
 	return nil
 }
+
+func (wallet *wallet) verifyUTXOBalance(password string, token tokens.Token, amount *big.Int) error {
+	if amount == nil {
+		return nil
+	}
+
+	chain, _, err := wallet.utxoChain(token.Blockchain)
+	if err != nil {
+		return err
+	}
+	dust := big.NewInt(chain.Dust)
+	if amount.Cmp(dust) < 0 {
+		return fmt.Errorf("invalid %s amount: minimum swappable amount is %s", token.Name, dust)
+	}
+
+	balance, err := wallet.Balance(password, token)
+	if err != nil {
+		return err
+	}
+	balanceAmount, ok := big.NewInt(0).SetString(balance.Amount, 10)
+	if !ok {
+		return fmt.Errorf("Invalid balance amount: %s", balance.Amount)
+	}
+
+	// The remaining balance must cover the fees of initiating and refunding
+	// the swap, and leave more than dust.
+	fee := big.NewInt(chain.FeeRate(blockchain.Fast) * (utxo.InitiateTxSize + utxo.RedeemTxSize))
+	leftover := new(big.Int).Sub(balanceAmount, amount)
+	if leftover.Cmp(new(big.Int).Add(fee, dust)) < 0 {
+		return fmt.Errorf("You need at least %s %s remaining in your wallet to cover transaction fees. You have: %v", new(big.Int).Add(fee, dust), token.Name, leftover)
+	}
+	return nil
+}
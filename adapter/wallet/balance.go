@@ -37,6 +37,9 @@ func (wallet *wallet) Balance(password string, token tokens.Token) (blockchain.B
 		return blockchain.Balance{}, err
 	}
 
+	if wallet.UTXOBacked(token.Blockchain) {
+		return wallet.balanceUTXO(token, address)
+	}
 	switch token.Blockchain {
 	case tokens.BITCOIN:
 		return wallet.balanceBTC(address)
@@ -70,6 +73,27 @@ func (wallet *wallet) balanceBTC(address string) (blockchain.Balance, error) {
 	}, nil
 }
 
+func (wallet *wallet) balanceUTXO(token tokens.Token, address string) (blockchain.Balance, error) {
+	client, err := wallet.UTXOBackend(token.Blockchain)
+	if err != nil {
+		return blockchain.Balance{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	balance, err := client.Balance(ctx, address)
+	if err != nil {
+		return blockchain.Balance{}, err
+	}
+
+	return blockchain.Balance{
+		Address:  address,
+		Decimals: int(token.Decimals),
+		Amount:   big.NewInt(balance).String(),
+	}, nil
+}
+
 func (wallet *wallet) balanceETH(address string) (blockchain.Balance, error) {
 	client, err := wallet.ethereumClient()
 	if err != nil {
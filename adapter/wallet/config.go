@@ -1,18 +1,29 @@
 package wallet
 
+import "github.com/renproject/swapperd/foundation/swap"
+
 // Testnet is the Swapperd's testnet config object
 var Testnet = Config{
 	Bitcoin: BlockchainConfig{
 		Network: Network{
 			Name: "testnet",
 		},
+		Confirmations: 1,
 	},
 	Ethereum: BlockchainConfig{
 		Network: Network{
 			Name: "kovan",
 			URL:  "https://kovan.infura.io",
 		},
+		Confirmations: 1,
+	},
+	Litecoin: BlockchainConfig{
+		Network: Network{
+			Name: "testnet",
+		},
+		Confirmations: 2,
 	},
+	DelayedSwapDeadline: swap.ExpiryUnit,
 }
 
 // Mainnet is the Swapperd's mainnet config object
@@ -21,11 +32,20 @@ var Mainnet = Config{
 		Network: Network{
 			Name: "mainnet",
 		},
+		Confirmations: 6,
 	},
 	Ethereum: BlockchainConfig{
 		Network: Network{
 			Name: "mainnet",
 			URL:  "https://mainnet.infura.io",
 		},
+		Confirmations: 12,
+	},
+	Litecoin: BlockchainConfig{
+		Network: Network{
+			Name: "mainnet",
+		},
+		Confirmations: 12,
 	},
+	DelayedSwapDeadline: swap.ExpiryUnit,
 }
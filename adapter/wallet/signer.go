@@ -1,11 +1,15 @@
 package wallet
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"encoding/base64"
+	"fmt"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
 )
 
 type ECDSASigner interface {
@@ -39,3 +43,31 @@ func (wallet *wallet) ID(password, idType string) (string, error) {
 		return base64.StdEncoding.EncodeToString(crypto.FromECDSAPub(&pubKey)), nil
 	}
 }
+
+// VerifySignature checks that the signature of the message was produced by
+// the owner of the public key, which is either base64 encoded like the default
+// swapperd id, or an ethereum address like the ethereum swapperd id. Messages
+// are signed over their sha3 hash, like the messages signed by swapperd.
+func VerifySignature(publicKey string, message, signature []byte) error {
+	hash := sha3.Sum256(message)
+	signer, err := crypto.SigToPub(hash[:], signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	if common.IsHexAddress(publicKey) {
+		if crypto.PubkeyToAddress(*signer) != common.HexToAddress(publicKey) {
+			return fmt.Errorf("signature does not match the address")
+		}
+		return nil
+	}
+
+	pubKeyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+	if !bytes.Equal(crypto.FromECDSAPub(signer), pubKeyBytes) {
+		return fmt.Errorf("signature does not match the public key")
+	}
+	return nil
+}
@@ -1,9 +1,25 @@
 package wallet
 
 import (
+	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/tokens"
 )
 
+// SupportedTokens returns the tokens of the tokens package, the tokens of the
+// Bitcoin forks that are configured, and the registered ERC20 tokens.
 func (wallet *wallet) SupportedTokens() []tokens.Token {
-	return tokens.SupportedTokens
+	supported := append([]tokens.Token{}, tokens.SupportedTokens...)
+	for _, token := range blockchain.UTXOTokens {
+		if wallet.blockchainConfig(token.Blockchain).Network.Name != "" {
+			supported = append(supported, token)
+		}
+	}
+	for _, token := range blockchain.ERC20Tokens() {
+		supported = append(supported, token.Token())
+	}
+	return supported
+}
+
+func (wallet *wallet) Confirmations(blockchain tokens.BlockchainName) int64 {
+	return wallet.blockchainConfig(blockchain).Confirmations
 }
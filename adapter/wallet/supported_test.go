@@ -1 +1,52 @@
 package wallet_test
+
+import (
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/tokens"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/adapter/wallet"
+)
+
+var _ = Describe("Supported tokens", func() {
+	Context("when tokens are registered", func() {
+		It("should support the registered erc20 tokens", func() {
+			token := blockchain.ERC20Token{
+				Name:        "SUPP",
+				Aliases:     []string{"supported"},
+				Decimals:    6,
+				Address:     "0x1111111111111111111111111111111111111111",
+				SwapAddress: "0x2222222222222222222222222222222222222222",
+			}
+			wallet := New(Config{}, logrus.StandardLogger())
+			Expect(wallet.SupportedTokens()).ShouldNot(ContainElement(token.Token()))
+
+			Expect(blockchain.RegisterERC20Token(token)).Should(Succeed())
+			supported := wallet.SupportedTokens()
+			Expect(supported).Should(ContainElement(tokens.Token{Name: "SUPP", Decimals: 6, Blockchain: tokens.ERC20}))
+			for _, token := range tokens.SupportedTokens {
+				Expect(supported).Should(ContainElement(token))
+			}
+		})
+
+		It("should not support tokens that fail to register", func() {
+			token := blockchain.ERC20Token{
+				Name:        "UNSUPP",
+				Address:     "0x1234",
+				SwapAddress: "0x2222222222222222222222222222222222222222",
+			}
+			Expect(blockchain.RegisterERC20Token(token)).ShouldNot(Succeed())
+			Expect(New(Config{}, logrus.StandardLogger()).SupportedTokens()).ShouldNot(ContainElement(token.Token()))
+		})
+	})
+
+	Context("when utxo chains are configured", func() {
+		It("should only support the tokens of the configured chains", func() {
+			supported := New(Config{Litecoin: BlockchainConfig{Network: Network{Name: "testnet"}}}, logrus.StandardLogger()).SupportedTokens()
+			Expect(supported).Should(ContainElement(blockchain.LTC))
+			Expect(supported).ShouldNot(ContainElement(blockchain.BCH))
+		})
+	})
+})
@@ -0,0 +1,20 @@
+package wallet
+
+import (
+	"github.com/renproject/swapperd/foundation/swap"
+)
+
+func (wallet *wallet) TimeLockPolicy() swap.TimeLockPolicy {
+	return wallet.config.TimeLocks.WithDefaults()
+}
+
+func (wallet *wallet) SpendingPolicy() swap.SpendingPolicy {
+	return wallet.config.Policy
+}
+
+func (wallet *wallet) DelayedSwapDeadline() int64 {
+	if wallet.config.DelayedSwapDeadline <= 0 {
+		return swap.ExpiryUnit
+	}
+	return wallet.config.DelayedSwapDeadline
+}
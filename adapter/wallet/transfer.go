@@ -14,6 +14,9 @@ import (
 )
 
 func (wallet *wallet) Transfer(password string, token tokens.Token, to string, amount *big.Int, speed blockchain.TxExecutionSpeed, sendAll bool) (string, blockchain.Cost, error) {
+	if wallet.UTXOBacked(token.Blockchain) {
+		return wallet.transferUTXO(password, token, to, amount, speed, sendAll)
+	}
 	switch token.Blockchain {
 	case tokens.BITCOIN:
 		return wallet.transferBTC(password, to, amount, speed, sendAll)
@@ -45,6 +48,25 @@ func (wallet *wallet) transferBTC(password, to string, amount *big.Int, speed bl
 	return txHash, cost, nil
 }
 
+func (wallet *wallet) transferUTXO(password string, token tokens.Token, to string, amount *big.Int, speed blockchain.TxExecutionSpeed, sendAll bool) (string, blockchain.Cost, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	cost := blockchain.Cost{}
+	account, err := wallet.UTXOAccount(password, token.Blockchain)
+	if err != nil {
+		return "", blockchain.Cost{}, err
+	}
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	txHash, txFee, err := account.Transfer(ctx, to, amount.Int64(), speed, sendAll)
+	if err != nil {
+		return txHash, blockchain.Cost{}, err
+	}
+	cost[token.Name] = big.NewInt(txFee)
+	return txHash, cost, nil
+}
+
 func (wallet *wallet) transferETH(password, to string, amount *big.Int, speed blockchain.TxExecutionSpeed, sendAll bool) (string, blockchain.Cost, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
@@ -81,13 +103,16 @@ func (wallet *wallet) transferERC20(password string, token tokens.Token, to stri
 		return "", cost, err
 	}
 	cost[tokens.NameETH] = tx.Cost()
-	if txFee := token.AdditionalTransactionFee(amount); txFee != nil {
+	if txFee := blockchain.AdditionalTransactionFee(token, amount); txFee != nil {
 		cost[token.Name] = txFee
 	}
 	return tx.Hash().String(), cost, nil
 }
 
 func (wallet *wallet) Lookup(token tokens.Token, txHash string) (transfer.UpdateReceipt, error) {
+	if wallet.UTXOBacked(token.Blockchain) {
+		return wallet.utxoLookup(token.Blockchain, txHash)
+	}
 	switch token.Blockchain {
 	case tokens.BITCOIN:
 		return wallet.bitcoinLookup(txHash)
@@ -141,3 +166,22 @@ func (wallet *wallet) bitcoinLookup(txHash string) (transfer.UpdateReceipt, erro
 		receipt.Confirmations = confirmations
 	}), nil
 }
+
+func (wallet *wallet) utxoLookup(blockchainName tokens.BlockchainName, txHash string) (transfer.UpdateReceipt, error) {
+	client, err := wallet.UTXOBackend(blockchainName)
+	if err != nil {
+		return transfer.UpdateReceipt{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	confirmations, err := client.Confirmations(ctx, txHash)
+	if err != nil {
+		return transfer.UpdateReceipt{}, err
+	}
+
+	return transfer.NewUpdateReceipt(txHash, func(receipt *transfer.TransferReceipt) {
+		receipt.Confirmations = confirmations
+	}), nil
+}
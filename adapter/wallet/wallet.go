@@ -1,31 +1,78 @@
 package wallet
 
 import (
+	"io"
 	"math/big"
 
 	"github.com/renproject/libbtc-go"
 	"github.com/renproject/libeth-go"
+	"github.com/renproject/swapperd/adapter/binder/utxo"
 	"github.com/renproject/swapperd/core/wallet/transfer"
 	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/foundation/swap"
 	"github.com/renproject/tokens"
 	"github.com/sirupsen/logrus"
 )
 
 type Config struct {
-	Mnemonic string           `json:"mnemonic"`
-	Ethereum BlockchainConfig `json:"ethereum"`
-	Bitcoin  BlockchainConfig `json:"bitcoin"`
+	Mnemonic  string              `json:"mnemonic"`
+	Ethereum  BlockchainConfig    `json:"ethereum"`
+	Bitcoin   BlockchainConfig    `json:"bitcoin"`
+	TimeLocks swap.TimeLockPolicy `json:"timeLocks"`
+
+	// Litecoin and BitcoinCash are supported once their networks are
+	// configured. The url of a network is the Esplora API that is used, which
+	// is required for Bitcoin Cash.
+	Litecoin    BlockchainConfig `json:"litecoin"`
+	BitcoinCash BlockchainConfig `json:"bitcoinCash"`
+
+	// Tokens are the ERC20 tokens that are supported in addition to those of
+	// the tokens package. They are registered when the keystore is loaded.
+	Tokens []blockchain.ERC20Token `json:"tokens,omitempty"`
+
+	// DelayedSwapDeadline is the number of seconds that delayed swaps have to
+	// be filled, unless they are created with a deadline.
+	DelayedSwapDeadline int64 `json:"delayedSwapDeadline"`
+
+	// Policy guards the swaps and transfers of every password, so that a
+	// misbehaving client cannot drain the wallets.
+	Policy swap.SpendingPolicy `json:"policy"`
+
+	AddressBook AddressBookConfig `json:"addressBook"`
 }
 
 type BlockchainConfig struct {
 	Network Network `json:"network"`
+
+	// Confirmations is the number of confirmations that the counterparty's
+	// funding of a swap needs before the swap proceeds.
+	Confirmations int64 `json:"confirmations"`
 }
 
 type Network struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
+
+	// Backend selects the API that is used to read and write to the network,
+	// at the url. Username and Password authenticate to backends that need
+	// credentials.
+	Backend  string `json:"backend,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
 }
 
+// Backends of networks. The default backend of Ethereum is Infura, and that of
+// Bitcoin is blockchain.info on mainnet and Mercury on testnet. The default
+// backend of Bitcoin forks is Esplora.
+const (
+	BackendDefault  = ""
+	BackendInfura   = "infura"
+	BackendJSONRPC  = "jsonrpc"
+	BackendEsplora  = "esplora"
+	BackendBitcoind = "bitcoind"
+	BackendElectrum = "electrum"
+)
+
 type Balance struct {
 	Address string
 	Amount  *big.Int
@@ -34,6 +81,10 @@ type Balance struct {
 type Wallet interface {
 	ID(password, idType string) (string, error)
 	SupportedTokens() []tokens.Token
+	TimeLockPolicy() swap.TimeLockPolicy
+	DelayedSwapDeadline() int64
+	SpendingPolicy() swap.SpendingPolicy
+	Confirmations(blockchain tokens.BlockchainName) int64
 	Balances(password string) (map[tokens.Name]blockchain.Balance, error)
 	Balance(password string, token tokens.Token) (blockchain.Balance, error)
 	Lookup(token tokens.Token, txHash string) (transfer.UpdateReceipt, error)
@@ -42,20 +93,58 @@ type Wallet interface {
 	Addresses(password string) (map[tokens.Name]string, error)
 	VerifyAddress(blockchain tokens.BlockchainName, address string) error
 	VerifyBalance(password string, token tokens.Token, balance *big.Int) error
+	NewAddressBookEntry(blockchain tokens.BlockchainName, address, label string) (AddressBookEntry, error)
+	AddressBookRestricted() bool
 
 	EthereumAccount(password string) (libeth.Account, error)
 	BitcoinAccount(password string) (libbtc.Account, error)
+	UTXOAccount(password string, blockchainName tokens.BlockchainName) (*utxo.Account, error)
+	UTXOBackend(blockchainName tokens.BlockchainName) (utxo.Backend, error)
+	UTXOBacked(blockchainName tokens.BlockchainName) bool
 	ECDSASigner(password string) (ECDSASigner, error)
+
+	// Close closes the connections of the backends.
+	Close() error
 }
 
 type wallet struct {
 	config Config
 	logger logrus.FieldLogger
+
+	// backends are the backends of the configured utxo chains, that are
+	// shared by all accounts, and backendErrs the errors of those that are
+	// misconfigured.
+	backends    map[tokens.BlockchainName]utxo.Backend
+	backendErrs map[tokens.BlockchainName]error
 }
 
 func New(config Config, logger logrus.FieldLogger) Wallet {
-	return &wallet{
-		config: config,
-		logger: logger,
+	wallet := &wallet{
+		config:      config,
+		logger:      logger,
+		backends:    map[tokens.BlockchainName]utxo.Backend{},
+		backendErrs: map[tokens.BlockchainName]error{},
+	}
+	for blockchainName, chain := range utxo.Chains {
+		network := wallet.blockchainConfig(blockchainName).Network
+		if network.Name == "" {
+			continue
+		}
+		wallet.backends[blockchainName], wallet.backendErrs[blockchainName] = newUTXOBackend(chain, network)
+	}
+	return wallet
+}
+
+func (wallet *wallet) Close() error {
+	var closeErr error
+	for _, backend := range wallet.backends {
+		closer, ok := backend.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			closeErr = err
+		}
 	}
+	return closeErr
 }
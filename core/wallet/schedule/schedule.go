@@ -0,0 +1,274 @@
+package schedule
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/republicprotocol/tau"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinInterval is the shortest interval between the swaps of a schedule.
+const MinInterval = int64(time.Hour / time.Second)
+
+// RetryDelay is the time after which a swap that could not be built is tried
+// again, within the same period.
+const RetryDelay = int64(10 * time.Minute / time.Second)
+
+type Storage interface {
+	PutSchedule(schedule Schedule) error
+	DeleteSchedule(id ScheduleID) error
+	Schedules() ([]Schedule, error)
+}
+
+// A SwapBuilder builds a new delayed swap from the template of a schedule,
+// with its own id, secret and timelocks.
+type SwapBuilder interface {
+	BuildSwap(template swap.SwapBlob) (swap.SwapBlob, error)
+}
+
+type schedules struct {
+	builder  SwapBuilder
+	storage  Storage
+	active   map[ScheduleID]Schedule
+	retryAts map[ScheduleID]int64
+}
+
+// New returns a task that emits a delayed swap for every period of the active
+// schedules. Schedules are active once the password that created them has
+// been bootloaded.
+func New(cap int, builder SwapBuilder, storage Storage) tau.Task {
+	return tau.New(tau.NewIO(cap), &schedules{builder, storage, map[ScheduleID]Schedule{}, map[ScheduleID]int64{}})
+}
+
+func (schedules *schedules) Reduce(msg tau.Message) tau.Message {
+	switch msg := msg.(type) {
+	case Bootload:
+		return schedules.handleBootload(msg)
+	case ScheduleRequest:
+		return schedules.handleScheduleRequest(Schedule(msg))
+	case UpdateSchedule:
+		return schedules.handleUpdateSchedule(msg)
+	case CancelSchedule:
+		return schedules.handleCancelSchedule(msg.ID)
+	case tau.Tick:
+		return schedules.handleTick()
+	default:
+		return tau.NewError(fmt.Errorf("invalid message type in schedules: %T", msg))
+	}
+}
+
+func (schedules *schedules) handleBootload(msg Bootload) tau.Message {
+	stored, err := schedules.storage.Schedules()
+	if err != nil {
+		return tau.NewError(err)
+	}
+	for _, schedule := range stored {
+		if schedule.Completed {
+			continue
+		}
+		if _, ok := schedules.active[schedule.ID]; ok {
+			continue
+		}
+		hash, err := base64.StdEncoding.DecodeString(schedule.Template.PasswordHash)
+		if err != nil || bcrypt.CompareHashAndPassword(hash, []byte(msg.Password)) != nil {
+			continue
+		}
+		schedule.Template.Password = msg.Password
+		schedules.active[schedule.ID] = schedule
+	}
+	return nil
+}
+
+func (schedules *schedules) handleScheduleRequest(schedule Schedule) tau.Message {
+	if schedule.Sent == "" {
+		schedule.Sent = "0"
+	}
+	return schedules.put(schedule)
+}
+
+func (schedules *schedules) handleUpdateSchedule(msg UpdateSchedule) tau.Message {
+	schedule, ok := schedules.active[msg.ID]
+	if !ok {
+		return tau.NewError(fmt.Errorf("cannot update schedule %s: schedule is not active", msg.ID))
+	}
+	password := schedule.Template.Password
+	schedule.Template = msg.Template
+	schedule.Template.Password = password
+	schedule.Interval = msg.Interval
+	schedule.MaxTotal = msg.MaxTotal
+	schedule.DelayDuration = msg.DelayDuration
+	return schedules.put(schedule)
+}
+
+func (schedules *schedules) handleCancelSchedule(id ScheduleID) tau.Message {
+	delete(schedules.active, id)
+	delete(schedules.retryAts, id)
+	if err := schedules.storage.DeleteSchedule(id); err != nil {
+		return tau.NewError(fmt.Errorf("cannot cancel schedule %s: %v", id, err))
+	}
+	return nil
+}
+
+func (schedules *schedules) handleTick() tau.Message {
+	now := time.Now().Unix()
+	messages := []tau.Message{}
+	for id, schedule := range schedules.active {
+		if now < schedule.NextSwap || now < schedules.retryAts[id] {
+			continue
+		}
+		if msg := schedules.handleDueSchedule(schedule, now); msg != nil {
+			messages = append(messages, msg)
+		}
+	}
+	return tau.NewMessageBatch(messages)
+}
+
+// handleDueSchedule emits the swap of the current period of a schedule. Swaps
+// that cannot be built are retried after the RetryDelay, and periods that were
+// missed while swapperd was not running are skipped.
+func (schedules *schedules) handleDueSchedule(schedule Schedule, now int64) tau.Message {
+	if !schedule.hasNext() {
+		return schedules.put(schedule)
+	}
+
+	blob, err := schedules.builder.BuildSwap(schedule.nextTemplate(now))
+	if err != nil {
+		schedules.retryAts[schedule.ID] = now + RetryDelay
+		schedule.LastError = err.Error()
+		if msg := schedules.put(schedule); msg != nil {
+			return msg
+		}
+		return tau.NewError(fmt.Errorf("cannot build swap of schedule %s: %v", schedule.ID, err))
+	}
+	delete(schedules.retryAts, schedule.ID)
+
+	sent, _ := new(big.Int).SetString(schedule.Sent, 10)
+	amount, _ := new(big.Int).SetString(schedule.Template.SendAmount, 10)
+	schedule.Sent = new(big.Int).Add(sent, amount).String()
+	schedule.Swaps++
+	schedule.LastSwap = blob.ID
+	schedule.LastError = ""
+	schedule.NextSwap += schedule.Interval
+	if schedule.NextSwap <= now {
+		schedule.NextSwap = now + schedule.Interval
+	}
+	if msg := schedules.put(schedule); msg != nil {
+		return msg
+	}
+	return SwapRequest(blob)
+}
+
+// put stores the schedule, and completes it once the next swap would exceed
+// its maximum total.
+func (schedules *schedules) put(schedule Schedule) tau.Message {
+	if !schedule.hasNext() {
+		schedule.Completed = true
+		delete(schedules.active, schedule.ID)
+		delete(schedules.retryAts, schedule.ID)
+	} else {
+		schedules.active[schedule.ID] = schedule
+	}
+	if err := schedules.storage.PutSchedule(schedule); err != nil {
+		return tau.NewError(fmt.Errorf("cannot store schedule %s: %v", schedule.ID, err))
+	}
+	return nil
+}
+
+type ScheduleID string
+
+// A Schedule is a recurring delayed swap. Every Interval seconds, starting at
+// NextSwap, a swap is built from the Template until the total amount sent
+// would exceed the MaxTotal.
+type Schedule struct {
+	ID       ScheduleID    `json:"id"`
+	Template swap.SwapBlob `json:"template"`
+	Interval int64         `json:"interval"`
+	MaxTotal string        `json:"maxTotal"`
+
+	// DelayDuration is the number of seconds that each swap can be filled
+	// for after it is posted. The default delay deadline is used when it is
+	// zero.
+	DelayDuration int64 `json:"delayDuration,omitempty"`
+
+	Sent      string      `json:"sent"`
+	Swaps     int64       `json:"swaps"`
+	NextSwap  int64       `json:"nextSwap"`
+	LastSwap  swap.SwapID `json:"lastSwap,omitempty"`
+	LastError string      `json:"lastError,omitempty"`
+	Completed bool        `json:"completed"`
+}
+
+// hasNext returns true if the next swap of the schedule does not exceed its
+// maximum total.
+func (schedule Schedule) hasNext() bool {
+	sent, ok := new(big.Int).SetString(schedule.Sent, 10)
+	if !ok {
+		return false
+	}
+	amount, ok := new(big.Int).SetString(schedule.Template.SendAmount, 10)
+	if !ok {
+		return false
+	}
+	maxTotal, ok := new(big.Int).SetString(schedule.MaxTotal, 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Add(sent, amount).Cmp(maxTotal) <= 0
+}
+
+// nextTemplate returns the template of the next swap, without the details
+// that are generated for every swap. The delay deadline of the swap is
+// rebased onto now.
+func (schedule Schedule) nextTemplate(now int64) swap.SwapBlob {
+	template := schedule.Template
+	template.ID = ""
+	template.Delay = true
+	template.DelayDeadline = 0
+	if schedule.DelayDuration > 0 {
+		template.DelayDeadline = now + schedule.DelayDuration
+	}
+	template.SecretHash = ""
+	template.TimeLock = 0
+	template.TimeLockMargin = 0
+	template.Secret = [32]byte{}
+	return template
+}
+
+type Bootload struct {
+	Password string
+}
+
+func (Bootload) IsMessage() {
+}
+
+type ScheduleRequest Schedule
+
+func (ScheduleRequest) IsMessage() {
+}
+
+type UpdateSchedule struct {
+	ID            ScheduleID
+	Template      swap.SwapBlob
+	Interval      int64
+	MaxTotal      string
+	DelayDuration int64
+}
+
+func (UpdateSchedule) IsMessage() {
+}
+
+type CancelSchedule struct {
+	ID ScheduleID
+}
+
+func (CancelSchedule) IsMessage() {
+}
+
+type SwapRequest swap.SwapBlob
+
+func (SwapRequest) IsMessage() {
+}
@@ -0,0 +1,41 @@
+package schedule_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/renproject/swapperd/foundation/swap"
+)
+
+func TestSchedule(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Schedule Suite")
+}
+
+// MockSwapBuilder builds swaps with a random id from the template, or fails
+// with the given error.
+type MockSwapBuilder struct {
+	err error
+}
+
+func NewMockSwapBuilder(err error) *MockSwapBuilder {
+	return &MockSwapBuilder{err}
+}
+
+func (builder *MockSwapBuilder) BuildSwap(template swap.SwapBlob) (swap.SwapBlob, error) {
+	if builder.err != nil {
+		return template, builder.err
+	}
+	if template.ID != "" || !template.Delay {
+		return template, fmt.Errorf("unexpected template")
+	}
+	id := [32]byte{}
+	rand.Read(id[:])
+	template.ID = swap.SwapID(base64.StdEncoding.EncodeToString(id[:]))
+	return template, nil
+}
@@ -0,0 +1,236 @@
+package schedule_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing/quick"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/core/wallet/schedule"
+
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/swapperd/testutils"
+	"github.com/republicprotocol/tau"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var _ = Describe("Schedule Task", func() {
+
+	init := func(err error) (tau.Task, *testutils.MockStorage, chan struct{}) {
+		storage := testutils.NewMockStorage()
+		return New(testutils.DefaultQuickCheckConfig.MaxCount, NewMockSwapBuilder(err), storage), storage, make(chan struct{})
+	}
+
+	newSchedule := func(id ScheduleID, password string, amount, periods uint16) ScheduleRequest {
+		sendAmount := uint64(amount) + 1
+		return ScheduleRequest{
+			ID: id,
+			Template: swap.SwapBlob{
+				SendAmount: fmt.Sprintf("%d", sendAmount),
+				Password:   password,
+				Delay:      true,
+			},
+			Interval: MinInterval,
+			MaxTotal: fmt.Sprintf("%d", sendAmount*(uint64(periods%4)+1)),
+			Sent:     "0",
+			NextSwap: time.Now().Add(-time.Minute).Unix(),
+		}
+	}
+
+	storedSchedule := func(storage *testutils.MockStorage, id ScheduleID) (Schedule, bool) {
+		schedules, err := storage.Schedules()
+		Expect(err).ShouldNot(HaveOccurred())
+		for _, schedule := range schedules {
+			if schedule.ID == id {
+				return schedule, true
+			}
+		}
+		return Schedule{}, false
+	}
+
+	Context("when a schedule is due", func() {
+		It("should return a delayed swap request and store the progress of the schedule", func() {
+			scheduleTask, storage, done := init(nil)
+			defer close(done)
+			go scheduleTask.Run(done)
+
+			test := func(id ScheduleID, password string, amount, periods uint16) bool {
+				req := newSchedule(id, password, amount, periods)
+				req.MaxTotal = req.Template.SendAmount + "0"
+				scheduleTask.IO().InputWriter() <- req
+				scheduleTask.IO().InputWriter() <- tau.Tick{}
+				response := <-scheduleTask.IO().OutputReader()
+
+				msg, ok := response.(tau.MessageBatch)
+				Expect(ok).Should(BeTrue())
+				swapReq, ok := msg[0].(SwapRequest)
+				Expect(ok).Should(BeTrue())
+
+				schedule, ok := storedSchedule(storage, id)
+				Expect(ok).Should(BeTrue())
+				scheduleTask.IO().InputWriter() <- CancelSchedule{ID: id}
+
+				return swapReq.Delay &&
+					swapReq.Password == password &&
+					swapReq.SendAmount == req.Template.SendAmount &&
+					schedule.Sent == req.Template.SendAmount &&
+					schedule.Swaps == 1 &&
+					schedule.LastSwap == swapReq.ID &&
+					schedule.NextSwap > time.Now().Unix() &&
+					schedule.Template.Password == "" &&
+					!schedule.Completed
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should complete the schedule once the max total is reached", func() {
+			scheduleTask, storage, done := init(nil)
+			defer close(done)
+			go scheduleTask.Run(done)
+
+			test := func(id ScheduleID, password string, amount uint16) bool {
+				req := newSchedule(id, password, amount, 0)
+				scheduleTask.IO().InputWriter() <- req
+				scheduleTask.IO().InputWriter() <- tau.Tick{}
+				response := <-scheduleTask.IO().OutputReader()
+
+				msg, ok := response.(tau.MessageBatch)
+				Expect(ok).Should(BeTrue())
+				_, ok = msg[0].(SwapRequest)
+				Expect(ok).Should(BeTrue())
+
+				schedule, ok := storedSchedule(storage, id)
+				return ok && schedule.Completed && schedule.Swaps == 1
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should rebase the delay deadline of every swap onto the time it is posted", func() {
+			scheduleTask, _, done := init(nil)
+			defer close(done)
+			go scheduleTask.Run(done)
+
+			test := func(id ScheduleID, password string, amount, periods uint16) bool {
+				req := newSchedule(id, password, amount, periods)
+				req.MaxTotal = req.Template.SendAmount + "0"
+				req.Interval = 0
+				req.Template.DelayDeadline = time.Now().Add(-time.Hour).Unix()
+				req.DelayDuration = int64(time.Hour / time.Second)
+				scheduleTask.IO().InputWriter() <- req
+				defer func() {
+					scheduleTask.IO().InputWriter() <- CancelSchedule{ID: id}
+				}()
+
+				for i := 0; i < 2; i++ {
+					before := time.Now().Unix()
+					scheduleTask.IO().InputWriter() <- tau.Tick{}
+					response := <-scheduleTask.IO().OutputReader()
+
+					msg, ok := response.(tau.MessageBatch)
+					Expect(ok).Should(BeTrue())
+					swapReq, ok := msg[0].(SwapRequest)
+					Expect(ok).Should(BeTrue())
+					if swapReq.DelayDeadline < before+req.DelayDuration || swapReq.DelayDeadline > time.Now().Unix()+req.DelayDuration {
+						return false
+					}
+				}
+				return true
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should return an error and store it when the swap cannot be built", func() {
+			test := func(id ScheduleID, password string, amount, periods uint16, errString string) bool {
+				scheduleTask, storage, done := init(fmt.Errorf(errString))
+				defer close(done)
+				go scheduleTask.Run(done)
+
+				scheduleTask.IO().InputWriter() <- newSchedule(id, password, amount, periods)
+				scheduleTask.IO().InputWriter() <- tau.Tick{}
+				response := <-scheduleTask.IO().OutputReader()
+
+				msg, ok := response.(tau.MessageBatch)
+				Expect(ok).Should(BeTrue())
+				_, ok = msg[0].(tau.Error)
+				Expect(ok).Should(BeTrue())
+
+				schedule, ok := storedSchedule(storage, id)
+				return ok && schedule.LastError == errString && schedule.Swaps == 0 && schedule.Sent == "0"
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("when bootloading a password", func() {
+		It("should resume the schedules of the password", func() {
+			test := func(id ScheduleID, seed uint32, amount, periods uint16) bool {
+				scheduleTask, storage, done := init(nil)
+				defer close(done)
+				go scheduleTask.Run(done)
+
+				password := fmt.Sprintf("password-%d", seed)
+				passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+				Expect(err).ShouldNot(HaveOccurred())
+				stored := Schedule(newSchedule(id, "", amount, periods))
+				stored.Template.PasswordHash = base64.StdEncoding.EncodeToString(passwordHash)
+				Expect(storage.PutSchedule(stored)).ShouldNot(HaveOccurred())
+
+				scheduleTask.IO().InputWriter() <- Bootload{Password: password}
+				scheduleTask.IO().InputWriter() <- tau.Tick{}
+				response := <-scheduleTask.IO().OutputReader()
+
+				msg, ok := response.(tau.MessageBatch)
+				Expect(ok).Should(BeTrue())
+				swapReq, ok := msg[0].(SwapRequest)
+				return ok && swapReq.Password == password
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("when cancelling a schedule", func() {
+		It("should delete the schedule", func() {
+			scheduleTask, storage, done := init(nil)
+			defer close(done)
+			go scheduleTask.Run(done)
+
+			test := func(id ScheduleID, password string, amount, periods uint16) bool {
+				req := newSchedule(id, password, amount, periods)
+				req.NextSwap = time.Now().Add(time.Hour).Unix()
+				scheduleTask.IO().InputWriter() <- req
+				scheduleTask.IO().InputWriter() <- CancelSchedule{ID: id}
+				scheduleTask.IO().InputWriter() <- tau.RandomMessage{}
+				<-scheduleTask.IO().OutputReader()
+
+				_, ok := storedSchedule(storage, id)
+				return !ok
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("when receiving an unknown message type", func() {
+		It("should return an error", func() {
+			scheduleTask, _, done := init(nil)
+			defer close(done)
+			go scheduleTask.Run(done)
+
+			test := func() bool {
+				scheduleTask.IO().InputWriter() <- tau.RandomMessage{}
+				err := <-scheduleTask.IO().OutputReader()
+				_, ok := err.(tau.Error)
+				return ok
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+	})
+})
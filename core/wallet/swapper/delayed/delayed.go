@@ -2,7 +2,9 @@ package delayed
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/renproject/swapperd/core/wallet/swapper/scheduler"
 	"github.com/renproject/swapperd/foundation/swap"
 	"github.com/republicprotocol/tau"
 )
@@ -12,21 +14,34 @@ var ErrSwapCancelled = fmt.Errorf("swap cancelled")
 
 type callback struct {
 	delayCallback DelayCallback
+	scheduler     *scheduler.Scheduler
 	swapMap       map[swap.SwapID]DelayedSwapRequest
 }
 
+// A DelayCallback fills delayed swaps. DelayCallback asks the broker for the
+// filled swap, signing the request with the key of the password, and DelayFill
+// verifies a filled swap that was pushed by the broker, given the signed
+// message holding it.
 type DelayCallback interface {
-	DelayCallback(swap.SwapBlob) (swap.SwapBlob, error)
+	DelayCallback(partialSwap swap.SwapBlob, password string) (swap.SwapBlob, error)
+	DelayFill(partialSwap swap.SwapBlob, message, signature []byte) (swap.SwapBlob, error)
 }
 
 func New(cap int, delayCallback DelayCallback) tau.Task {
-	return tau.New(tau.NewIO(cap), &callback{delayCallback, map[swap.SwapID]DelayedSwapRequest{}})
+	return tau.New(tau.NewIO(cap), &callback{delayCallback, scheduler.New(scheduler.DefaultConfig), map[swap.SwapID]DelayedSwapRequest{}})
 }
 
 func (callback *callback) Reduce(msg tau.Message) tau.Message {
 	switch msg := msg.(type) {
 	case DelayedSwapRequest:
 		return callback.handleDelayedSwapRequest(msg)
+	case CancelSwap:
+		if _, ok := callback.swapMap[msg.ID]; !ok {
+			return tau.NewError(fmt.Errorf("cannot cancel swap %s: swap is not active", msg.ID))
+		}
+		return callback.handleCancelSwap(msg.ID)
+	case FillSwap:
+		return callback.handleFillSwap(msg)
 	case tau.Tick:
 		return callback.handleTick()
 	default:
@@ -35,8 +50,16 @@ func (callback *callback) Reduce(msg tau.Message) tau.Message {
 }
 
 func (callback *callback) handleTick() tau.Message {
+	now := time.Now()
 	messages := []tau.Message{}
-	for _, swap := range callback.swapMap {
+	for id, swap := range callback.swapMap {
+		if now.Unix() > deadline(swap) {
+			messages = append(messages, callback.handleExpiredSwap(id))
+			continue
+		}
+		if !callback.scheduler.Due(id, now) {
+			continue
+		}
 		if msg := callback.handleDelayedSwapRequest(swap); msg != nil {
 			messages = append(messages, msg)
 		}
@@ -45,11 +68,16 @@ func (callback *callback) handleTick() tau.Message {
 }
 
 func (callback *callback) handleDelayedSwapRequest(blob DelayedSwapRequest) tau.Message {
+	if time.Now().Unix() > deadline(blob) {
+		return callback.handleExpiredSwap(blob.ID)
+	}
+
 	password := blob.Password
 	blob.Password = ""
-	filledBlob, err := callback.delayCallback.DelayCallback(swap.SwapBlob(blob))
+	filledBlob, err := callback.delayCallback.DelayCallback(swap.SwapBlob(blob), password)
 	if err == nil {
 		filledBlob.Password = password
+		filledBlob.Secret = blob.Secret
 		return callback.handleUpdateSwap(SwapRequest(filledBlob))
 	}
 	if err == ErrSwapCancelled {
@@ -57,18 +85,58 @@ func (callback *callback) handleDelayedSwapRequest(blob DelayedSwapRequest) tau.
 	}
 	blob.Password = password
 	callback.swapMap[blob.ID] = blob
-	if err != ErrSwapDetailsUnavailable {
+	if err == ErrSwapDetailsUnavailable {
+		err = nil
+	}
+	if scheduleErr := callback.scheduler.Schedule(blob.ID, time.Now(), deadline(blob), err); scheduleErr != nil {
+		return tau.NewError(scheduleErr)
+	}
+	if err != nil {
 		return tau.NewError(err)
 	}
 	return nil
 }
 
+// handleFillSwap fills a delayed swap with a swap that was pushed by the
+// broker, instead of waiting for the next callback.
+func (callback *callback) handleFillSwap(fill FillSwap) tau.Message {
+	blob, ok := callback.swapMap[fill.ID]
+	if !ok {
+		return tau.NewError(fmt.Errorf("cannot fill swap %s: swap is not active", fill.ID))
+	}
+	if time.Now().Unix() > deadline(blob) {
+		return callback.handleExpiredSwap(blob.ID)
+	}
+
+	partialBlob := swap.SwapBlob(blob)
+	partialBlob.Password = ""
+	filledBlob, err := callback.delayCallback.DelayFill(partialBlob, fill.Message, fill.Signature)
+	if err != nil {
+		return tau.NewError(fmt.Errorf("cannot fill swap %s: %v", fill.ID, err))
+	}
+	filledBlob.Password = blob.Password
+	filledBlob.Secret = blob.Secret
+	return callback.handleUpdateSwap(SwapRequest(filledBlob))
+}
+
 func (callback *callback) handleCancelSwap(id swap.SwapID) tau.Message {
 	update := ReceiptUpdate(swap.NewReceiptUpdate(id, func(receipt *swap.SwapReceipt) {
 		receipt.ID = id
 		receipt.Status = swap.Cancelled
 	}))
 	delete(callback.swapMap, id)
+	callback.scheduler.Remove(id)
+	return tau.NewMessageBatch([]tau.Message{update, DeleteSwap{id}})
+}
+
+// handleExpiredSwap stops filling a delayed swap once its deadline has passed.
+func (callback *callback) handleExpiredSwap(id swap.SwapID) tau.Message {
+	update := ReceiptUpdate(swap.NewReceiptUpdate(id, func(receipt *swap.SwapReceipt) {
+		receipt.ID = id
+		receipt.Status = swap.Expired
+	}))
+	delete(callback.swapMap, id)
+	callback.scheduler.Remove(id)
 	return tau.NewMessageBatch([]tau.Message{update, DeleteSwap{id}})
 }
 
@@ -79,9 +147,18 @@ func (callback *callback) handleUpdateSwap(req SwapRequest) tau.Message {
 		receipt.TimeLock = req.TimeLock
 	}))
 	delete(callback.swapMap, req.ID)
+	callback.scheduler.Remove(req.ID)
 	return tau.NewMessageBatch([]tau.Message{update, req})
 }
 
+// deadline returns the time by which a delayed swap has to be filled.
+func deadline(blob DelayedSwapRequest) int64 {
+	if blob.DelayDeadline == 0 {
+		return blob.TimeLock
+	}
+	return blob.DelayDeadline
+}
+
 type DelayedSwapRequest swap.SwapBlob
 
 func (msg DelayedSwapRequest) IsMessage() {
@@ -103,3 +180,21 @@ type DeleteSwap struct {
 
 func (msg DeleteSwap) IsMessage() {
 }
+
+// FillSwap is a filled delayed swap pushed by the broker. The Message is the
+// json encoded filled swap, and the Signature is the broker's signature of it.
+type FillSwap struct {
+	ID        swap.SwapID
+	Message   []byte
+	Signature []byte
+}
+
+func (msg FillSwap) IsMessage() {
+}
+
+type CancelSwap struct {
+	ID swap.SwapID
+}
+
+func (msg CancelSwap) IsMessage() {
+}
@@ -1,9 +1,11 @@
 package delayed_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing/quick"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -28,6 +30,7 @@ var _ = Describe("Delayed Swap Task", func() {
 			go delayedTask.Run(done)
 
 			test := func(request DelayedSwapRequest) bool {
+				request.DelayDeadline = time.Now().Add(time.Hour).Unix()
 				delayedTask.IO().InputWriter() <- request
 				response := <-delayedTask.IO().OutputReader()
 
@@ -51,6 +54,7 @@ var _ = Describe("Delayed Swap Task", func() {
 			go delayedTask.Run(done)
 
 			test := func(request DelayedSwapRequest) bool {
+				request.DelayDeadline = time.Now().Add(time.Hour).Unix()
 				delayedTask.IO().InputWriter() <- request
 				response := <-delayedTask.IO().OutputReader()
 
@@ -74,6 +78,7 @@ var _ = Describe("Delayed Swap Task", func() {
 			go delayedTask.Run(done)
 
 			test := func(request DelayedSwapRequest) bool {
+				request.DelayDeadline = time.Now().Add(time.Hour).Unix()
 				delayedTask.IO().InputWriter() <- request
 				return len(delayedTask.IO().OutputReader()) == 0
 			}
@@ -87,6 +92,7 @@ var _ = Describe("Delayed Swap Task", func() {
 				defer close(done)
 				go delayedTask.Run(done)
 
+				request.DelayDeadline = time.Now().Add(time.Hour).Unix()
 				delayedTask.IO().InputWriter() <- request
 				response := <-delayedTask.IO().OutputReader()
 				_, ok := response.(tau.Error)
@@ -96,12 +102,128 @@ var _ = Describe("Delayed Swap Task", func() {
 		})
 	})
 
+	Context("when the deadline of a delayed swap has passed", func() {
+		It("should expire the swap without calling the broker", func() {
+			delayedTask, done := init(nil)
+			defer close(done)
+			go delayedTask.Run(done)
+
+			test := func(request DelayedSwapRequest) bool {
+				request.DelayDeadline = time.Now().Add(-time.Minute).Unix()
+				delayedTask.IO().InputWriter() <- request
+				response := <-delayedTask.IO().OutputReader()
+
+				msg, ok := response.(tau.MessageBatch)
+				Expect(ok).Should(BeTrue())
+
+				update, ok := msg[0].(ReceiptUpdate)
+				Expect(ok).Should(BeTrue())
+				receipt := swap.NewSwapReceipt(swap.SwapBlob(request))
+				update.Update(&receipt)
+
+				return receipt.Status == swap.Expired && reflect.DeepEqual(DeleteSwap{request.ID}, msg[1].(DeleteSwap))
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should fall back to the timelock for swaps without a deadline", func() {
+			delayedTask, done := init(ErrSwapDetailsUnavailable)
+			defer close(done)
+			go delayedTask.Run(done)
+
+			test := func(request DelayedSwapRequest) bool {
+				request.DelayDeadline = 0
+				request.TimeLock = time.Now().Add(-time.Minute).Unix()
+				delayedTask.IO().InputWriter() <- request
+				response := <-delayedTask.IO().OutputReader()
+
+				msg, ok := response.(tau.MessageBatch)
+				Expect(ok).Should(BeTrue())
+				return reflect.DeepEqual(DeleteSwap{request.ID}, msg[1].(DeleteSwap))
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("when receiving a pushed fill", func() {
+		It("should return receipt update and new swap for an active swap", func() {
+			delayedTask, done := init(ErrSwapDetailsUnavailable)
+			defer close(done)
+			go delayedTask.Run(done)
+
+			test := func(request DelayedSwapRequest) bool {
+				request.DelayDeadline = time.Now().Add(time.Hour).Unix()
+				delayedTask.IO().InputWriter() <- request
+
+				filledSwap := swap.SwapBlob(request)
+				filledSwap.Password = ""
+				filledSwap.Secret = [32]byte{}
+				filledSwap.DelayInfo = nil
+				message, err := json.Marshal(filledSwap)
+				Expect(err).ShouldNot(HaveOccurred())
+				delayedTask.IO().InputWriter() <- FillSwap{ID: request.ID, Message: message, Signature: []byte("signature")}
+				response := <-delayedTask.IO().OutputReader()
+
+				msg, ok := response.(tau.MessageBatch)
+				Expect(ok).Should(BeTrue())
+				_, ok = msg[0].(ReceiptUpdate)
+				Expect(ok).Should(BeTrue())
+
+				filledRequest := msg[1].(SwapRequest)
+				return filledRequest.ID == request.ID && filledRequest.Password == request.Password && filledRequest.Secret == request.Secret && !filledRequest.Delay
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should return an error for a swap that is not active", func() {
+			delayedTask, done := init(nil)
+			defer close(done)
+			go delayedTask.Run(done)
+
+			test := func(id swap.SwapID) bool {
+				delayedTask.IO().InputWriter() <- FillSwap{ID: id, Signature: []byte("signature")}
+				response := <-delayedTask.IO().OutputReader()
+				_, ok := response.(tau.Error)
+				return ok
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should return an error and keep the swap when the fill is rejected", func() {
+			delayedTask, done := init(ErrSwapDetailsUnavailable)
+			defer close(done)
+			go delayedTask.Run(done)
+
+			test := func(request DelayedSwapRequest) bool {
+				request.DelayDeadline = time.Now().Add(time.Hour).Unix()
+				delayedTask.IO().InputWriter() <- request
+				delayedTask.IO().InputWriter() <- FillSwap{ID: request.ID, Message: []byte("{}")}
+				response := <-delayedTask.IO().OutputReader()
+				if _, ok := response.(tau.Error); !ok {
+					return false
+				}
+
+				delayedTask.IO().InputWriter() <- CancelSwap{ID: request.ID}
+				response = <-delayedTask.IO().OutputReader()
+				msg, ok := response.(tau.MessageBatch)
+				return ok && reflect.DeepEqual(DeleteSwap{request.ID}, msg[1].(DeleteSwap))
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Context("when receiveng new tick", func() {
 		It("should return ", func() {
 			statusTask, done := init(ErrSwapDetailsUnavailable)
 			defer close(done)
 			go statusTask.Run(done)
 			test := func(request DelayedSwapRequest) bool {
+				request.DelayDeadline = time.Now().Add(time.Hour).Unix()
 				statusTask.IO().InputWriter() <- request
 				statusTask.IO().InputWriter() <- tau.Tick{}
 				return true
@@ -2,16 +2,22 @@ package immediate
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/renproject/swapperd/core/wallet/swapper/scheduler"
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
 	"github.com/republicprotocol/tau"
-	"golang.org/x/crypto/sha3"
 )
 
 var ErrSwapExpired = fmt.Errorf("swap expired")
 var ErrAuditPending = fmt.Errorf("audit pending")
 
+// ErrSpendPending is returned by a redeem or a refund that has broadcast a
+// transaction which is not confirmed yet. The swap is attempted again, without
+// counting the attempt as failed, until the transaction is confirmed.
+var ErrSpendPending = fmt.Errorf("spend pending")
+
 type Contract interface {
 	Initiate() error
 	Audit() error
@@ -19,22 +25,39 @@ type Contract interface {
 	AuditSecret() ([32]byte, error)
 	Refund() error
 	Cost() blockchain.Cost
+	Details() swap.ContractDetails
 }
 
 type ContractBuilder interface {
 	BuildSwapContracts(request SwapRequest) (Contract, Contract, error)
 }
 
+// DefaultWorkers is the number of swap steps that can be executed
+// concurrently by the immediate swapper.
+const DefaultWorkers = 16
+
 type swapper struct {
-	builder ContractBuilder
-	swapMap map[swap.SwapID]SwapRequest
+	task      tau.Task
+	builder   ContractBuilder
+	scheduler *scheduler.Scheduler
+	workers   chan struct{}
+	swapMap   map[swap.SwapID]SwapRequest
+
+	// inFlight holds the swaps that are being executed by a worker, and
+	// whether they should be executed again as soon as the worker is done.
+	inFlight map[swap.SwapID]bool
 }
 
-func New(cap int, builder ContractBuilder) tau.Task {
-	return tau.New(tau.NewIO(cap), &swapper{
-		builder: builder,
-		swapMap: map[swap.SwapID]SwapRequest{},
-	})
+func New(cap, workers int, builder ContractBuilder) tau.Task {
+	swapper := &swapper{
+		builder:   builder,
+		scheduler: scheduler.New(scheduler.DefaultConfig),
+		workers:   make(chan struct{}, workers),
+		swapMap:   map[swap.SwapID]SwapRequest{},
+		inFlight:  map[swap.SwapID]bool{},
+	}
+	swapper.task = tau.New(tau.NewIO(cap), swapper)
+	return swapper.task
 }
 
 func (swapper *swapper) Reduce(msg tau.Message) tau.Message {
@@ -42,24 +65,78 @@ func (swapper *swapper) Reduce(msg tau.Message) tau.Message {
 	case tau.Tick:
 		return swapper.handleTick()
 	case SwapRequest:
-		return swapper.handleSwap(msg)
+		swapper.dispatch(msg)
+		return nil
+	case CancelSwap:
+		return swapper.handleCancelSwap(msg.ID)
+	case swapResult:
+		return swapper.handleResult(msg)
+	case SecretRevealed:
+		swapper.handleSecretRevealed(msg)
+		return nil
 	default:
 		return tau.NewError(fmt.Errorf("invalid message type in swapper: %T", msg))
 	}
 }
 
 func (swapper *swapper) handleTick() tau.Message {
-	msgs := []tau.Message{}
-	for _, req := range swapper.swapMap {
-		msgs = append(msgs, swapper.handleSwap(req))
+	now := time.Now()
+	for id, req := range swapper.swapMap {
+		if _, ok := swapper.inFlight[id]; !ok && swapper.scheduler.Due(id, now) {
+			swapper.dispatch(req)
+		}
+	}
+	return tau.NewMessageBatch([]tau.Message{})
+}
+
+func (swapper *swapper) handleCancelSwap(id swap.SwapID) tau.Message {
+	req, ok := swapper.swapMap[id]
+	if !ok {
+		return tau.NewError(fmt.Errorf("cannot cancel swap %s: swap is not active", id))
+	}
+	req.Blob.Cancelled = true
+	swapper.scheduler.Remove(id)
+	swapper.dispatch(req)
+	return nil
+}
+
+// handleSecretRevealed resumes a swap as soon as its secret is revealed by a
+// pending transaction, so that the foreign contract is redeemed without
+// waiting for the transaction to be mined.
+func (swapper *swapper) handleSecretRevealed(msg SecretRevealed) {
+	req, ok := swapper.swapMap[msg.ID]
+	if !ok {
+		return
 	}
-	return tau.NewMessageBatch(msgs)
+	req.RevealedSecret = msg.Secret
+	swapper.dispatch(req)
 }
 
-func (swapper *swapper) handleSwap(req SwapRequest) tau.Message {
+// dispatch executes the next step of a swap on a worker. Steps of the same
+// swap are never executed concurrently, if the swap is already being executed
+// it is executed again once the current step is done.
+func (swapper *swapper) dispatch(req SwapRequest) {
+	swapper.swapMap[req.Blob.ID] = req
+	if _, ok := swapper.inFlight[req.Blob.ID]; ok {
+		swapper.inFlight[req.Blob.ID] = true
+		return
+	}
+	swapper.inFlight[req.Blob.ID] = false
+	go func() {
+		swapper.workers <- struct{}{}
+		result := swapper.handleSwap(req)
+		<-swapper.workers
+		swapper.task.Send(result)
+	}()
+}
+
+func (swapper *swapper) handleSwap(req SwapRequest) swapResult {
 	native, foreign, err := swapper.builder.BuildSwapContracts(req)
 	if err != nil {
-		return tau.NewError(err)
+		return swapResult{req: req, err: err}
+	}
+	if req.Blob.Cancelled {
+		return swapper.cancel(req, native, foreign)
 	}
 	if req.Blob.ShouldInitiateFirst {
 		return swapper.initiate(req, native, foreign)
@@ -67,80 +144,182 @@ func (swapper *swapper) handleSwap(req SwapRequest) tau.Message {
 	return swapper.respond(req, native, foreign)
 }
 
-func (swapper *swapper) initiate(req SwapRequest, native, foreign Contract) tau.Message {
-	secret := sha3.Sum256(append([]byte(req.Blob.Password), []byte(req.Blob.ID)...))
+func (swapper *swapper) initiate(req SwapRequest, native, foreign Contract) swapResult {
+	secret := req.Blob.Secret
+	if secret == [32]byte{} {
+		secret = swap.LegacySecret(req.Blob.Password, req.Blob.ID)
+	}
 	if err := native.Initiate(); err != nil {
-		return swapper.handleResult(req, swap.Inactive, native, foreign, err, false)
+		return newSwapResult(req, swap.Inactive, native, foreign, err, false)
 	}
 	if err := foreign.Audit(); err != nil {
 		if err == ErrAuditPending {
-			return swapper.handleResult(req, swap.AuditPending, native, foreign, nil, false)
+			return newSwapResult(req, swap.AuditPending, native, foreign, nil, false)
 		}
 		if err != ErrSwapExpired {
-			return swapper.handleResult(req, swap.AuditPending, native, foreign, err, false)
+			return newSwapResult(req, swap.AuditPending, native, foreign, err, false)
 		}
-		if err := native.Refund(); err != nil {
-			return swapper.handleResult(req, swap.RefundFailed, native, foreign, err, false)
-		}
-		return swapper.handleResult(req, swap.Refunded, native, foreign, nil, true)
-	}
-	if err := foreign.Redeem(secret); err != nil {
-		return swapper.handleResult(req, swap.Audited, native, foreign, err, false)
+		return swapper.refund(req, native, foreign)
 	}
-	return swapper.handleResult(req, swap.Redeemed, native, foreign, nil, true)
+	return swapper.redeem(req, swap.Audited, secret, native, foreign)
 }
 
-func (swapper *swapper) respond(req SwapRequest, native, foreign Contract) tau.Message {
+func (swapper *swapper) respond(req SwapRequest, native, foreign Contract) swapResult {
 	if err := foreign.Audit(); err != nil {
 		if err == ErrAuditPending {
-			return swapper.handleResult(req, swap.AuditPending, native, foreign, nil, false)
+			return newSwapResult(req, swap.AuditPending, native, foreign, nil, false)
 		}
 		if err == ErrSwapExpired {
-			return swapper.handleResult(req, swap.Expired, native, foreign, err, true)
+			return newSwapResult(req, swap.Expired, native, foreign, err, true)
 		}
-		return swapper.handleResult(req, swap.AuditPending, native, foreign, err, false)
+		return newSwapResult(req, swap.AuditPending, native, foreign, err, false)
 	}
 
 	if err := native.Initiate(); err != nil {
-		return swapper.handleResult(req, swap.Audited, native, foreign, err, false)
+		return newSwapResult(req, swap.Audited, native, foreign, err, false)
 	}
 	secret, err := native.AuditSecret()
+	if err == ErrAuditPending && req.RevealedSecret != [32]byte{} {
+		secret, err = req.RevealedSecret, nil
+	}
 	if err != nil {
 		if err == ErrAuditPending {
-			return swapper.handleResult(req, swap.AuditPending, native, foreign, nil, false)
+			result := newSwapResult(req, swap.AuditPending, native, foreign, nil, false)
+			result.watch = true
+			return result
 		}
 		if err != ErrSwapExpired {
-			return swapper.handleResult(req, swap.Initiated, native, foreign, err, false)
-		}
-		if err := native.Refund(); err != nil {
-			return swapper.handleResult(req, swap.RefundFailed, native, foreign, err, false)
+			return newSwapResult(req, swap.Initiated, native, foreign, err, false)
 		}
-		return swapper.handleResult(req, swap.Refunded, native, foreign, nil, true)
+		return swapper.refund(req, native, foreign)
 	}
+	return swapper.redeem(req, swap.AuditedSecret, secret, native, foreign)
+}
+
+// cancel stops a swap that the user has abandoned. If the native contract was
+// never funded the swap is cancelled straight away, otherwise the native
+// contract is refunded as soon as its timelock expires. If the counterparty
+// has already revealed the secret the foreign contract is redeemed instead.
+func (swapper *swapper) cancel(req SwapRequest, native, foreign Contract) swapResult {
+	if err := native.Audit(); err == ErrAuditPending || err == ErrSwapExpired {
+		return newSwapResult(req, swap.Cancelled, native, foreign, nil, true)
+	}
+	secret, err := native.AuditSecret()
+	if err == nil {
+		return swapper.redeem(req, swap.AuditedSecret, secret, native, foreign)
+	}
+	if err == ErrAuditPending {
+		return newSwapResult(req, swap.RefundPending, native, foreign, nil, false)
+	}
+	if err != ErrSwapExpired {
+		return newSwapResult(req, swap.RefundPending, native, foreign, err, false)
+	}
+	return swapper.refund(req, native, foreign)
+}
+
+// redeem redeems the foreign contract with the secret. The swap keeps its
+// status until the redeem is confirmed.
+func (swapper *swapper) redeem(req SwapRequest, status int, secret [32]byte, native, foreign Contract) swapResult {
 	if err := foreign.Redeem(secret); err != nil {
-		return swapper.handleResult(req, swap.AuditedSecret, native, foreign, err, false)
+		if err == ErrSpendPending {
+			return newSwapResult(req, status, native, foreign, nil, false)
+		}
+		return newSwapResult(req, status, native, foreign, err, false)
 	}
-	return swapper.handleResult(req, swap.Redeemed, native, foreign, nil, true)
+	return newSwapResult(req, swap.Redeemed, native, foreign, nil, true)
 }
 
-func (swapper *swapper) handleResult(req SwapRequest, status int, native, foreign Contract, err error, remove bool) tau.Message {
-	messages := []tau.Message{}
-	messages = append(messages, NewReceiptUpdate(req.Blob.ID, status, native, foreign))
+// refund refunds the native contract once its timelock has expired. The swap
+// is pending until the refund is confirmed.
+func (swapper *swapper) refund(req SwapRequest, native, foreign Contract) swapResult {
+	if err := native.Refund(); err != nil {
+		if err == ErrSpendPending {
+			return newSwapResult(req, swap.RefundPending, native, foreign, nil, false)
+		}
+		return newSwapResult(req, swap.RefundFailed, native, foreign, err, false)
+	}
+	return newSwapResult(req, swap.Refunded, native, foreign, nil, true)
+}
+
+func (swapper *swapper) handleResult(result swapResult) tau.Message {
+	id := result.req.Blob.ID
+	rerun := swapper.inFlight[id]
+	delete(swapper.inFlight, id)
+
+	// The swap request may have been updated, for example cancelled, while
+	// the step was being executed.
+	req, ok := swapper.swapMap[id]
+	if !ok {
+		req = result.req
+	}
+
+	err := result.err
+	if result.remove {
+		delete(swapper.swapMap, id)
+		swapper.scheduler.Remove(id)
+	} else {
+		if scheduleErr := swapper.scheduler.Schedule(id, time.Now(), req.Blob.ResponderTimeLock(), err); scheduleErr != nil {
+			err = scheduleErr
+		}
+		if rerun {
+			swapper.dispatch(req)
+		}
+	}
+	if result.native == nil || result.foreign == nil {
+		return tau.NewError(err)
+	}
+
+	update := NewReceiptUpdate(id, result.status, result.native, result.foreign)
+	update.Error = err
+	messages := []tau.Message{update}
 	if err != nil {
 		messages = append(messages, tau.NewError(err))
 	}
-	if remove {
-		delete(swapper.swapMap, req.Blob.ID)
-		return tau.NewMessageBatch(append(messages, DeleteSwap{req.Blob.ID}))
+	if result.remove {
+		messages = append(messages, DeleteSwap{id})
+	} else if result.watch {
+		messages = append(messages, WatchSecret{req})
 	}
-	swapper.swapMap[req.Blob.ID] = req
 	return tau.NewMessageBatch(messages)
 }
 
+// swapResult is the outcome of a swap step, sent by a worker back to the
+// swapper.
+type swapResult struct {
+	req     SwapRequest
+	status  int
+	native  Contract
+	foreign Contract
+	err     error
+	remove  bool
+
+	// watch is set when the swap is waiting for the counterparty to reveal
+	// the secret.
+	watch bool
+}
+
+func (msg swapResult) IsMessage() {
+}
+
+func newSwapResult(req SwapRequest, status int, native, foreign Contract, err error, remove bool) swapResult {
+	return swapResult{
+		req:     req,
+		status:  status,
+		native:  native,
+		foreign: foreign,
+		err:     err,
+		remove:  remove,
+	}
+}
+
 type SwapRequest struct {
 	Blob        swap.SwapBlob
 	SendCost    blockchain.Cost
 	ReceiveCost blockchain.Cost
+
+	// RevealedSecret is the secret revealed by a pending transaction of the
+	// counterparty, before it can be audited on the native contract.
+	RevealedSecret [32]byte
 }
 
 func (msg SwapRequest) IsMessage() {
@@ -164,6 +343,8 @@ func NewReceiptUpdate(id swap.SwapID, status int, native, foreign Contract) Rece
 		receipt.Status = status
 		receipt.SendCost = blockchain.CostToCostBlob(native.Cost())
 		receipt.ReceiveCost = blockchain.CostToCostBlob(foreign.Cost())
+		receipt.SendContract.Merge(native.Details())
+		receipt.ReceiveContract.Merge(foreign.Details())
 	}))
 }
 
@@ -173,3 +354,29 @@ type DeleteSwap struct {
 
 func (msg DeleteSwap) IsMessage() {
 }
+
+type CancelSwap struct {
+	ID swap.SwapID
+}
+
+func (msg CancelSwap) IsMessage() {
+}
+
+// WatchSecret is output when a swap is waiting for the counterparty to reveal
+// the secret, so that its pending transactions are watched.
+type WatchSecret struct {
+	Request SwapRequest
+}
+
+func (msg WatchSecret) IsMessage() {
+}
+
+// SecretRevealed is received when the secret of a swap is revealed by a
+// pending transaction.
+type SecretRevealed struct {
+	ID     swap.SwapID
+	Secret [32]byte
+}
+
+func (msg SecretRevealed) IsMessage() {
+}
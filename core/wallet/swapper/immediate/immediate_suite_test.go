@@ -3,6 +3,7 @@ package immediate_test
 import (
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -101,3 +102,101 @@ func (contract *MockContract) Refund() error {
 func (contract *MockContract) Cost() blockchain.Cost {
 	return contract.cost
 }
+
+func (contract *MockContract) Details() swap.ContractDetails {
+	return swap.ContractDetails{ContractID: string(contract.blob.ID)}
+}
+
+// BlockingContractBuilder blocks every build until it is released, and
+// records how many builds are running at the same time.
+type BlockingContractBuilder struct {
+	mu        *sync.Mutex
+	active    int
+	maxActive int
+
+	Started chan swap.SwapID
+	Release chan struct{}
+}
+
+func NewBlockingContractBuilder() *BlockingContractBuilder {
+	return &BlockingContractBuilder{
+		mu:      new(sync.Mutex),
+		Started: make(chan swap.SwapID, 128),
+		Release: make(chan struct{}),
+	}
+}
+
+func (builder *BlockingContractBuilder) BuildSwapContracts(request immediate.SwapRequest) (immediate.Contract, immediate.Contract, error) {
+	builder.mu.Lock()
+	builder.active++
+	if builder.active > builder.maxActive {
+		builder.maxActive = builder.active
+	}
+	builder.mu.Unlock()
+
+	builder.Started <- request.Blob.ID
+	<-builder.Release
+
+	builder.mu.Lock()
+	builder.active--
+	builder.mu.Unlock()
+	return nil, nil, fmt.Errorf("blocked swap")
+}
+
+func (builder *BlockingContractBuilder) Active() int {
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+	return builder.active
+}
+
+func (builder *BlockingContractBuilder) MaxActive() int {
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+	return builder.maxActive
+}
+
+// PendingSpendContractBuilder builds contracts whose redeems and refunds are
+// broadcast but not confirmed yet. The foreign contract of an expired swap
+// has expired.
+type PendingSpendContractBuilder struct {
+	Expired bool
+}
+
+func (builder PendingSpendContractBuilder) BuildSwapContracts(request immediate.SwapRequest) (immediate.Contract, immediate.Contract, error) {
+	return &PendingSpendContract{expired: builder.Expired}, &PendingSpendContract{expired: builder.Expired}, nil
+}
+
+type PendingSpendContract struct {
+	expired bool
+}
+
+func (contract *PendingSpendContract) Initiate() error {
+	return nil
+}
+
+func (contract *PendingSpendContract) Audit() error {
+	if contract.expired {
+		return immediate.ErrSwapExpired
+	}
+	return nil
+}
+
+func (contract *PendingSpendContract) Redeem([32]byte) error {
+	return immediate.ErrSpendPending
+}
+
+func (contract *PendingSpendContract) AuditSecret() ([32]byte, error) {
+	return [32]byte{}, nil
+}
+
+func (contract *PendingSpendContract) Refund() error {
+	return immediate.ErrSpendPending
+}
+
+func (contract *PendingSpendContract) Cost() blockchain.Cost {
+	return blockchain.Cost{}
+}
+
+func (contract *PendingSpendContract) Details() swap.ContractDetails {
+	return swap.ContractDetails{}
+}
@@ -1,7 +1,9 @@
 package immediate_test
 
 import (
+	"fmt"
 	"testing/quick"
+	"time"
 
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
@@ -17,7 +19,7 @@ var _ = Describe("Immediate Swap Task", func() {
 
 	init := func() (tau.Task, chan struct{}) {
 		contractBuilder := NewMockContractBuilder()
-		return New(testutils.DefaultQuickCheckConfig.MaxCount, contractBuilder), make(chan struct{})
+		return New(testutils.DefaultQuickCheckConfig.MaxCount, DefaultWorkers, contractBuilder), make(chan struct{})
 	}
 
 	handleSwapResponse := func(msg tau.Message, blob swap.SwapBlob) bool {
@@ -66,7 +68,11 @@ var _ = Describe("Immediate Swap Task", func() {
 			_, ok := messages[1].(tau.Error)
 			return blob.ShouldInitiateFirst || ok && len(messages) == 2 && receipt.Status == swap.Initiated
 		case 6:
-			return blob.ShouldInitiateFirst || len(messages) == 1
+			if blob.ShouldInitiateFirst {
+				return true
+			}
+			watchSecret, ok := messages[1].(WatchSecret)
+			return ok && len(messages) == 2 && watchSecret.Request.Blob.ID == blob.ID
 		case 7:
 			if timeLock%18 == 7 {
 				_, ok := messages[1].(tau.Error)
@@ -82,6 +88,43 @@ var _ = Describe("Immediate Swap Task", func() {
 		}
 	}
 
+	handleCancelResponse := func(msg tau.Message, blob swap.SwapBlob) bool {
+		timeLock := uint64(blob.TimeLock)
+		if timeLock%36 == 8 {
+			_, ok := msg.(tau.Error)
+			return ok
+		}
+
+		messages := msg.(tau.MessageBatch)
+		update := messages[0].(ReceiptUpdate)
+		receipt := swap.NewSwapReceipt(blob)
+		update.Update(&receipt)
+
+		switch timeLock % 9 {
+		case 1, 2:
+			deleteSwap, ok := messages[1].(DeleteSwap)
+			return ok && len(messages) == 2 && deleteSwap.ID == blob.ID && receipt.Status == swap.Cancelled
+		case 4:
+			_, ok := messages[1].(tau.Error)
+			return ok && len(messages) == 2 && receipt.Status == swap.AuditedSecret
+		case 5:
+			_, ok := messages[1].(tau.Error)
+			return ok && len(messages) == 2 && receipt.Status == swap.RefundPending
+		case 6:
+			return len(messages) == 1 && receipt.Status == swap.RefundPending
+		case 7:
+			if timeLock%18 == 7 {
+				_, ok := messages[1].(tau.Error)
+				return ok && len(messages) == 2 && receipt.Status == swap.RefundFailed
+			}
+			deleteSwap, ok := messages[1].(DeleteSwap)
+			return ok && len(messages) == 2 && deleteSwap.ID == blob.ID && receipt.Status == swap.Refunded
+		default:
+			deleteSwap, ok := messages[1].(DeleteSwap)
+			return ok && len(messages) == 2 && deleteSwap.ID == blob.ID && receipt.Status == swap.Redeemed
+		}
+	}
+
 	Context("when receiving new immediate swap request", func() {
 		It("should return receipt update and new swap on nil error", func() {
 			immediateTask, done := init()
@@ -89,6 +132,7 @@ var _ = Describe("Immediate Swap Task", func() {
 			go immediateTask.Run(done)
 
 			test := func(blob swap.SwapBlob) bool {
+				blob.Cancelled = false
 				request := NewSwapRequest(blob, blockchain.Cost{}, blockchain.Cost{})
 				immediateTask.IO().InputWriter() <- request
 				response := <-immediateTask.IO().OutputReader()
@@ -118,6 +162,145 @@ var _ = Describe("Immediate Swap Task", func() {
 			})
 		})
 
+		Context("when the secret is revealed by a pending transaction", func() {
+			It("should redeem the foreign contract with the revealed secret", func() {
+				immediateTask, done := init()
+				defer close(done)
+				go immediateTask.Run(done)
+
+				test := func(blob swap.SwapBlob, secret [32]byte) bool {
+					blob.Cancelled = false
+					blob.ShouldInitiateFirst = false
+					blob.TimeLock = int64(uint64(blob.TimeLock)/36*36 + 6)
+					immediateTask.IO().InputWriter() <- NewSwapRequest(blob, blockchain.Cost{}, blockchain.Cost{})
+					messages := (<-immediateTask.IO().OutputReader()).(tau.MessageBatch)
+					if _, ok := messages[1].(WatchSecret); !ok {
+						return false
+					}
+
+					immediateTask.IO().InputWriter() <- SecretRevealed{ID: blob.ID, Secret: secret}
+					messages = (<-immediateTask.IO().OutputReader()).(tau.MessageBatch)
+					update := messages[0].(ReceiptUpdate)
+					receipt := swap.NewSwapReceipt(blob)
+					update.Update(&receipt)
+					deleteSwap, ok := messages[1].(DeleteSwap)
+					return ok && len(messages) == 2 && deleteSwap.ID == blob.ID && receipt.Status == swap.Redeemed
+				}
+
+				Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when receiving a cancelled swap", func() {
+			It("should cancel, refund or redeem depending on the state of the contracts", func() {
+				immediateTask, done := init()
+				defer close(done)
+				go immediateTask.Run(done)
+
+				test := func(blob swap.SwapBlob) bool {
+					blob.Cancelled = true
+					request := NewSwapRequest(blob, blockchain.Cost{}, blockchain.Cost{})
+					immediateTask.IO().InputWriter() <- request
+					response := <-immediateTask.IO().OutputReader()
+
+					return handleCancelResponse(response, blob)
+				}
+
+				Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+			})
+
+			It("should return an error when the swap is not active", func() {
+				immediateTask, done := init()
+				defer close(done)
+				go immediateTask.Run(done)
+
+				test := func(id swap.SwapID) bool {
+					immediateTask.IO().InputWriter() <- CancelSwap{ID: id}
+					response := <-immediateTask.IO().OutputReader()
+					_, ok := response.(tau.Error)
+					return ok
+				}
+
+				Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when a redeem or a refund is pending", func() {
+			It("should keep the swap without returning an error", func() {
+				for _, expired := range []bool{false, true} {
+					immediateTask, done := New(16, DefaultWorkers, PendingSpendContractBuilder{Expired: expired}), make(chan struct{})
+					go immediateTask.Run(done)
+
+					blob := swap.SwapBlob{ID: "swap", ShouldInitiateFirst: true, TimeLock: time.Now().Add(48 * time.Hour).Unix()}
+					immediateTask.IO().InputWriter() <- NewSwapRequest(blob, blockchain.Cost{}, blockchain.Cost{})
+					messages := (<-immediateTask.IO().OutputReader()).(tau.MessageBatch)
+					update := messages[0].(ReceiptUpdate)
+					receipt := swap.NewSwapReceipt(blob)
+					update.Update(&receipt)
+
+					Expect(messages).Should(HaveLen(1))
+					Expect(update.Error).ShouldNot(HaveOccurred())
+					if expired {
+						Expect(receipt.Status).Should(Equal(swap.RefundPending))
+					} else {
+						Expect(receipt.Status).Should(Equal(swap.Audited))
+					}
+					close(done)
+				}
+			})
+		})
+
+		Context("when a swap is requested while one of its steps is running", func() {
+			It("should run the step again once the running step is done", func() {
+				builder := NewBlockingContractBuilder()
+				immediateTask, done := New(16, DefaultWorkers, builder), make(chan struct{})
+				defer close(done)
+				go immediateTask.Run(done)
+
+				blob := swap.SwapBlob{ID: "swap", TimeLock: time.Now().Add(48 * time.Hour).Unix()}
+				request := NewSwapRequest(blob, blockchain.Cost{}, blockchain.Cost{})
+				immediateTask.IO().InputWriter() <- request
+				Eventually(builder.Started).Should(Receive(Equal(blob.ID)))
+
+				immediateTask.IO().InputWriter() <- request
+				Consistently(builder.Started, 200*time.Millisecond).ShouldNot(Receive())
+
+				builder.Release <- struct{}{}
+				<-immediateTask.IO().OutputReader()
+				Eventually(builder.Started).Should(Receive(Equal(blob.ID)))
+				builder.Release <- struct{}{}
+				<-immediateTask.IO().OutputReader()
+
+				Expect(builder.MaxActive()).Should(Equal(1))
+				Consistently(builder.Started, 200*time.Millisecond).ShouldNot(Receive())
+			})
+		})
+
+		Context("when more swaps are requested than there are workers", func() {
+			It("should run at most as many steps as there are workers", func() {
+				workers := 4
+				builder := NewBlockingContractBuilder()
+				immediateTask, done := New(16, workers, builder), make(chan struct{})
+				defer close(done)
+				go immediateTask.Run(done)
+
+				swaps := 3 * workers
+				for i := 0; i < swaps; i++ {
+					blob := swap.SwapBlob{ID: swap.SwapID(fmt.Sprintf("swap-%d", i)), TimeLock: time.Now().Add(48 * time.Hour).Unix()}
+					immediateTask.IO().InputWriter() <- NewSwapRequest(blob, blockchain.Cost{}, blockchain.Cost{})
+				}
+				Eventually(builder.Active).Should(Equal(workers))
+				Consistently(builder.Active, 200*time.Millisecond).Should(Equal(workers))
+
+				for i := 0; i < swaps; i++ {
+					<-builder.Started
+					builder.Release <- struct{}{}
+					<-immediateTask.IO().OutputReader()
+				}
+				Expect(builder.MaxActive()).Should(Equal(workers))
+			})
+		})
+
 		Context("when receiving an unknown message type", func() {
 			It("should return an error", func() {
 				immediateTask, done := init()
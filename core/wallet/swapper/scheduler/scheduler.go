@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/renproject/swapperd/foundation/swap"
+)
+
+// Config of the Scheduler.
+type Config struct {
+	// Interval between attempts of a swap that is waiting on the
+	// counterparty or on the blockchain.
+	Interval time.Duration
+
+	// MinInterval between attempts of a swap that is close to its deadline.
+	MinInterval time.Duration
+
+	// MaxInterval is the upper bound of the backoff after failed attempts.
+	MaxInterval time.Duration
+
+	// DeadlineWindow is how close to its deadline a swap has to be before it
+	// is attempted every MinInterval.
+	DeadlineWindow time.Duration
+
+	// MaxRetries is the number of consecutive failed attempts after which a
+	// swap is suspended. Swaps close to their deadline are never suspended,
+	// and suspended swaps are resumed once they get close to their deadline.
+	MaxRetries int
+}
+
+// DefaultConfig is the Scheduler config used by the swapper tasks.
+var DefaultConfig = Config{
+	Interval:       time.Minute,
+	MinInterval:    30 * time.Second,
+	MaxInterval:    30 * time.Minute,
+	DeadlineWindow: time.Duration(swap.ExpiryUnit) * time.Second,
+	MaxRetries:     20,
+}
+
+// The Scheduler tracks when each swap should next be attempted, so that swaps
+// which have not changed are not retried on every tick.
+type Scheduler struct {
+	config   Config
+	attempts map[swap.SwapID]attempt
+}
+
+type attempt struct {
+	next      time.Time
+	deadline  int64
+	failures  int
+	suspended bool
+}
+
+// New returns a new Scheduler.
+func New(config Config) *Scheduler {
+	return &Scheduler{
+		config:   config,
+		attempts: map[swap.SwapID]attempt{},
+	}
+}
+
+// Due returns whether the swap should be attempted at the given time. Swaps
+// that have never been scheduled are always due, and suspended swaps are due
+// again once they are close to their deadline.
+func (scheduler *Scheduler) Due(id swap.SwapID, now time.Time) bool {
+	attempt, ok := scheduler.attempts[id]
+	if !ok {
+		return true
+	}
+	if attempt.suspended {
+		return scheduler.nearDeadline(attempt.deadline, now)
+	}
+	return !now.Before(attempt.next)
+}
+
+// Schedule records the outcome of an attempt and schedules the next one. A
+// deadline of zero means that the swap has no deadline. It returns an error
+// when the swap has been suspended after too many failed attempts.
+func (scheduler *Scheduler) Schedule(id swap.SwapID, now time.Time, deadline int64, err error) error {
+	attempt := scheduler.attempts[id]
+	attempt.deadline = deadline
+	nearDeadline := scheduler.nearDeadline(deadline, now)
+	if nearDeadline {
+		attempt.suspended = false
+	}
+
+	interval := scheduler.config.Interval
+	if err == nil {
+		attempt.failures = 0
+	} else {
+		attempt.failures++
+		if !nearDeadline && scheduler.config.MaxRetries > 0 && attempt.failures > scheduler.config.MaxRetries {
+			attempt.suspended = true
+			scheduler.attempts[id] = attempt
+			return fmt.Errorf("swap suspended after %d failed attempts: %v", scheduler.config.MaxRetries, err)
+		}
+		interval = scheduler.backoff(attempt.failures)
+	}
+	if nearDeadline && interval > scheduler.config.MinInterval {
+		interval = scheduler.config.MinInterval
+	}
+
+	attempt.next = now.Add(interval)
+	scheduler.attempts[id] = attempt
+	return nil
+}
+
+// Remove forgets the swap, so that it is due immediately if it is scheduled
+// again.
+func (scheduler *Scheduler) Remove(id swap.SwapID) {
+	delete(scheduler.attempts, id)
+}
+
+func (scheduler *Scheduler) nearDeadline(deadline int64, now time.Time) bool {
+	return deadline != 0 && time.Unix(deadline, 0).Sub(now) < scheduler.config.DeadlineWindow
+}
+
+func (scheduler *Scheduler) backoff(failures int) time.Duration {
+	interval := scheduler.config.Interval
+	for i := 0; i < failures && interval < scheduler.config.MaxInterval; i++ {
+		interval *= 2
+	}
+	if interval > scheduler.config.MaxInterval {
+		return scheduler.config.MaxInterval
+	}
+	return interval
+}
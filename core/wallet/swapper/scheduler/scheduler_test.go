@@ -0,0 +1,105 @@
+package scheduler_test
+
+import (
+	"errors"
+	"testing/quick"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/core/wallet/swapper/scheduler"
+
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/swapperd/testutils"
+)
+
+var _ = Describe("Scheduler", func() {
+
+	config := Config{
+		Interval:       time.Minute,
+		MinInterval:    10 * time.Second,
+		MaxInterval:    10 * time.Minute,
+		DeadlineWindow: time.Hour,
+		MaxRetries:     5,
+	}
+
+	Context("when scheduling a swap", func() {
+		It("should be due before it is scheduled and after the interval", func() {
+			test := func(id swap.SwapID) bool {
+				scheduler := New(config)
+				now := time.Now()
+				Expect(scheduler.Due(id, now)).Should(BeTrue())
+				Expect(scheduler.Schedule(id, now, 0, nil)).ShouldNot(HaveOccurred())
+				return !scheduler.Due(id, now.Add(config.Interval-time.Second)) && scheduler.Due(id, now.Add(config.Interval))
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should back off after failures up to the maximum interval", func() {
+			test := func(id swap.SwapID) bool {
+				scheduler := New(config)
+				now := time.Now()
+				Expect(scheduler.Schedule(id, now, 0, errors.New("connection failed"))).ShouldNot(HaveOccurred())
+				Expect(scheduler.Due(id, now.Add(config.Interval))).Should(BeFalse())
+				Expect(scheduler.Due(id, now.Add(2*config.Interval))).Should(BeTrue())
+
+				for i := 0; i < 4; i++ {
+					Expect(scheduler.Schedule(id, now, 0, errors.New("connection failed"))).ShouldNot(HaveOccurred())
+				}
+				return scheduler.Due(id, now.Add(config.MaxInterval))
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should poll faster near the deadline", func() {
+			test := func(id swap.SwapID) bool {
+				scheduler := New(config)
+				now := time.Now()
+				deadline := now.Add(config.DeadlineWindow / 2).Unix()
+				Expect(scheduler.Schedule(id, now, deadline, errors.New("connection failed"))).ShouldNot(HaveOccurred())
+				return scheduler.Due(id, now.Add(config.MinInterval))
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should suspend the swap after too many failures", func() {
+			test := func(id swap.SwapID) bool {
+				scheduler := New(config)
+				now := time.Now()
+				for i := 0; i < config.MaxRetries; i++ {
+					Expect(scheduler.Schedule(id, now, 0, errors.New("connection failed"))).ShouldNot(HaveOccurred())
+				}
+				Expect(scheduler.Schedule(id, now, 0, errors.New("connection failed"))).Should(HaveOccurred())
+				Expect(scheduler.Due(id, now.Add(24*time.Hour))).Should(BeFalse())
+
+				scheduler.Remove(id)
+				return scheduler.Due(id, now)
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should resume a suspended swap once it is close to its deadline", func() {
+			test := func(id swap.SwapID) bool {
+				scheduler := New(config)
+				now := time.Now()
+				deadline := now.Add(24 * time.Hour)
+				for i := 0; i < config.MaxRetries; i++ {
+					Expect(scheduler.Schedule(id, now, deadline.Unix(), errors.New("connection failed"))).ShouldNot(HaveOccurred())
+				}
+				Expect(scheduler.Schedule(id, now, deadline.Unix(), errors.New("connection failed"))).Should(HaveOccurred())
+				Expect(scheduler.Due(id, deadline.Add(-config.DeadlineWindow-time.Minute))).Should(BeFalse())
+
+				resumed := deadline.Add(-config.DeadlineWindow / 2)
+				Expect(scheduler.Due(id, resumed)).Should(BeTrue())
+				Expect(scheduler.Schedule(id, resumed, deadline.Unix(), errors.New("connection failed"))).ShouldNot(HaveOccurred())
+				return !scheduler.Due(id, resumed) && scheduler.Due(id, resumed.Add(config.MinInterval))
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+	})
+})
@@ -6,6 +6,7 @@ import (
 
 	"github.com/renproject/swapperd/core/wallet/swapper/delayed"
 	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
+	"github.com/renproject/swapperd/core/wallet/swapper/watcher"
 	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/renproject/swapperd/foundation/swap"
 	"github.com/republicprotocol/tau"
@@ -16,23 +17,35 @@ type Storage interface {
 	LoadCosts(id swap.SwapID) (blockchain.Cost, blockchain.Cost)
 	PutSwap(blob swap.SwapBlob) error
 	DeletePendingSwap(swap.SwapID) error
+	PendingSwap(swap.SwapID) (swap.SwapBlob, error)
 	PendingSwaps() ([]swap.SwapBlob, error)
+	PutSecret(id swap.SwapID, encryptedSecret string) error
+	Secret(id swap.SwapID) (string, error)
+}
+
+// A ContractBuilder builds the contracts of swaps, and the sources of their
+// secrets.
+type ContractBuilder interface {
+	immediate.ContractBuilder
+	watcher.Builder
 }
 
 type swapper struct {
 	delayedSwapper   tau.Task
 	immediateSwapper tau.Task
+	watcher          tau.Task
 	storage          Storage
 }
 
-func New(cap int, storage Storage, builder immediate.ContractBuilder, callback delayed.DelayCallback) tau.Task {
+func New(cap int, storage Storage, builder ContractBuilder, callback delayed.DelayCallback) tau.Task {
 	delayedSwapperTask := delayed.New(cap, callback)
-	immediateSwapperTask := immediate.New(cap, builder)
-	return tau.New(tau.NewIO(cap), NewSwapper(delayedSwapperTask, immediateSwapperTask, storage), delayedSwapperTask, immediateSwapperTask)
+	immediateSwapperTask := immediate.New(cap, immediate.DefaultWorkers, builder)
+	watcherTask := watcher.New(cap, watcher.DefaultConfig, builder)
+	return tau.New(tau.NewIO(cap), NewSwapper(delayedSwapperTask, immediateSwapperTask, watcherTask, storage), delayedSwapperTask, immediateSwapperTask, watcherTask)
 }
 
-func NewSwapper(delayedSwapperTask, immediateSwapperTask tau.Task, storage Storage) tau.Reducer {
-	return &swapper{delayedSwapperTask, immediateSwapperTask, storage}
+func NewSwapper(delayedSwapperTask, immediateSwapperTask, watcherTask tau.Task, storage Storage) tau.Reducer {
+	return &swapper{delayedSwapperTask, immediateSwapperTask, watcherTask, storage}
 }
 
 func (swapper *swapper) Reduce(msg tau.Message) tau.Message {
@@ -41,10 +54,22 @@ func (swapper *swapper) Reduce(msg tau.Message) tau.Message {
 		return swapper.handleBootload(msg)
 	case SwapRequest:
 		return swapper.handleSwapRequest(msg)
+	case CancelSwap:
+		return swapper.handleCancelSwap(msg.ID)
+	case FillSwap:
+		swapper.delayedSwapper.Send(delayed.FillSwap(msg))
+		return nil
 	case immediate.ReceiptUpdate:
 		return ReceiptUpdate(msg)
 	case immediate.DeleteSwap:
+		swapper.watcher.Send(watcher.Unwatch{ID: msg.ID})
 		return swapper.handleDeleteSwap(msg.ID)
+	case immediate.WatchSecret:
+		swapper.watcher.Send(watcher.Watch(msg))
+		return nil
+	case watcher.SecretRevealed:
+		swapper.immediateSwapper.Send(immediate.SecretRevealed(msg))
+		return nil
 	case delayed.SwapRequest:
 		return swapper.handleSwapRequest(SwapRequest(msg))
 	case delayed.ReceiptUpdate:
@@ -63,10 +88,20 @@ func (swapper *swapper) Reduce(msg tau.Message) tau.Message {
 func (swapper *swapper) handleTick(msg tau.Message) tau.Message {
 	swapper.immediateSwapper.Send(msg)
 	swapper.delayedSwapper.Send(msg)
+	swapper.watcher.Send(msg)
 	return nil
 }
 
 func (swapper *swapper) handleSwapRequest(msg SwapRequest) tau.Message {
+	if msg.Secret != [32]byte{} {
+		encryptedSecret, err := swap.EncryptSecret(msg.Password, msg.Secret)
+		if err != nil {
+			return tau.NewError(fmt.Errorf("cannot encrypt secret of swap %s: %v", msg.ID, err))
+		}
+		if err := swapper.storage.PutSecret(msg.ID, encryptedSecret); err != nil {
+			return tau.NewError(err)
+		}
+	}
 	if err := swapper.storage.PutSwap(swap.SwapBlob(msg)); err != nil {
 		return tau.NewError(err)
 	}
@@ -103,6 +138,13 @@ func (swapper *swapper) handleBootload(msg Bootload) tau.Message {
 		})))
 
 		pendingSwap.Password = msg.Password
+		secret, err := swapper.loadSecret(pendingSwap.ID, msg.Password)
+		if err != nil {
+			msgs = append(msgs, tau.NewError(err))
+			continue
+		}
+		pendingSwap.Secret = secret
+
 		if pendingSwap.Delay {
 			swapper.delayedSwapper.Send(delayed.DelayedSwapRequest(pendingSwap))
 			continue
@@ -115,6 +157,43 @@ func (swapper *swapper) handleBootload(msg Bootload) tau.Message {
 	return tau.NewMessageBatch(msgs)
 }
 
+// loadSecret decrypts the stored secret of a swap. Swaps without a stored
+// secret get an empty secret, and fall back to the LegacySecret.
+func (swapper *swapper) loadSecret(id swap.SwapID, password string) ([32]byte, error) {
+	encryptedSecret, err := swapper.storage.Secret(id)
+	if err != nil {
+		if err == swap.ErrSecretNotFound {
+			return [32]byte{}, nil
+		}
+		return [32]byte{}, fmt.Errorf("cannot load secret of swap %s: %v", id, err)
+	}
+	secret, err := swap.DecryptSecret(password, encryptedSecret)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("cannot load secret of swap %s: %v", id, err)
+	}
+	return secret, nil
+}
+
+func (swapper *swapper) handleCancelSwap(id swap.SwapID) tau.Message {
+	blob, err := swapper.storage.PendingSwap(id)
+	if err != nil {
+		return tau.NewError(fmt.Errorf("cannot cancel swap %s: %v", id, err))
+	}
+
+	if blob.Delay {
+		swapper.delayedSwapper.Send(delayed.CancelSwap{ID: id})
+		return nil
+	}
+
+	// Persist the cancellation so that the swap is not resumed after a restart.
+	blob.Cancelled = true
+	if err := swapper.storage.PutSwap(blob); err != nil {
+		return tau.NewError(err)
+	}
+	swapper.immediateSwapper.Send(immediate.CancelSwap{ID: id})
+	return nil
+}
+
 func (swapper *swapper) handleDeleteSwap(id swap.SwapID) tau.Message {
 	if err := swapper.storage.DeletePendingSwap(id); err != nil {
 		return tau.NewError(err)
@@ -127,6 +206,22 @@ type SwapRequest swap.SwapBlob
 func (SwapRequest) IsMessage() {
 }
 
+type CancelSwap struct {
+	ID swap.SwapID
+}
+
+func (CancelSwap) IsMessage() {
+}
+
+type FillSwap struct {
+	ID        swap.SwapID
+	Message   []byte
+	Signature []byte
+}
+
+func (FillSwap) IsMessage() {
+}
+
 type Bootload struct {
 	Password string
 }
@@ -18,14 +18,16 @@ func TestSwapper(t *testing.T) {
 }
 
 type MockStorage struct {
-	mu    *sync.RWMutex
-	swaps map[swap.SwapID]swap.SwapBlob
+	mu      *sync.RWMutex
+	swaps   map[swap.SwapID]swap.SwapBlob
+	secrets map[swap.SwapID]string
 }
 
 func NewMockStorage() *MockStorage {
 	return &MockStorage{
-		mu:    new(sync.RWMutex),
-		swaps: map[swap.SwapID]swap.SwapBlob{},
+		mu:      new(sync.RWMutex),
+		swaps:   map[swap.SwapID]swap.SwapBlob{},
+		secrets: map[swap.SwapID]string{},
 	}
 }
 
@@ -68,6 +70,25 @@ func (store *MockStorage) PendingSwaps() ([]swap.SwapBlob, error) {
 	return swaps, nil
 }
 
+func (store *MockStorage) PutSecret(id swap.SwapID, encryptedSecret string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.secrets[id] = encryptedSecret
+	return nil
+}
+
+func (store *MockStorage) Secret(id swap.SwapID) (string, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	encryptedSecret, ok := store.secrets[id]
+	if !ok {
+		return "", swap.ErrSecretNotFound
+	}
+	return encryptedSecret, nil
+}
+
 func (store *MockStorage) LoadCosts(id swap.SwapID) (blockchain.Cost, blockchain.Cost) {
 	return blockchain.Cost{}, blockchain.Cost{}
 }
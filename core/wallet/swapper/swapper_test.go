@@ -38,8 +38,11 @@ var _ = Describe("Swapper", func() {
 	}
 
 	init := func(storage Storage, delayed, immediate tau.Task) tau.Task {
-		reducer := NewSwapper(delayed, immediate, storage)
-		return tau.New(tau.NewIO(2048), reducer, delayed, immediate)
+		watcher := newTask(func(tau.Message) tau.Message {
+			return nil
+		})
+		reducer := NewSwapper(delayed, immediate, watcher, storage)
+		return tau.New(tau.NewIO(2048), reducer, delayed, immediate, watcher)
 	}
 
 	Context("when receiving bootload message", func() {
@@ -0,0 +1,223 @@
+package watcher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
+	"github.com/renproject/swapperd/core/wallet/swapper/scheduler"
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/republicprotocol/tau"
+)
+
+// DefaultConfig is the Scheduler config of the polls of the watched swaps.
+// Swaps are polled on ticks, at most every Interval, and polls that fail are
+// backed off. Watched swaps are never suspended.
+var DefaultConfig = scheduler.Config{
+	Interval:    15 * time.Second,
+	MaxInterval: 10 * time.Minute,
+}
+
+// A SecretSource reveals the secret of a swap as soon as a transaction that
+// redeems its native contract is broadcast, before it is mined. It returns
+// ErrAuditPending until then.
+type SecretSource interface {
+	PendingSecret() ([32]byte, error)
+}
+
+// A Builder builds the SecretSource of the native contract of a swap.
+type Builder interface {
+	BuildSecretSource(req immediate.SwapRequest) (SecretSource, error)
+}
+
+type watcher struct {
+	task      tau.Task
+	builder   Builder
+	scheduler *scheduler.Scheduler
+	watches   map[swap.SwapID]*watch
+
+	// polling is set while the due swaps are being polled. Swaps are polled
+	// one after the other, by a single goroutine, and ticks are ignored until
+	// it is done.
+	polling bool
+}
+
+type watch struct {
+	req        immediate.SwapRequest
+	secretHash []byte
+	source     SecretSource
+}
+
+// New returns a task that watches the pending transactions of swaps for their
+// secret, and outputs it as soon as it is revealed.
+func New(cap int, config scheduler.Config, builder Builder) tau.Task {
+	watcher := &watcher{
+		builder:   builder,
+		scheduler: scheduler.New(config),
+		watches:   map[swap.SwapID]*watch{},
+	}
+	watcher.task = tau.New(tau.NewIO(cap), watcher)
+	return watcher.task
+}
+
+func (watcher *watcher) Reduce(msg tau.Message) tau.Message {
+	switch msg := msg.(type) {
+	case Watch:
+		return watcher.handleWatch(msg.Request)
+	case Unwatch:
+		watcher.handleUnwatch(msg.ID)
+		return nil
+	case pollResults:
+		return watcher.handleResults(msg)
+	case tau.Tick:
+		watcher.handleTick()
+		return nil
+	default:
+		return tau.NewError(fmt.Errorf("invalid message type in watcher: %T", msg))
+	}
+}
+
+// handleWatch starts watching a swap, unless it is already being watched. The
+// swap is watched until its secret is revealed, it is unwatched, or the native
+// contract expires.
+func (watcher *watcher) handleWatch(req immediate.SwapRequest) tau.Message {
+	id := req.Blob.ID
+	if _, ok := watcher.watches[id]; ok {
+		return nil
+	}
+	secretHash, err := base64.StdEncoding.DecodeString(req.Blob.SecretHash)
+	if err != nil {
+		return tau.NewError(fmt.Errorf("cannot watch swap %s: %v", id, err))
+	}
+	watcher.watches[id] = &watch{req: req, secretHash: secretHash}
+	return nil
+}
+
+func (watcher *watcher) handleUnwatch(id swap.SwapID) {
+	delete(watcher.watches, id)
+	watcher.scheduler.Remove(id)
+}
+
+// handleTick polls the swaps that are due, unless the previous polls are not
+// done yet. Swaps whose native contract has expired are no longer watched.
+func (watcher *watcher) handleTick() {
+	if watcher.polling {
+		return
+	}
+	now := time.Now()
+	polls := pollResults{}
+	for id, w := range watcher.watches {
+		if now.Unix() > w.req.Blob.TimeLock {
+			watcher.handleUnwatch(id)
+			continue
+		}
+		if watcher.scheduler.Due(id, now) {
+			polls = append(polls, pollResult{watch: w, source: w.source})
+		}
+	}
+	if len(polls) == 0 {
+		return
+	}
+
+	watcher.polling = true
+	go func() {
+		for i := range polls {
+			polls[i].poll(watcher.builder)
+		}
+		watcher.task.Send(polls)
+	}()
+}
+
+func (watcher *watcher) handleResults(results pollResults) tau.Message {
+	watcher.polling = false
+	now := time.Now()
+	messages := []tau.Message{}
+	for _, result := range results {
+		id := result.watch.req.Blob.ID
+		// The swap may have been unwatched, and watched again, while it was
+		// being polled.
+		if watcher.watches[id] != result.watch {
+			continue
+		}
+		if result.buildErr != nil {
+			watcher.handleUnwatch(id)
+			messages = append(messages, tau.NewError(fmt.Errorf("cannot watch swap %s: %v", id, result.buildErr)))
+			continue
+		}
+		if result.revealed {
+			watcher.handleUnwatch(id)
+			messages = append(messages, SecretRevealed{ID: id, Secret: result.secret})
+			continue
+		}
+		result.watch.source = result.source
+		watcher.scheduler.Schedule(id, now, 0, result.err)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return tau.NewMessageBatch(messages)
+}
+
+// pollResult is the outcome of a poll of the pending transactions of a
+// watched swap.
+type pollResult struct {
+	watch    *watch
+	source   SecretSource
+	secret   [32]byte
+	revealed bool
+	err      error
+	buildErr error
+}
+
+// poll gets the secret revealed by the pending transactions of the swap, if
+// any. The secret source of the swap is built on its first poll.
+func (result *pollResult) poll(builder Builder) {
+	if result.source == nil {
+		source, err := builder.BuildSecretSource(result.watch.req)
+		if err != nil {
+			result.buildErr = err
+			return
+		}
+		result.source = source
+	}
+	secret, err := result.source.PendingSecret()
+	if err != nil {
+		if err != immediate.ErrAuditPending {
+			result.err = err
+		}
+		return
+	}
+	if hash := sha256.Sum256(secret[:]); bytes.Equal(hash[:], result.watch.secretHash) {
+		result.secret, result.revealed = secret, true
+	}
+}
+
+type pollResults []pollResult
+
+func (pollResults) IsMessage() {
+}
+
+type Watch struct {
+	Request immediate.SwapRequest
+}
+
+func (Watch) IsMessage() {
+}
+
+type Unwatch struct {
+	ID swap.SwapID
+}
+
+func (Unwatch) IsMessage() {
+}
+
+type SecretRevealed struct {
+	ID     swap.SwapID
+	Secret [32]byte
+}
+
+func (SecretRevealed) IsMessage() {
+}
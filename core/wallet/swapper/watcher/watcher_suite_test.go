@@ -0,0 +1,90 @@
+package watcher_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
+	"github.com/renproject/swapperd/core/wallet/swapper/watcher"
+)
+
+func TestWatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Watcher Suite")
+}
+
+// MockBuilder builds secret sources that reveal the secret after the given
+// number of polls. Negative polls never reveal it. The sources of a builder
+// fail every poll if Fail is set, and record how many polls are running at
+// the same time.
+type MockBuilder struct {
+	Fail bool
+
+	secret    [32]byte
+	polls     int
+	mu        *sync.Mutex
+	total     int
+	active    int
+	maxActive int
+}
+
+func NewMockBuilder(secret [32]byte, polls int) *MockBuilder {
+	return &MockBuilder{secret: secret, polls: polls, mu: new(sync.Mutex)}
+}
+
+func (builder *MockBuilder) BuildSecretSource(req immediate.SwapRequest) (watcher.SecretSource, error) {
+	secretHash := sha256.Sum256(builder.secret[:])
+	if req.Blob.SecretHash != base64.StdEncoding.EncodeToString(secretHash[:]) {
+		return nil, fmt.Errorf("unknown swap")
+	}
+	return &MockSecretSource{builder, builder.polls}, nil
+}
+
+func (builder *MockBuilder) Polls() int {
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+	return builder.total
+}
+
+func (builder *MockBuilder) MaxActive() int {
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+	return builder.maxActive
+}
+
+type MockSecretSource struct {
+	builder *MockBuilder
+	polls   int
+}
+
+func (source *MockSecretSource) PendingSecret() ([32]byte, error) {
+	builder := source.builder
+	builder.mu.Lock()
+	builder.total++
+	builder.active++
+	if builder.active > builder.maxActive {
+		builder.maxActive = builder.active
+	}
+	builder.mu.Unlock()
+
+	time.Sleep(100 * time.Microsecond)
+	builder.mu.Lock()
+	builder.active--
+	builder.mu.Unlock()
+
+	if builder.Fail {
+		return [32]byte{}, fmt.Errorf("connection failed")
+	}
+	if source.polls != 0 {
+		source.polls--
+		return [32]byte{}, immediate.ErrAuditPending
+	}
+	return builder.secret, nil
+}
@@ -0,0 +1,173 @@
+package watcher_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing/quick"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/core/wallet/swapper/watcher"
+
+	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
+	"github.com/renproject/swapperd/core/wallet/swapper/scheduler"
+	"github.com/renproject/swapperd/foundation/swap"
+	"github.com/renproject/swapperd/testutils"
+	"github.com/republicprotocol/tau"
+)
+
+var _ = Describe("Watcher Task", func() {
+
+	config := scheduler.Config{
+		Interval:    time.Millisecond,
+		MaxInterval: time.Millisecond,
+	}
+
+	request := func(id swap.SwapID, secret [32]byte) immediate.SwapRequest {
+		secretHash := sha256.Sum256(secret[:])
+		return immediate.SwapRequest{
+			Blob: swap.SwapBlob{
+				ID:         id,
+				SecretHash: base64.StdEncoding.EncodeToString(secretHash[:]),
+				TimeLock:   time.Now().Add(time.Hour).Unix(),
+			},
+		}
+	}
+
+	// tick sends ticks to the watcher until done is closed.
+	tick := func(watcher tau.Task, done <-chan struct{}) {
+		for {
+			select {
+			case <-done:
+				return
+			case watcher.IO().InputWriter() <- tau.Tick{}:
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+
+	// output returns the next message output by the watcher, that is not a
+	// batch of messages.
+	output := func(watcher tau.Task) tau.Message {
+		msg := <-watcher.IO().OutputReader()
+		if batch, ok := msg.(tau.MessageBatch); ok && len(batch) == 1 {
+			return batch[0]
+		}
+		return msg
+	}
+
+	Context("when watching a swap", func() {
+		It("should output the secret once it is revealed", func() {
+			test := func(id swap.SwapID, secret [32]byte, polls uint8) bool {
+				done := make(chan struct{})
+				defer close(done)
+				watcher := New(16, config, NewMockBuilder(secret, int(polls%8)))
+				go watcher.Run(done)
+				go tick(watcher, done)
+
+				watcher.Send(Watch{request(id, secret)})
+				revealed, ok := output(watcher).(SecretRevealed)
+				return ok && revealed.ID == id && revealed.Secret == secret
+			}
+
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should ignore secrets that do not match the secret hash", func() {
+			done := make(chan struct{})
+			defer close(done)
+			watcher := New(16, config, NewMockBuilder([32]byte{1}, 0))
+			go watcher.Run(done)
+			go tick(watcher, done)
+
+			req := request("swap", [32]byte{1})
+			req.Blob.SecretHash = base64.StdEncoding.EncodeToString(make([]byte, 32))
+			watcher.Send(Watch{req})
+			Consistently(watcher.IO().OutputReader(), 50*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should return an error when the swap cannot be watched", func() {
+			done := make(chan struct{})
+			defer close(done)
+			watcher := New(16, config, NewMockBuilder([32]byte{1}, 0))
+			go watcher.Run(done)
+			go tick(watcher, done)
+
+			watcher.Send(Watch{request("swap", [32]byte{2})})
+			_, ok := output(watcher).(tau.Error)
+			Expect(ok).Should(BeTrue())
+		})
+
+		It("should not poll the swap before it is ticked", func() {
+			done := make(chan struct{})
+			defer close(done)
+			builder := NewMockBuilder([32]byte{1}, 0)
+			watcher := New(16, config, builder)
+			go watcher.Run(done)
+
+			watcher.Send(Watch{request("swap", [32]byte{1})})
+			Consistently(watcher.IO().OutputReader(), 50*time.Millisecond).ShouldNot(Receive())
+			Expect(builder.Polls()).Should(Equal(0))
+		})
+	})
+
+	Context("when watching many swaps", func() {
+		It("should poll them one at a time", func() {
+			done := make(chan struct{})
+			defer close(done)
+			builder := NewMockBuilder([32]byte{1}, -1)
+			watcher := New(16, config, builder)
+			go watcher.Run(done)
+
+			for i := 0; i < 8; i++ {
+				watcher.Send(Watch{request(swap.SwapID(fmt.Sprintf("swap-%d", i)), [32]byte{1})})
+			}
+			go tick(watcher, done)
+			Eventually(builder.Polls).Should(BeNumerically(">=", 32))
+			Expect(builder.MaxActive()).Should(Equal(1))
+		})
+
+		It("should back off the polls that fail", func() {
+			done := make(chan struct{})
+			defer close(done)
+			builder := NewMockBuilder([32]byte{1}, -1)
+			builder.Fail = true
+			watcher := New(16, scheduler.Config{Interval: time.Millisecond, MaxInterval: time.Hour}, builder)
+			go watcher.Run(done)
+			go tick(watcher, done)
+
+			watcher.Send(Watch{request("swap", [32]byte{1})})
+			time.Sleep(100 * time.Millisecond)
+			Expect(builder.Polls()).Should(BeNumerically("<=", 8))
+		})
+	})
+
+	Context("when unwatching a swap", func() {
+		It("should stop watching the swap", func() {
+			done := make(chan struct{})
+			defer close(done)
+			watcher := New(16, config, NewMockBuilder([32]byte{1}, -1))
+			go watcher.Run(done)
+			go tick(watcher, done)
+
+			watcher.Send(Watch{request("swap", [32]byte{1})})
+			watcher.Send(Unwatch{"swap"})
+			Consistently(watcher.IO().OutputReader(), 50*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	Context("when receiving an unknown message type", func() {
+		It("should return an error", func() {
+			done := make(chan struct{})
+			defer close(done)
+			watcher := New(16, config, NewMockBuilder([32]byte{}, 0))
+			go watcher.Run(done)
+
+			watcher.Send(tau.RandomMessage{})
+			_, ok := (<-watcher.IO().OutputReader()).(tau.Error)
+			Expect(ok).Should(BeTrue())
+		})
+	})
+})
@@ -3,10 +3,10 @@ package wallet
 import (
 	"fmt"
 
+	"github.com/renproject/swapperd/core/wallet/schedule"
 	"github.com/renproject/swapperd/core/wallet/status"
 	"github.com/renproject/swapperd/core/wallet/swapper"
 	"github.com/renproject/swapperd/core/wallet/swapper/delayed"
-	"github.com/renproject/swapperd/core/wallet/swapper/immediate"
 	"github.com/renproject/swapperd/core/wallet/transfer"
 	"github.com/renproject/swapperd/foundation/swap"
 	"github.com/republicprotocol/tau"
@@ -16,19 +16,22 @@ type Storage interface {
 	status.Storage
 	transfer.Storage
 	swapper.Storage
+	schedule.Storage
 }
 
 type wallet struct {
 	swapStatusTask tau.Task
 	swapperTask    tau.Task
 	transferTask   tau.Task
+	scheduleTask   tau.Task
 }
 
-func New(cap int, storage Storage, bc transfer.Blockchain, builder immediate.ContractBuilder, callback delayed.DelayCallback) tau.Task {
+func New(cap int, storage Storage, bc transfer.Blockchain, builder swapper.ContractBuilder, callback delayed.DelayCallback, swapBuilder schedule.SwapBuilder) tau.Task {
 	swapperTask := swapper.New(cap, storage, builder, callback)
 	swapStatusTask := status.New(cap, storage)
 	transferTask := transfer.New(cap, bc, storage)
-	return tau.New(tau.NewIO(cap), &wallet{swapStatusTask, swapperTask, transferTask}, swapStatusTask, swapperTask, transferTask)
+	scheduleTask := schedule.New(cap, swapBuilder, storage)
+	return tau.New(tau.NewIO(cap), &wallet{swapStatusTask, swapperTask, transferTask, scheduleTask}, swapStatusTask, swapperTask, transferTask, scheduleTask)
 }
 
 func (wallet *wallet) Reduce(msg tau.Message) tau.Message {
@@ -39,10 +42,18 @@ func (wallet *wallet) Reduce(msg tau.Message) tau.Message {
 		wallet.handleTick(msg)
 	case swapper.SwapRequest:
 		wallet.handleSwapRequest(msg)
+	case swapper.CancelSwap:
+		wallet.swapperTask.Send(msg)
+	case swapper.FillSwap:
+		wallet.swapperTask.Send(msg)
 	case swapper.ReceiptUpdate:
 		wallet.swapStatusTask.Send(status.ReceiptUpdate(msg))
 	case transfer.TransferRequest:
 		wallet.transferTask.Send(msg)
+	case schedule.ScheduleRequest, schedule.UpdateSchedule, schedule.CancelSchedule:
+		wallet.scheduleTask.Send(msg)
+	case schedule.SwapRequest:
+		wallet.handleSwapRequest(swapper.SwapRequest(msg))
 	case tau.Error:
 		return msg
 	default:
@@ -58,10 +69,12 @@ func (wallet *wallet) handleSwapRequest(msg swapper.SwapRequest) {
 
 func (wallet *wallet) handleBootload(msg Bootload) {
 	wallet.swapperTask.Send(swapper.Bootload{msg.Password})
+	wallet.scheduleTask.Send(schedule.Bootload{msg.Password})
 }
 
 func (wallet *wallet) handleTick(msg tau.Tick) {
 	wallet.swapperTask.Send(msg)
+	wallet.scheduleTask.Send(msg)
 }
 
 type Bootload struct {
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/renproject/swapperd/adapter/wallet"
+	"github.com/renproject/swapperd/foundation/blockchain"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,10 +19,20 @@ func Wallet(homeDir, network string, logger logrus.FieldLogger) (wallet.Wallet,
 	if err != nil {
 		return nil, err
 	}
-	config := wallet.Config{}
+	// Values missing from the keystore, such as settings added after it was
+	// generated, keep the defaults of the network.
+	config, err := generateConfig(strings.ToLower(network), "")
+	if err != nil {
+		config = wallet.Config{}
+	}
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
+	for _, token := range config.Tokens {
+		if err := blockchain.RegisterERC20Token(token); err != nil {
+			return nil, err
+		}
+	}
 	return wallet.New(config, logger), nil
 }
 
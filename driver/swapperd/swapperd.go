@@ -1,6 +1,8 @@
 package swapperd
 
 import (
+	"io"
+
 	"github.com/renproject/swapperd/adapter/binder"
 	"github.com/renproject/swapperd/adapter/callback"
 	"github.com/renproject/swapperd/adapter/db"
@@ -20,6 +22,7 @@ type swapperd struct {
 	logger      logrus.FieldLogger
 	walletTask  tau.Task
 	serviceTask tau.Task
+	wallet      io.Closer
 }
 
 type Swapperd interface {
@@ -42,9 +45,11 @@ func New(version, homeDir, network, port string, logger logrus.FieldLogger) Swap
 	serviceTask := server.NewService(BufferCapacity, receiver)
 	serviceTask.Send(server.AcceptRequest{})
 
-	server := server.NewHttpServer(BufferCapacity, port, version, receiver, storage, bc, logger)
-	walletTask := wallet.New(BufferCapacity, storage, bc, binder.NewBuilder(bc, logger), callback.New())
-	return &swapperd{server, logger, walletTask, serviceTask}
+	builder := binder.NewBuilder(bc, logger)
+	ledger := server.NewLedger()
+	walletTask := wallet.New(BufferCapacity, storage, bc, builder, callback.New(bc), server.NewSwapBuilder(bc, storage, ledger))
+	server := server.NewHttpServer(BufferCapacity, port, version, receiver, storage, ledger, bc, builder, logger)
+	return &swapperd{server, logger, walletTask, serviceTask, bc}
 }
 
 func (swapperd *swapperd) Run(done <-chan struct{}) {
@@ -67,4 +72,7 @@ func (swapperd *swapperd) Run(done <-chan struct{}) {
 			swapperd.server.Run(done)
 		},
 	)
+	if err := swapperd.wallet.Close(); err != nil {
+		swapperd.logger.Error(err)
+	}
 }
@@ -0,0 +1,132 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/renproject/tokens"
+)
+
+// An ERC20Token is an ERC20 token that is added to swapperd at runtime,
+// rather than compiled into the tokens package.
+type ERC20Token struct {
+	Name     tokens.Name `json:"name"`
+	Aliases  []string    `json:"aliases,omitempty"`
+	Decimals uint8       `json:"decimals"`
+
+	// Address is the address of the ERC20 contract, and SwapAddress is the
+	// address of the swap contract of the token.
+	Address     string `json:"address"`
+	SwapAddress string `json:"swapAddress"`
+
+	// TransferFeeBips is the fee, in bips of the amount transferred, that the
+	// token charges on transfers.
+	TransferFeeBips int64 `json:"transferFeeBips,omitempty"`
+}
+
+// Token returns the token of the ERC20 token.
+func (token ERC20Token) Token() tokens.Token {
+	return tokens.Token{Name: token.Name, Decimals: token.Decimals, Blockchain: tokens.ERC20}
+}
+
+// Verify returns an error if the ERC20 token is invalid, or its name or
+// aliases resolve to another token.
+func (token ERC20Token) Verify() error {
+	if token.Name == "" {
+		return fmt.Errorf("erc20 token has no name")
+	}
+	for _, name := range append([]string{string(token.Name)}, token.Aliases...) {
+		if existing, err := PatchToken(name); err == nil && existing.Name != token.Name {
+			return fmt.Errorf("erc20 token %s: %s is the name of %s", token.Name, name, existing.Name)
+		}
+	}
+	if _, err := tokens.PatchToken(string(token.Name)); err == nil {
+		return fmt.Errorf("erc20 token %s is already supported", token.Name)
+	}
+	if !common.IsHexAddress(token.Address) {
+		return fmt.Errorf("erc20 token %s has an invalid address: %s", token.Name, token.Address)
+	}
+	if !common.IsHexAddress(token.SwapAddress) {
+		return fmt.Errorf("erc20 token %s has an invalid swap address: %s", token.Name, token.SwapAddress)
+	}
+	if token.TransferFeeBips < 0 || token.TransferFeeBips > 10000 {
+		return fmt.Errorf("erc20 token %s has an invalid transfer fee: %d bips", token.Name, token.TransferFeeBips)
+	}
+	return nil
+}
+
+// AdditionalTransactionFee returns the fee that the token charges on top of
+// the amount transferred, or nil if it charges none.
+func (token ERC20Token) AdditionalTransactionFee(amount *big.Int) *big.Int {
+	if token.TransferFeeBips == 0 || amount == nil {
+		return nil
+	}
+	return new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(token.TransferFeeBips)), big.NewInt(10000))
+}
+
+var registry = struct {
+	mu     *sync.RWMutex
+	tokens map[tokens.Name]ERC20Token
+}{
+	mu:     new(sync.RWMutex),
+	tokens: map[tokens.Name]ERC20Token{},
+}
+
+// RegisterERC20Token adds the ERC20 token to the tokens that are supported by
+// swapperd. A token that is registered again is replaced.
+func RegisterERC20Token(token ERC20Token) error {
+	if err := token.Verify(); err != nil {
+		return err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.tokens[token.Name] = token
+	return nil
+}
+
+// ERC20Tokens returns the registered ERC20 tokens, ordered by name.
+func ERC20Tokens() []ERC20Token {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	erc20Tokens := make([]ERC20Token, 0, len(registry.tokens))
+	for _, token := range registry.tokens {
+		erc20Tokens = append(erc20Tokens, token)
+	}
+	sort.Slice(erc20Tokens, func(i, j int) bool {
+		return erc20Tokens[i].Name < erc20Tokens[j].Name
+	})
+	return erc20Tokens
+}
+
+// RegisteredERC20Token returns the registered ERC20 token with the given
+// name, or one of its aliases.
+func RegisteredERC20Token(name string) (ERC20Token, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	name = strings.ToLower(name)
+	for _, token := range registry.tokens {
+		if strings.ToLower(string(token.Name)) == name {
+			return token, true
+		}
+		for _, alias := range token.Aliases {
+			if strings.ToLower(alias) == name {
+				return token, true
+			}
+		}
+	}
+	return ERC20Token{}, false
+}
+
+// AdditionalTransactionFee returns the fee that the token charges on top of
+// the amount transferred, or nil if it charges none.
+func AdditionalTransactionFee(token tokens.Token, amount *big.Int) *big.Int {
+	if erc20Token, ok := RegisteredERC20Token(string(token.Name)); ok && token.Blockchain == tokens.ERC20 {
+		return erc20Token.AdditionalTransactionFee(amount)
+	}
+	return token.AdditionalTransactionFee(amount)
+}
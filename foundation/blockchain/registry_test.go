@@ -0,0 +1,138 @@
+package blockchain_test
+
+import (
+	"math/big"
+	"testing/quick"
+
+	"github.com/renproject/swapperd/testutils"
+	"github.com/renproject/tokens"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/foundation/blockchain"
+)
+
+var _ = Describe("ERC20 token registry", func() {
+	newToken := func(name string, aliases ...string) ERC20Token {
+		return ERC20Token{
+			Name:        tokens.Name(name),
+			Aliases:     aliases,
+			Decimals:    18,
+			Address:     "0x1111111111111111111111111111111111111111",
+			SwapAddress: "0x2222222222222222222222222222222222222222",
+		}
+	}
+
+	Context("when verifying a token", func() {
+		It("should accept a valid token", func() {
+			Expect(newToken("VRFY", "verify").Verify()).Should(Succeed())
+		})
+
+		It("should reject a token without a name", func() {
+			Expect(newToken("").Verify()).ShouldNot(Succeed())
+		})
+
+		It("should reject a token whose name or aliases resolve to another token", func() {
+			Expect(newToken("LTC2", "ltc").Verify()).ShouldNot(Succeed())
+			Expect(newToken("BCH2", "bitcoin-cash").Verify()).ShouldNot(Succeed())
+			Expect(newToken("ETH2", "eth").Verify()).ShouldNot(Succeed())
+
+			Expect(RegisterERC20Token(newToken("TAKEN", "taken-alias"))).Should(Succeed())
+			Expect(newToken("OTHER", "taken").Verify()).ShouldNot(Succeed())
+			Expect(newToken("OTHER", "TAKEN-ALIAS").Verify()).ShouldNot(Succeed())
+			Expect(newToken("TAKEN-ALIAS").Verify()).ShouldNot(Succeed())
+		})
+
+		It("should reject a token that is compiled into the tokens package", func() {
+			Expect(newToken(string(tokens.NameWBTC)).Verify()).ShouldNot(Succeed())
+			Expect(RegisterERC20Token(newToken(string(tokens.NameWBTC)))).ShouldNot(Succeed())
+			_, ok := RegisteredERC20Token(string(tokens.NameWBTC))
+			Expect(ok).Should(BeFalse())
+		})
+
+		It("should reject invalid addresses", func() {
+			token := newToken("ADDR")
+			token.Address = "0x1234"
+			Expect(token.Verify()).ShouldNot(Succeed())
+
+			token = newToken("ADDR")
+			token.SwapAddress = "not an address"
+			Expect(token.Verify()).ShouldNot(Succeed())
+
+			token = newToken("ADDR")
+			token.Address = ""
+			Expect(token.Verify()).ShouldNot(Succeed())
+		})
+
+		It("should reject invalid transfer fees", func() {
+			token := newToken("FEE")
+			token.TransferFeeBips = -1
+			Expect(token.Verify()).ShouldNot(Succeed())
+			token.TransferFeeBips = 10001
+			Expect(token.Verify()).ShouldNot(Succeed())
+			token.TransferFeeBips = 10000
+			Expect(token.Verify()).Should(Succeed())
+		})
+	})
+
+	Context("when looking up a registered token", func() {
+		It("should find the token by its name or aliases, ignoring case", func() {
+			token := newToken("LOOK", "lookup", "Look-Up")
+			Expect(RegisterERC20Token(token)).Should(Succeed())
+			for _, name := range []string{"LOOK", "look", "LOOKUP", "look-up"} {
+				registered, ok := RegisteredERC20Token(name)
+				Expect(ok).Should(BeTrue())
+				Expect(registered).Should(Equal(token))
+			}
+			_, ok := RegisteredERC20Token("looks")
+			Expect(ok).Should(BeFalse())
+
+			patched, err := PatchToken("lookup")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(patched).Should(Equal(tokens.Token{Name: "LOOK", Decimals: 18, Blockchain: tokens.ERC20}))
+		})
+
+		It("should replace a token that is registered again", func() {
+			Expect(RegisterERC20Token(newToken("AGAIN"))).Should(Succeed())
+			token := newToken("AGAIN", "again-alias")
+			token.Decimals = 6
+			Expect(RegisterERC20Token(token)).Should(Succeed())
+			registered, ok := RegisteredERC20Token("again-alias")
+			Expect(ok).Should(BeTrue())
+			Expect(registered.Decimals).Should(Equal(uint8(6)))
+		})
+	})
+
+	Context("when computing the additional transaction fee", func() {
+		It("should charge the transfer fee of the token on the amount", func() {
+			token := newToken("TFEE")
+			token.TransferFeeBips = 25
+			Expect(RegisterERC20Token(token)).Should(Succeed())
+
+			test := func(amount uint64) bool {
+				value := new(big.Int).SetUint64(amount)
+				fee := new(big.Int).Div(new(big.Int).Mul(value, big.NewInt(25)), big.NewInt(10000))
+				return token.AdditionalTransactionFee(value).Cmp(fee) == 0 &&
+					AdditionalTransactionFee(token.Token(), value).Cmp(fee) == 0
+			}
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+			Expect(AdditionalTransactionFee(token.Token(), big.NewInt(10000))).Should(Equal(big.NewInt(25)))
+		})
+
+		It("should not charge a fee on tokens without a transfer fee", func() {
+			token := newToken("NOFEE")
+			Expect(RegisterERC20Token(token)).Should(Succeed())
+			Expect(token.AdditionalTransactionFee(big.NewInt(10000))).Should(BeNil())
+			Expect(token.AdditionalTransactionFee(nil)).Should(BeNil())
+			Expect(AdditionalTransactionFee(token.Token(), big.NewInt(10000))).Should(BeNil())
+			Expect(AdditionalTransactionFee(tokens.ETH, big.NewInt(10000))).Should(BeNil())
+		})
+
+		It("should only charge the fee of a registered token on its erc20 token", func() {
+			token := newToken("ONLY")
+			token.TransferFeeBips = 100
+			Expect(RegisterERC20Token(token)).Should(Succeed())
+			Expect(AdditionalTransactionFee(tokens.Token{Name: "ONLY", Decimals: 8, Blockchain: LITECOIN}, big.NewInt(10000))).Should(BeNil())
+		})
+	})
+})
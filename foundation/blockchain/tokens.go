@@ -0,0 +1,46 @@
+package blockchain
+
+import (
+	"strings"
+
+	"github.com/renproject/tokens"
+)
+
+// The blockchains that are supported by swapperd, but not by the tokens
+// package.
+const (
+	LITECOIN    = tokens.BlockchainName("litecoin")
+	BITCOINCASH = tokens.BlockchainName("bitcoincash")
+)
+
+// The tokens that are supported by swapperd, but not by the tokens package.
+const (
+	NameLTC = tokens.Name("LTC")
+	NameBCH = tokens.Name("BCH")
+)
+
+var (
+	LTC = tokens.Token{Name: NameLTC, Decimals: 8, Blockchain: LITECOIN}
+	BCH = tokens.Token{Name: NameBCH, Decimals: 8, Blockchain: BITCOINCASH}
+)
+
+// UTXOTokens are the native tokens of the Bitcoin forks that are supported by
+// swapperd.
+var UTXOTokens = []tokens.Token{LTC, BCH}
+
+// PatchToken returns the token with the given name. It extends the tokens
+// package with the tokens that are supported by swapperd, and the registered
+// ERC20 tokens.
+func PatchToken(name string) (tokens.Token, error) {
+	switch strings.ToLower(name) {
+	case "ltc", "litecoin":
+		return LTC, nil
+	case "bch", "bitcoincash", "bitcoin-cash":
+		return BCH, nil
+	default:
+		if token, ok := RegisteredERC20Token(name); ok {
+			return token.Token(), nil
+		}
+		return tokens.PatchToken(name)
+	}
+}
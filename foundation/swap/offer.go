@@ -0,0 +1,25 @@
+package swap
+
+// An Offer is a swap proposed by another swapperd. The Swap is the mirror of a
+// swap that the other swapperd initiates, signed by its id public key, and is
+// executed by posting it as the responder. Offers are made to the password
+// whose offer policy trusts the other swapperd, and PasswordHash is the hash
+// of that password.
+type Offer struct {
+	ID           SwapID   `json:"id"`
+	Swap         SwapBlob `json:"swap"`
+	Signature    string   `json:"signature"`
+	PublicKey    string   `json:"publicKey"`
+	Timestamp    int64    `json:"timestamp"`
+	PasswordHash string   `json:"passwordHash,omitempty"`
+
+	// Accepted offers are kept until they expire, so that they cannot be
+	// offered again.
+	Accepted bool `json:"accepted,omitempty"`
+}
+
+// Expired returns true once the responder's timelock of the swap has expired,
+// after which the swap can no longer be accepted.
+func (offer Offer) Expired(now int64) bool {
+	return now >= offer.Swap.ResponderTimeLock()
+}
@@ -0,0 +1,147 @@
+package swap
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/renproject/tokens"
+)
+
+// SpendingPeriod is the number of seconds over which the daily limits of a
+// SpendingPolicy apply.
+const SpendingPeriod = int64(24 * 60 * 60)
+
+// The rules of a SpendingPolicy that can be violated.
+const (
+	RulePerTransactionLimit = "perTransactionLimit"
+	RuleDailyLimit          = "dailyLimit"
+	RuleAllowedPairs        = "allowedPairs"
+	RuleMaxBrokerFee        = "maxBrokerFee"
+	RuleMinRate             = "minRate"
+)
+
+// A SpendingPolicy guards the swaps and transfers executed by swapperd. All
+// amounts are in the smallest unit of their token, and rules that are not
+// configured are not enforced.
+type SpendingPolicy struct {
+	// Limits are the maximum amounts of a token that can be sent by a single
+	// swap or transfer, and by all swaps and transfers within a day.
+	Limits map[tokens.Name]SpendingLimit `json:"limits,omitempty"`
+
+	// AllowedPairs are the token pairs that can be swapped. They are of the
+	// form "BTC/WBTC" and apply to both directions of the pair.
+	AllowedPairs []string `json:"allowedPairs,omitempty"`
+
+	// MaxBrokerFee is the maximum broker fee of a swap in BIPs.
+	MaxBrokerFee int64 `json:"maxBrokerFee,omitempty"`
+
+	// MinRates are the minimum amounts of the receive token, per unit of the
+	// send token, that swaps of a pair can be executed at. The keys are of
+	// the form "BTC/WBTC", and the rates are decimals of whole tokens such as
+	// "0.995".
+	MinRates map[string]string `json:"minRates,omitempty"`
+}
+
+// A SpendingLimit limits the amounts of a token that are sent.
+type SpendingLimit struct {
+	PerTransaction string `json:"perTransaction,omitempty"`
+	Daily          string `json:"daily,omitempty"`
+}
+
+// A PolicyViolation is returned for swaps and transfers that are rejected by
+// the SpendingPolicy.
+type PolicyViolation struct {
+	Rule  string      `json:"rule"`
+	Token tokens.Name `json:"token,omitempty"`
+	Pair  string      `json:"pair,omitempty"`
+	Limit string      `json:"limit,omitempty"`
+	Value string      `json:"value"`
+}
+
+func (violation PolicyViolation) Error() string {
+	switch violation.Rule {
+	case RulePerTransactionLimit:
+		return fmt.Sprintf("policy violation: %s %s exceeds the per transaction limit of %s", violation.Value, violation.Token, violation.Limit)
+	case RuleDailyLimit:
+		return fmt.Sprintf("policy violation: %s %s sent today exceeds the daily limit of %s", violation.Value, violation.Token, violation.Limit)
+	case RuleAllowedPairs:
+		return fmt.Sprintf("policy violation: pair %s is not allowed", violation.Pair)
+	case RuleMaxBrokerFee:
+		return fmt.Sprintf("policy violation: broker fee of %s bips exceeds the maximum of %s bips", violation.Value, violation.Limit)
+	case RuleMinRate:
+		return fmt.Sprintf("policy violation: rate %s of pair %s is below the minimum of %s", violation.Value, violation.Pair, violation.Limit)
+	default:
+		return fmt.Sprintf("policy violation: %s", violation.Rule)
+	}
+}
+
+// CheckSpend returns a PolicyViolation if sending the amount of the token
+// exceeds its limits, given the amount that has already been spent within
+// the SpendingPeriod.
+func (policy SpendingPolicy) CheckSpend(token tokens.Name, amount, spent *big.Int) error {
+	limits, ok := policy.Limits[token]
+	if !ok {
+		return nil
+	}
+	if limits.PerTransaction != "" {
+		limit, ok := new(big.Int).SetString(limits.PerTransaction, 10)
+		if !ok {
+			return fmt.Errorf("invalid per transaction limit of %s: %s", token, limits.PerTransaction)
+		}
+		if amount.Cmp(limit) > 0 {
+			return PolicyViolation{Rule: RulePerTransactionLimit, Token: token, Limit: limit.String(), Value: amount.String()}
+		}
+	}
+	if limits.Daily != "" {
+		limit, ok := new(big.Int).SetString(limits.Daily, 10)
+		if !ok {
+			return fmt.Errorf("invalid daily limit of %s: %s", token, limits.Daily)
+		}
+		if total := new(big.Int).Add(spent, amount); total.Cmp(limit) > 0 {
+			return PolicyViolation{Rule: RuleDailyLimit, Token: token, Limit: limit.String(), Value: total.String()}
+		}
+	}
+	return nil
+}
+
+// CheckSwap returns a PolicyViolation if a swap between the given tokens is
+// not allowed, or its broker fee or rate are not acceptable.
+func (policy SpendingPolicy) CheckSwap(send, receive tokens.Token, sendAmount, receiveAmount *big.Int, brokerFee int64) error {
+	pair := PairName(send.Name, receive.Name)
+	if len(policy.AllowedPairs) > 0 && !policy.pairAllowed(send.Name, receive.Name) {
+		return PolicyViolation{Rule: RuleAllowedPairs, Pair: pair, Value: pair}
+	}
+	if policy.MaxBrokerFee > 0 && brokerFee > policy.MaxBrokerFee {
+		return PolicyViolation{Rule: RuleMaxBrokerFee, Limit: fmt.Sprintf("%d", policy.MaxBrokerFee), Value: fmt.Sprintf("%d", brokerFee)}
+	}
+	if minRate, ok := policy.MinRates[pair]; ok {
+		min, ok := new(big.Rat).SetString(minRate)
+		if !ok {
+			return fmt.Errorf("invalid minimum rate of %s: %s", pair, minRate)
+		}
+		if sendAmount.Sign() <= 0 {
+			return fmt.Errorf("invalid send amount: %s", sendAmount)
+		}
+		rate := new(big.Rat).SetFrac(
+			new(big.Int).Mul(receiveAmount, decimalUnit(int64(send.Decimals))),
+			new(big.Int).Mul(sendAmount, decimalUnit(int64(receive.Decimals))),
+		)
+		if rate.Cmp(min) < 0 {
+			return PolicyViolation{Rule: RuleMinRate, Pair: pair, Limit: minRate, Value: rate.FloatString(int(receive.Decimals))}
+		}
+	}
+	return nil
+}
+
+func (policy SpendingPolicy) pairAllowed(send, receive tokens.Name) bool {
+	for _, pair := range policy.AllowedPairs {
+		if pair == PairName(send, receive) || pair == PairName(receive, send) {
+			return true
+		}
+	}
+	return false
+}
+
+func decimalUnit(decimals int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(decimals), nil)
+}
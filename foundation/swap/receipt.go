@@ -10,20 +10,23 @@ import (
 
 // The SwapReceipt contains the swap details and the status.
 type SwapReceipt struct {
-	ID            SwapID              `json:"id"`
-	SendToken     tokens.Name         `json:"sendToken"`
-	ReceiveToken  tokens.Name         `json:"receiveToken"`
-	SendAmount    string              `json:"sendAmount"`
-	ReceiveAmount string              `json:"receiveAmount"`
-	SendCost      blockchain.CostBlob `json:"sendCost"`
-	ReceiveCost   blockchain.CostBlob `json:"receiveCost"`
-	Timestamp     int64               `json:"timestamp"`
-	TimeLock      int64               `json:"timeLock"`
-	Status        int                 `json:"status"`
-	Delay         bool                `json:"delay"`
-	DelayInfo     json.RawMessage     `json:"delayInfo,omitempty"`
-	Active        bool                `json:"active"`
-	PasswordHash  string              `json:"passwordHash,omitempty"`
+	ID              SwapID              `json:"id"`
+	SendToken       tokens.Name         `json:"sendToken"`
+	ReceiveToken    tokens.Name         `json:"receiveToken"`
+	SendAmount      string              `json:"sendAmount"`
+	BrokerFee       int64               `json:"brokerFee,omitempty"` // in BIPs or (1/10000)
+	ReceiveAmount   string              `json:"receiveAmount"`
+	SendCost        blockchain.CostBlob `json:"sendCost"`
+	ReceiveCost     blockchain.CostBlob `json:"receiveCost"`
+	SendContract    ContractDetails     `json:"sendContract"`
+	ReceiveContract ContractDetails     `json:"receiveContract"`
+	Timestamp       int64               `json:"timestamp"`
+	TimeLock        int64               `json:"timeLock"`
+	Status          int                 `json:"status"`
+	Delay           bool                `json:"delay"`
+	DelayInfo       json.RawMessage     `json:"delayInfo,omitempty"`
+	Active          bool                `json:"active"`
+	PasswordHash    string              `json:"passwordHash,omitempty"`
 }
 
 // NewSwapReceipt returns a SwapReceipt from a swapBlob.
@@ -33,6 +36,7 @@ func NewSwapReceipt(blob SwapBlob) SwapReceipt {
 		SendToken:     blob.SendToken,
 		ReceiveToken:  blob.ReceiveToken,
 		SendAmount:    blob.SendAmount,
+		BrokerFee:     blob.BrokerFee,
 		ReceiveAmount: blob.ReceiveAmount,
 		SendCost:      blockchain.CostBlob{},
 		ReceiveCost:   blockchain.CostBlob{},
@@ -46,11 +50,78 @@ func NewSwapReceipt(blob SwapBlob) SwapReceipt {
 	}
 }
 
+// ContractDetails holds the on-chain identifiers of one leg of a swap, so that
+// it can be checked independently on a block explorer.
+type ContractDetails struct {
+	ContractID     string `json:"contractId,omitempty"`
+	InitiateTxHash string `json:"initiateTxHash,omitempty"`
+	AuditTxHash    string `json:"auditTxHash,omitempty"`
+	RedeemTxHash   string `json:"redeemTxHash,omitempty"`
+	RefundTxHash   string `json:"refundTxHash,omitempty"`
+
+	// AuditConfirmations is the number of confirmations of the audited
+	// transaction, when it was last audited.
+	AuditConfirmations int64 `json:"auditConfirmations,omitempty"`
+}
+
+// Merge copies the non-empty fields of the given details, so that details
+// learnt in earlier attempts are not lost.
+func (details *ContractDetails) Merge(other ContractDetails) {
+	if other.ContractID != "" {
+		details.ContractID = other.ContractID
+	}
+	if other.InitiateTxHash != "" {
+		details.InitiateTxHash = other.InitiateTxHash
+	}
+	if other.AuditTxHash != "" {
+		details.AuditTxHash = other.AuditTxHash
+	}
+	if other.RedeemTxHash != "" {
+		details.RedeemTxHash = other.RedeemTxHash
+	}
+	if other.RefundTxHash != "" {
+		details.RefundTxHash = other.RefundTxHash
+	}
+	if other.AuditConfirmations != 0 {
+		details.AuditConfirmations = other.AuditConfirmations
+	}
+}
+
 type ReceiptUpdate struct {
 	ID     SwapID
 	Update func(receipt *SwapReceipt)
+
+	// Error is the error, if any, that caused the update. It is recorded in
+	// the event log of the swap.
+	Error error
 }
 
 func NewReceiptUpdate(id SwapID, update func(receipt *SwapReceipt)) ReceiptUpdate {
-	return ReceiptUpdate{id, update}
+	return ReceiptUpdate{ID: id, Update: update}
+}
+
+// A SwapEvent records a transition in the state of a swap.
+type SwapEvent struct {
+	Timestamp   int64               `json:"timestamp"`
+	OldStatus   int                 `json:"oldStatus"`
+	NewStatus   int                 `json:"newStatus"`
+	Error       string              `json:"error,omitempty"`
+	SendCost    blockchain.CostBlob `json:"sendCost"`
+	ReceiveCost blockchain.CostBlob `json:"receiveCost"`
+}
+
+// NewSwapEvent returns a SwapEvent for the transition of the given receipt
+// from the old status to its current status.
+func NewSwapEvent(oldStatus int, receipt SwapReceipt, err error) SwapEvent {
+	event := SwapEvent{
+		Timestamp:   time.Now().Unix(),
+		OldStatus:   oldStatus,
+		NewStatus:   receipt.Status,
+		SendCost:    receipt.SendCost,
+		ReceiveCost: receipt.ReceiveCost,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
 }
@@ -0,0 +1,100 @@
+package swap
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// ErrSecretNotFound is returned when a swap does not have a stored secret,
+// which is the case for swaps created before secrets were stored.
+var ErrSecretNotFound = fmt.Errorf("secret not found")
+
+const (
+	secretSaltLength = 16
+	secretScryptN    = 1 << 14
+	secretScryptR    = 8
+	secretScryptP    = 1
+)
+
+// NewSecret returns a random swap secret.
+func NewSecret() ([32]byte, error) {
+	secret := [32]byte{}
+	if _, err := rand.Read(secret[:]); err != nil {
+		return secret, fmt.Errorf("cannot generate secret: %v", err)
+	}
+	return secret, nil
+}
+
+// LegacySecret returns the secret of swaps created before secrets were
+// generated randomly, which is derived from the password and the swap id.
+func LegacySecret(password string, id SwapID) [32]byte {
+	return sha3.Sum256(append([]byte(password), []byte(id)...))
+}
+
+// EncryptSecret encrypts the secret using AES-GCM, under a key derived from
+// the password using scrypt. The result is the base64 encoding of the salt,
+// the nonce and the ciphertext.
+func EncryptSecret(password string, secret [32]byte) (string, error) {
+	salt := make([]byte, secretSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	aead, err := secretCipher(password, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	data := append(salt, nonce...)
+	data = aead.Seal(data, nonce, secret[:], nil)
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecryptSecret decrypts a secret encrypted by EncryptSecret.
+func DecryptSecret(password string, encryptedSecret string) ([32]byte, error) {
+	secret := [32]byte{}
+	data, err := base64.StdEncoding.DecodeString(encryptedSecret)
+	if err != nil {
+		return secret, fmt.Errorf("corrupted secret: %v", err)
+	}
+	if len(data) < secretSaltLength {
+		return secret, fmt.Errorf("corrupted secret")
+	}
+	aead, err := secretCipher(password, data[:secretSaltLength])
+	if err != nil {
+		return secret, err
+	}
+	data = data[secretSaltLength:]
+	if len(data) < aead.NonceSize() {
+		return secret, fmt.Errorf("corrupted secret")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return secret, fmt.Errorf("cannot decrypt secret: %v", err)
+	}
+	if len(plaintext) != 32 {
+		return secret, fmt.Errorf("corrupted secret")
+	}
+	copy(secret[:], plaintext)
+	return secret, nil
+}
+
+func secretCipher(password string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(password), salt, secretScryptN, secretScryptR, secretScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
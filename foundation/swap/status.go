@@ -12,4 +12,5 @@ const (
 	RefundFailed
 	Cancelled
 	Expired
+	RefundPending
 )
@@ -3,6 +3,7 @@ package swap
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"reflect"
@@ -13,6 +14,11 @@ import (
 
 const ExpiryUnit = int64(2 * 60 * 60)
 
+// LegacyTimeLockMargin is the number of seconds by which the responder's
+// timelock expires before the initiator's timelock, in swaps that were
+// negotiated without a TimeLockMargin.
+const LegacyTimeLockMargin = int64(24 * 60 * 60)
+
 // TODO: Rename to ID
 // A SwapID uniquely identifies a Swap that is being executed.
 type SwapID string
@@ -37,6 +43,32 @@ type Swap struct {
 	FundingAddress  string
 	BrokerAddress   string
 	Speed           blockchain.TxExecutionSpeed
+
+	// Confirmations is the number of confirmations that the funding of the
+	// contract needs before it passes the audit.
+	Confirmations int64
+
+	// ScriptType is the type of the Bitcoin script of the contract.
+	ScriptType ScriptType
+}
+
+// A ScriptType is the type of the script that holds the Bitcoin leg of a swap.
+// Swaps without a script type use P2SH.
+type ScriptType string
+
+const (
+	ScriptTypeP2SH  = ScriptType("p2sh")
+	ScriptTypeP2WSH = ScriptType("p2wsh")
+)
+
+// Verify returns an error if the script type is not supported.
+func (scriptType ScriptType) Verify() error {
+	switch scriptType {
+	case "", ScriptTypeP2SH, ScriptTypeP2WSH:
+		return nil
+	default:
+		return fmt.Errorf("unsupported bitcoin script type: %s", scriptType)
+	}
 }
 
 // A SwapBlob is used to encode a Swap for storage and transmission.
@@ -57,16 +89,61 @@ type SwapBlob struct {
 	SecretHash          string `json:"secretHash"`
 	ShouldInitiateFirst bool   `json:"shouldInitiateFirst"`
 
+	// TimeLockMargin is the number of seconds by which the responder's
+	// timelock expires before the initiator's TimeLock. Swaps negotiated
+	// without a margin use the LegacyTimeLockMargin.
+	TimeLockMargin int64 `json:"timeLockMargin,omitempty"`
+
 	Delay            bool            `json:"delay,omitempty"`
 	DelayInfo        json.RawMessage `json:"delayInfo,omitempty"`
 	DelayCallbackURL string          `json:"delayCallbackUrl,omitempty"`
 
+	// DelayDeadline is the unix time by which a delayed swap has to be
+	// filled, after which it expires. Swaps stored without a deadline expire
+	// at their TimeLock.
+	DelayDeadline int64 `json:"delayDeadline,omitempty"`
+
+	// BrokerPublicKey is the base64 encoded public key of the broker that
+	// fills a delayed swap. Fills pushed to swapperd have to be signed by it,
+	// swaps without a broker public key can only be filled by the callback.
+	BrokerPublicKey string `json:"brokerPublicKey,omitempty"`
+
 	BrokerFee              int64  `json:"brokerFee,omitempty"` // in BIPs or (1/10000)
 	BrokerSendTokenAddr    string `json:"brokerSendTokenAddr,omitempty"`
 	BrokerReceiveTokenAddr string `json:"brokerReceiveTokenAddr,omitempty"`
 
+	// BitcoinScriptType is the type of the scripts of the Bitcoin, and
+	// Bitcoin fork, legs of the swap. It is chosen by the initiator, and both
+	// parties must use it.
+	BitcoinScriptType ScriptType `json:"bitcoinScriptType,omitempty"`
+
+	// CounterpartyPublicKey is the id of the counterparty that signed the
+	// swap, and Signature is its signature of the swap as it was mirrored by
+	// the counterparty. Swaps with a counterparty public key are rejected
+	// unless the signature matches.
+	CounterpartyPublicKey string `json:"counterpartyPublicKey,omitempty"`
+	Signature             string `json:"signature,omitempty"`
+
 	WithdrawAddress string `json:"withdrawAddress,omitempty"`
 	ResponseURL     string `json:"responseURL,omitempty"`
 	Password        string `json:"password,omitempty"`
 	PasswordHash    string `json:"passwordHash,omitempty"`
+
+	// Cancelled is set locally when the user abandons the swap, it is never
+	// sent to the counterparty.
+	Cancelled bool `json:"cancelled,omitempty"`
+
+	// Secret is only held in memory, it is stored encrypted in the secret
+	// store. It is empty for responders and for swaps that use the
+	// LegacySecret.
+	Secret [32]byte `json:"-"`
+}
+
+// ResponderTimeLock returns the time at which the responder's timelock
+// expires, which is TimeLockMargin seconds before the initiator's TimeLock.
+func (blob SwapBlob) ResponderTimeLock() int64 {
+	if blob.TimeLockMargin == 0 {
+		return blob.TimeLock - LegacyTimeLockMargin
+	}
+	return blob.TimeLock - blob.TimeLockMargin
 }
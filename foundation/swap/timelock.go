@@ -0,0 +1,83 @@
+package swap
+
+import (
+	"fmt"
+
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/tokens"
+)
+
+// DefaultTimeLockPolicy is used for the durations and safety margins that are
+// not configured.
+var DefaultTimeLockPolicy = TimeLockPolicy{
+	DefaultDuration: 3 * ExpiryUnit,
+	SafetyMargins: map[tokens.BlockchainName]int64{
+		tokens.BITCOIN:         ExpiryUnit,
+		tokens.ETHEREUM:        ExpiryUnit,
+		tokens.ERC20:           ExpiryUnit,
+		blockchain.LITECOIN:    ExpiryUnit,
+		blockchain.BITCOINCASH: ExpiryUnit,
+	},
+}
+
+// A TimeLockPolicy determines the timelocks of new swaps. All durations are in
+// seconds.
+type TimeLockPolicy struct {
+	// DefaultDuration is the time until the initiator's timelock expires.
+	DefaultDuration int64 `json:"defaultDuration"`
+
+	// SafetyMargins are the minimum times, per blockchain, between the
+	// expiry of the responder's timelock and the expiry of the initiator's
+	// timelock.
+	SafetyMargins map[tokens.BlockchainName]int64 `json:"safetyMargins"`
+
+	// Durations override the default duration for token pairs. The keys are
+	// of the form "BTC/WBTC" and apply to both directions of the pair.
+	Durations map[string]int64 `json:"durations,omitempty"`
+}
+
+// WithDefaults returns the policy with every value that has not been
+// configured taken from the DefaultTimeLockPolicy.
+func (policy TimeLockPolicy) WithDefaults() TimeLockPolicy {
+	if policy.DefaultDuration == 0 {
+		policy.DefaultDuration = DefaultTimeLockPolicy.DefaultDuration
+	}
+	margins := map[tokens.BlockchainName]int64{}
+	for chain, margin := range DefaultTimeLockPolicy.SafetyMargins {
+		margins[chain] = margin
+	}
+	for chain, margin := range policy.SafetyMargins {
+		margins[chain] = margin
+	}
+	policy.SafetyMargins = margins
+	return policy
+}
+
+// Duration returns the time until the initiator's timelock of a swap between
+// the given tokens expires.
+func (policy TimeLockPolicy) Duration(send, receive tokens.Token) int64 {
+	if duration, ok := policy.Durations[PairName(send.Name, receive.Name)]; ok {
+		return duration
+	}
+	if duration, ok := policy.Durations[PairName(receive.Name, send.Name)]; ok {
+		return duration
+	}
+	return policy.DefaultDuration
+}
+
+// SafetyMargin returns the minimum time between the expiries of the two
+// timelocks of a swap between the given tokens. It is the largest safety
+// margin of the two blockchains.
+func (policy TimeLockPolicy) SafetyMargin(send, receive tokens.Token) int64 {
+	margin := policy.SafetyMargins[send.Blockchain]
+	if policy.SafetyMargins[receive.Blockchain] > margin {
+		margin = policy.SafetyMargins[receive.Blockchain]
+	}
+	return margin
+}
+
+// PairName returns the name of the token pair used as a key in the
+// TimeLockPolicy durations.
+func PairName(send, receive tokens.Name) string {
+	return fmt.Sprintf("%s/%s", send, receive)
+}
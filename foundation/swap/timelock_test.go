@@ -0,0 +1,69 @@
+package swap_test
+
+import (
+	"testing/quick"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/swapperd/foundation/swap"
+
+	"github.com/renproject/swapperd/foundation/blockchain"
+	"github.com/renproject/swapperd/testutils"
+	"github.com/renproject/tokens"
+)
+
+var _ = Describe("Timelocks", func() {
+	Context("when configuring a timelock policy", func() {
+		It("should take the values that are not configured from the default policy", func() {
+			policy := TimeLockPolicy{
+				SafetyMargins: map[tokens.BlockchainName]int64{tokens.BITCOIN: 4 * ExpiryUnit},
+			}.WithDefaults()
+			Expect(policy.DefaultDuration).Should(Equal(DefaultTimeLockPolicy.DefaultDuration))
+			Expect(policy.SafetyMargins[tokens.BITCOIN]).Should(Equal(4 * ExpiryUnit))
+			Expect(policy.SafetyMargins[tokens.ETHEREUM]).Should(Equal(DefaultTimeLockPolicy.SafetyMargins[tokens.ETHEREUM]))
+			Expect(DefaultTimeLockPolicy.SafetyMargins[tokens.BITCOIN]).Should(Equal(ExpiryUnit))
+		})
+
+		It("should use the duration of a token pair in both directions", func() {
+			policy := TimeLockPolicy{
+				DefaultDuration: 3 * ExpiryUnit,
+				Durations:       map[string]int64{PairName(tokens.NameBTC, tokens.NameWBTC): 6 * ExpiryUnit},
+			}
+			Expect(policy.Duration(tokens.BTC, tokens.WBTC)).Should(Equal(6 * ExpiryUnit))
+			Expect(policy.Duration(tokens.WBTC, tokens.BTC)).Should(Equal(6 * ExpiryUnit))
+			Expect(policy.Duration(tokens.BTC, tokens.ETH)).Should(Equal(3 * ExpiryUnit))
+		})
+
+		It("should use the largest safety margin of the two blockchains", func() {
+			policy := TimeLockPolicy{
+				SafetyMargins: map[tokens.BlockchainName]int64{
+					tokens.BITCOIN:      3 * ExpiryUnit,
+					tokens.ETHEREUM:     ExpiryUnit,
+					blockchain.LITECOIN: 2 * ExpiryUnit,
+				},
+			}
+			Expect(policy.SafetyMargin(tokens.BTC, tokens.ETH)).Should(Equal(3 * ExpiryUnit))
+			Expect(policy.SafetyMargin(tokens.ETH, tokens.BTC)).Should(Equal(3 * ExpiryUnit))
+			Expect(policy.SafetyMargin(tokens.ETH, tokens.ETH)).Should(Equal(ExpiryUnit))
+		})
+	})
+
+	Context("when calculating the responder's timelock", func() {
+		It("should expire the timelock margin before the initiator's timelock", func() {
+			test := func(timeLock int64, margin uint16) bool {
+				blob := SwapBlob{TimeLock: timeLock, TimeLockMargin: int64(margin) + 1}
+				return blob.ResponderTimeLock() == timeLock-int64(margin)-1
+			}
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+		})
+
+		It("should keep the legacy margin for swaps that were negotiated without a margin", func() {
+			test := func(timeLock int64) bool {
+				blob := SwapBlob{TimeLock: timeLock}
+				return blob.ResponderTimeLock() == timeLock-LegacyTimeLockMargin
+			}
+			Expect(quick.Check(test, testutils.DefaultQuickCheckConfig)).ShouldNot(HaveOccurred())
+			Expect(LegacyTimeLockMargin).Should(Equal(int64(24 * 60 * 60)))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package testutils
 
 import (
+	"encoding/json"
+	"fmt"
 	"sync"
 
 	"github.com/renproject/swapperd/foundation/swap"
@@ -20,7 +22,7 @@ func NewMockCallback(err error) *MockCallback {
 	}
 }
 
-func (callback *MockCallback) DelayCallback(swap swap.SwapBlob) (swap.SwapBlob, error) {
+func (callback *MockCallback) DelayCallback(swap swap.SwapBlob, password string) (swap.SwapBlob, error) {
 	callback.mu.Lock()
 	defer callback.mu.Unlock()
 	if !callback.flags[swap.ID] {
@@ -29,3 +31,18 @@ func (callback *MockCallback) DelayCallback(swap swap.SwapBlob) (swap.SwapBlob,
 	}
 	return swap, nil
 }
+
+func (callback *MockCallback) DelayFill(partialSwap swap.SwapBlob, message, signature []byte) (swap.SwapBlob, error) {
+	if len(signature) == 0 {
+		return partialSwap, fmt.Errorf("missing signature")
+	}
+	filledSwap := swap.SwapBlob{}
+	if err := json.Unmarshal(message, &filledSwap); err != nil {
+		return partialSwap, err
+	}
+	if filledSwap.ID != partialSwap.ID {
+		return partialSwap, fmt.Errorf("filled swap id does not match")
+	}
+	filledSwap.Delay = false
+	return filledSwap, nil
+}
@@ -0,0 +1,33 @@
+package testutils
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/renproject/swapperd/adapter/wallet"
+)
+
+// MockSigner signs with the same key for every password.
+type MockSigner struct {
+	Key *ecdsa.PrivateKey
+}
+
+func NewMockSigner() *MockSigner {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	return &MockSigner{key}
+}
+
+func (signer *MockSigner) ECDSASigner(password string) (wallet.ECDSASigner, error) {
+	return signer, nil
+}
+
+func (signer *MockSigner) PublicKey() ecdsa.PublicKey {
+	return signer.Key.PublicKey
+}
+
+func (signer *MockSigner) Sign(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, signer.Key)
+}
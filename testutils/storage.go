@@ -4,6 +4,8 @@ import (
 	"errors"
 	"sync"
 
+	"github.com/renproject/swapperd/adapter/wallet"
+	"github.com/renproject/swapperd/core/wallet/schedule"
 	"github.com/renproject/swapperd/core/wallet/transfer"
 	"github.com/renproject/swapperd/foundation/swap"
 )
@@ -11,13 +13,21 @@ import (
 type MockStorage struct {
 	mu        *sync.RWMutex
 	receipts  map[swap.SwapID]swap.SwapReceipt
+	events    map[swap.SwapID][]swap.SwapEvent
 	transfers map[string]transfer.TransferReceipt
+	schedules map[schedule.ScheduleID]schedule.Schedule
+	offers    map[swap.SwapID]swap.Offer
+	entries   map[string]wallet.AddressBookEntry
 }
 
 func NewMockStorage() *MockStorage {
 	return &MockStorage{
-		mu:       new(sync.RWMutex),
-		receipts: map[swap.SwapID]swap.SwapReceipt{},
+		mu:        new(sync.RWMutex),
+		receipts:  map[swap.SwapID]swap.SwapReceipt{},
+		events:    map[swap.SwapID][]swap.SwapEvent{},
+		schedules: map[schedule.ScheduleID]schedule.Schedule{},
+		offers:    map[swap.SwapID]swap.Offer{},
+		entries:   map[string]wallet.AddressBookEntry{},
 	}
 }
 
@@ -57,11 +67,22 @@ func (store *MockStorage) UpdateReceipt(update swap.ReceiptUpdate) error {
 		return errors.New("swap not found")
 	}
 
+	oldStatus := receipt.Status
 	update.Update(&receipt)
 	store.receipts[update.ID] = receipt
+	if oldStatus != receipt.Status || update.Error != nil {
+		store.events[update.ID] = append(store.events[update.ID], swap.NewSwapEvent(oldStatus, receipt, update.Error))
+	}
 	return nil
 }
 
+func (store *MockStorage) SwapEvents(id swap.SwapID) ([]swap.SwapEvent, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.events[id], nil
+}
+
 func (store *MockStorage) PutTransfer(receipt transfer.TransferReceipt) error {
 	store.mu.Lock()
 	defer store.mu.Unlock()
@@ -81,3 +102,85 @@ func (store *MockStorage) Transfers() ([]transfer.TransferReceipt, error) {
 
 	return transfers, nil
 }
+
+func (store *MockStorage) PutSchedule(schedule schedule.Schedule) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	schedule.Template.Password = ""
+	store.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (store *MockStorage) DeleteSchedule(id schedule.ScheduleID) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.schedules, id)
+	return nil
+}
+
+func (store *MockStorage) Schedules() ([]schedule.Schedule, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	schedules := []schedule.Schedule{}
+	for _, schedule := range store.schedules {
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (store *MockStorage) PutOffer(offer swap.Offer) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.offers[offer.ID] = offer
+	return nil
+}
+
+func (store *MockStorage) DeleteOffer(id swap.SwapID) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.offers, id)
+	return nil
+}
+
+func (store *MockStorage) Offers() ([]swap.Offer, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	offers := []swap.Offer{}
+	for _, offer := range store.offers {
+		offers = append(offers, offer)
+	}
+	return offers, nil
+}
+
+func (store *MockStorage) PutAddressBookEntry(entry wallet.AddressBookEntry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.entries[entry.ID] = entry
+	return nil
+}
+
+func (store *MockStorage) DeleteAddressBookEntry(id string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.entries, id)
+	return nil
+}
+
+func (store *MockStorage) AddressBook() ([]wallet.AddressBookEntry, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	entries := []wallet.AddressBookEntry{}
+	for _, entry := range store.entries {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}